@@ -1,4 +1,4 @@
-import React, { useState, useEffect } from "react";
+import React, { useState, useEffect, useRef } from "react";
 import axios from "axios";
 import {
   RefreshCw,
@@ -13,6 +13,7 @@ import {
   ShoppingCart,
   TrendingDown,
 } from "lucide-react";
+import { criarEConfirmarTransacao } from "../services/transacoes";
 
 interface OperationData {
   tipo: string;
@@ -48,6 +49,12 @@ interface ApiStatus {
   online: boolean;
 }
 
+// Gera o valor aleatório do cabeçalho Idempotency-Key
+const newIdempotencyKey = (): string =>
+  Array.from(crypto.getRandomValues(new Uint8Array(16)), (b) =>
+    b.toString(16).padStart(2, "0")
+  ).join("");
+
 const ExchangeRate: React.FC = () => {
   const [operation, setOperation] = useState<OperationData>({
     tipo: "Compra",
@@ -69,6 +76,11 @@ const ExchangeRate: React.FC = () => {
   const [lastUpdate, setLastUpdate] = useState<string>("");
   const [apiStatus, setApiStatus] = useState<ApiStatus>({ online: false });
   const [availableCurrencies, setAvailableCurrencies] = useState<string[]>([]);
+  // Chave de idempotência da operação em envio: cliques repetidos e novas
+  // tentativas do mesmo pedido reutilizam a chave e não duplicam a transação
+  const pendingOperation = useRef<{ key: string; payload: string } | null>(
+    null
+  );
 
   const API_BASE = "/api";
 
@@ -150,15 +162,32 @@ const ExchangeRate: React.FC = () => {
     setError((prev) => ({ ...prev, operation: undefined }));
     setResult(null);
 
-    try {
-      const response = await axios.post(`${API_BASE}/transacoes`, {
-        tipo: operation.tipo,
-        moeda_origem: operation.moedaOrigem,
-        moeda_destino: operation.moedaDestino,
-        valor_origem: valor,
-      });
+    const payload = {
+      tipo: operation.tipo,
+      moeda_origem: operation.moedaOrigem,
+      moeda_destino: operation.moedaDestino,
+      valor_origem: valor,
+    };
+    const serializedPayload = JSON.stringify(payload);
+    if (pendingOperation.current?.payload !== serializedPayload) {
+      pendingOperation.current = {
+        key: newIdempotencyKey(),
+        payload: serializedPayload,
+      };
+    }
 
-      setResult(response.data);
+    try {
+      // Se a confirmação falhar, a mesma chave é reenviada na nova tentativa
+      // e a transação pendente já criada é confirmada, sem duplicá-la
+      const transacao = await criarEConfirmarTransacao<OperationResult>(
+        axios,
+        API_BASE,
+        payload,
+        pendingOperation.current.key
+      );
+
+      pendingOperation.current = null;
+      setResult(transacao);
       setApiStatus({ online: true });
 
       setOperation({
@@ -531,7 +560,7 @@ const ExchangeRate: React.FC = () => {
                     <span className="text-sm text-gray-600">Status:</span>
                     <span
                       className={`px-3 py-1 rounded-full text-xs font-medium ${
-                        result.status === "Concluída"
+                        result.status === "Concluído"
                           ? "bg-green-100 text-green-700"
                           : result.status === "Pendente"
                           ? "bg-yellow-100 text-yellow-700"
@@ -0,0 +1,59 @@
+import {
+  ClienteHttp,
+  criarEConfirmarTransacao,
+  STATUS_PENDENTE,
+} from "./transacoes";
+
+interface Chamada {
+  url: string;
+  data?: unknown;
+  headers?: Record<string, string>;
+}
+
+// Cliente falso que responde às URLs configuradas e registra as chamadas
+const clienteFalso = (respostas: Record<string, unknown>) => {
+  const chamadas: Chamada[] = [];
+  const http: ClienteHttp = {
+    post: async <T>(
+      url: string,
+      data?: unknown,
+      config?: { headers?: Record<string, string> }
+    ) => {
+      chamadas.push({ url, data, headers: config?.headers });
+      return { data: respostas[url] as T };
+    },
+  };
+  return { http, chamadas };
+};
+
+test("cria a transação e a confirma", async () => {
+  const { http, chamadas } = clienteFalso({
+    "/api/transacoes": { id: 7, status: STATUS_PENDENTE },
+    "/api/transacoes/7/confirmar": { id: 7, status: "Concluído" },
+  });
+
+  const transacao = await criarEConfirmarTransacao(
+    http,
+    "/api",
+    { tipo: "Compra" },
+    "chave-1"
+  );
+
+  expect(transacao.status).toBe("Concluído");
+  expect(chamadas.map((c) => c.url)).toEqual([
+    "/api/transacoes",
+    "/api/transacoes/7/confirmar",
+  ]);
+  expect(chamadas[0].headers).toEqual({ "Idempotency-Key": "chave-1" });
+});
+
+test("não confirma de novo uma transação já confirmada", async () => {
+  const { http, chamadas } = clienteFalso({
+    "/api/transacoes": { id: 7, status: "Concluído" },
+  });
+
+  const transacao = await criarEConfirmarTransacao(http, "/api", {}, "chave-1");
+
+  expect(transacao.status).toBe("Concluído");
+  expect(chamadas).toHaveLength(1);
+});
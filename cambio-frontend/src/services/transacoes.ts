@@ -0,0 +1,39 @@
+// Status em que o servidor cria as transações; só elas precisam ser confirmadas
+export const STATUS_PENDENTE = "Pendente";
+
+// Parte do cliente HTTP (axios) usada aqui, para que os testes possam trocá-lo
+export interface ClienteHttp {
+  post<T>(
+    url: string,
+    data?: unknown,
+    config?: { headers?: Record<string, string> }
+  ): Promise<{ data: T }>;
+}
+
+export interface TransacaoCriada {
+  id: number;
+  status: string;
+}
+
+// Cria a transação e a confirma em seguida, já que o servidor cria toda
+// transação como Pendente. Uma repetição com a mesma chave de idempotência
+// recebe a transação já criada; se ela não estiver mais pendente (confirmada
+// numa tentativa anterior), a confirmação é dispensada.
+export async function criarEConfirmarTransacao<T extends TransacaoCriada>(
+  http: ClienteHttp,
+  apiBase: string,
+  payload: unknown,
+  idempotencyKey: string
+): Promise<T> {
+  const criada = await http.post<T>(`${apiBase}/transacoes`, payload, {
+    headers: { "Idempotency-Key": idempotencyKey },
+  });
+  if (criada.data.status !== STATUS_PENDENTE) {
+    return criada.data;
+  }
+
+  const confirmada = await http.post<T>(
+    `${apiBase}/transacoes/${criada.data.id}/confirmar`
+  );
+  return confirmada.data;
+}
@@ -1,12 +1,12 @@
 package cambio
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"sync"
 	"time"
+
+	"golang-project/moeda"
 )
 
 type SimpleExchangeResponse struct {
@@ -28,69 +28,119 @@ type ExchangeRateResponse struct {
 }
 
 type CambioClient struct {
-	baseURL string
-	timeout time.Duration
+	provedor RateProvider
+	registro *moeda.Registro
+
+	// Triangulação: quando pivo é informado, apenas a moeda pivô é buscada
+	// e as demais cotações são derivadas dela
+	pivo                   string
+	basesVerificacao       []string
+	toleranciaConsistencia float64
 }
 
-func NewCambioClient() *CambioClient {
-	return &CambioClient{
-		baseURL: "https://api.fxratesapi.com/latest",
-		timeout: 15 * time.Second,
+// NewCambioClient cria um cliente com a cadeia de provedores padrão. As
+// opções ajustam timeout, transporte, proxy, User-Agent, endereços e chaves
+// de API dos provedores; uma opção que não se aplica à cadeia padrão é
+// ignorada com um aviso.
+func NewCambioClient(opcoes ...OpcaoCliente) *CambioClient {
+	cliente, err := NewCambioClientConfigurado(PROVEDORES_PADRAO, "", opcoes...)
+	if err != nil {
+		fmt.Printf("Aviso: %v; usando provedores padrão sem as opções\n", err)
+		cliente, _ = NewCambioClientConfigurado(PROVEDORES_PADRAO, "")
 	}
+	return cliente
 }
 
-func (c *CambioClient) BuscarTaxasCambio(moedaBase string) (map[string]float64, error) {
-	client := &http.Client{
-		Timeout: c.timeout,
+// NewCambioClientConfigurado cria um cliente com a cadeia de provedores
+// informada e, se arquivoFallback for informado, a tabela offline ao final
+func NewCambioClientConfigurado(provedores []string, arquivoFallback string, opcoes ...OpcaoCliente) (*CambioClient, error) {
+	provedor, err := MontarProvedores(provedores, arquivoFallback, opcoes...)
+	if err != nil {
+		return nil, err
 	}
+	return NewCambioClientComProvedor(provedor), nil
+}
 
-	url := fmt.Sprintf("%s?base=%s", c.baseURL, moedaBase)
+// NewCambioClientComProvedor cria um cliente que busca taxas no provedor informado
+func NewCambioClientComProvedor(provedor RateProvider) *CambioClient {
+	return &CambioClient{
+		provedor: provedor,
+	}
+}
 
-	resp, err := client.Get(url)
+func (c *CambioClient) BuscarTaxasCambio(ctx context.Context, moedaBase string) (map[string]float64, error) {
+	cotacoes, err := c.provedor.BuscarTaxas(ctx, moedaBase)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer requisição para API: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return cotacoes.Taxas, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API retornou status %d", resp.StatusCode)
+// Saude informa o estado dos provedores que relatam saúde (circuitos)
+func (c *CambioClient) Saude() []SaudeProvedor {
+	if relator, ok := c.provedor.(RelatorSaude); ok {
+		return relator.Saude()
 	}
+	return nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao ler resposta da API: %w", err)
+// UsarMoedas define o registro de moedas buscadas por este cliente.
+// Sem registro, o cliente usa o registro global (moeda.Padrao).
+func (c *CambioClient) UsarMoedas(registro *moeda.Registro) {
+	c.registro = registro
+}
+
+func (c *CambioClient) moedas() *moeda.Registro {
+	if c.registro != nil {
+		return c.registro
 	}
+	return moeda.Padrao()
+}
 
-	var simpleData SimpleExchangeResponse
-	err = json.Unmarshal(body, &simpleData)
-	if err == nil && len(simpleData.Rates) > 0 {
-		return simpleData.Rates, nil
+// UsarTriangulacao ativa o modo de triangulação: uma única busca pela moeda
+// pivô gera a matriz completa. As bases de verificação, se informadas, são
+// buscadas diretamente e comparadas com as cotações derivadas.
+func (c *CambioClient) UsarTriangulacao(pivo string, tolerancia float64, basesVerificacao ...string) {
+	c.pivo = pivo
+	c.toleranciaConsistencia = tolerancia
+	c.basesVerificacao = basesVerificacao
+}
+
+// BuscarTaxasParaTodasMoedas busca as taxas de todas as moedas suportadas.
+// Falhas em moedas individuais são registradas no resultado; só retorna erro
+// quando nenhuma moeda pôde ser obtida ou o contexto foi cancelado.
+func (c *CambioClient) BuscarTaxasParaTodasMoedas(ctx context.Context) (*ResultadoTaxas, error) {
+	moedas := c.moedas().Codigos()
+
+	var resultado *ResultadoTaxas
+	if c.pivo != "" {
+		resultado = c.buscarPorTriangulacao(ctx, moedas)
+	} else {
+		resultado = c.buscarDireto(ctx, moedas)
 	}
 
-	var exchangeData ExchangeRateResponse
-	err = json.Unmarshal(body, &exchangeData)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao deserializar resposta da API: %w", err)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("busca de taxas cancelada: %w", err)
 	}
 
-	if exchangeData.Result != "" && exchangeData.Result != "success" {
-		return nil, fmt.Errorf("API retornou resultado: %s", exchangeData.Result)
+	if len(resultado.Taxas) == 0 {
+		return nil, fmt.Errorf("%w: %w", ErrTaxasIndisponiveis, resultado.Erro())
 	}
+	resultado.ObtidoEm = time.Now()
 
-	if len(exchangeData.ConversionRates) > 0 {
-		return exchangeData.ConversionRates, nil
+	if !resultado.Completo() {
+		fmt.Printf("Aviso: taxas indisponíveis para %v\n", resultado.MoedasComFalha())
 	}
 
-	return nil, fmt.Errorf("resposta da API não contém taxas válidas")
+	return resultado, nil
 }
 
-func (c *CambioClient) BuscarTaxasParaTodasMoedas() (map[string]map[string]float64, error) {
-	moedas := []string{"USD", "EUR", "BRL", "GBP", "JPY"}
-	taxasCompletas := make(map[string]map[string]float64)
+// buscarDireto faz uma requisição por moeda base, em paralelo
+func (c *CambioClient) buscarDireto(ctx context.Context, moedas []string) *ResultadoTaxas {
+	resultado := NewResultadoTaxas()
 
 	var mu sync.Mutex
 	var wg sync.WaitGroup
-	errors := make(chan error, len(moedas))
 
 	for _, moedaBase := range moedas {
 		wg.Add(1)
@@ -99,57 +149,64 @@ func (c *CambioClient) BuscarTaxasParaTodasMoedas() (map[string]map[string]float
 
 			fmt.Printf("Buscando taxas para %s...\n", moeda)
 
-			taxas, err := c.BuscarTaxasCambio(moeda)
+			cotacoes, err := c.provedor.BuscarTaxas(ctx, moeda)
 			if err != nil {
-				errors <- fmt.Errorf("erro ao buscar taxas para %s: %w", moeda, err)
+				mu.Lock()
+				resultado.Falhas[moeda] = err.Error()
+				mu.Unlock()
 				return
 			}
 
 			taxasFiltradas := make(map[string]float64)
 			for _, moedaDestino := range moedas {
 				if moedaDestino != moeda {
-					if taxa, existe := taxas[moedaDestino]; existe {
+					if taxa, existe := cotacoes.Taxas[moedaDestino]; existe {
 						taxasFiltradas[moedaDestino] = taxa
 					}
 				}
 			}
 
 			mu.Lock()
-			taxasCompletas[moeda] = taxasFiltradas
+			resultado.Taxas[moeda] = taxasFiltradas
+			resultado.Fontes[moeda] = cotacoes.Fonte
 			mu.Unlock()
-
-			// Sleep para evitar rate limiting (menor que antes pois é paralelo)
-			time.Sleep(100 * time.Millisecond)
 		}(moedaBase)
 	}
 
 	// Aguardar todas as goroutines terminarem
 	wg.Wait()
-	close(errors)
-
-	// Verificar se houve algum erro
-	select {
-	case err := <-errors:
-		if err != nil {
-			return nil, err
-		}
-	default:
-		// Nenhum erro
-	}
 
-	return taxasCompletas, nil
+	return resultado
 }
 
-func (c *CambioClient) CalcularConversao(valor float64, moedaOrigem, moedaDestino string, taxas map[string]map[string]float64) (float64, error) {
+// CalcularConversao converte o valor usando a tabela de taxas. A taxa é
+// aplicada com CASAS_TAXA casas e o resultado arredondado conforme a moeda de destino.
+func (c *CambioClient) CalcularConversao(valor moeda.Decimal, moedaOrigem, moedaDestino string, taxas map[string]map[string]float64) (*Conversao, error) {
+	registro := c.moedas()
+	conversao := &Conversao{
+		ValorOrigem:  registro.Arredondar(valor, moedaOrigem),
+		MoedaOrigem:  moedaOrigem,
+		MoedaDestino: moedaDestino,
+	}
+
 	if moedaOrigem == moedaDestino {
-		return valor, nil
+		conversao.Taxa = moeda.NewFromInt(1)
+		conversao.TaxaMedia = conversao.Taxa
+		conversao.ValorDestino = conversao.ValorOrigem
+		return conversao, nil
 	}
 
-	if taxasMoeda, existe := taxas[moedaOrigem]; existe {
-		if taxa, existeTaxa := taxasMoeda[moedaDestino]; existeTaxa {
-			return valor * taxa, nil
-		}
+	taxasMoeda, existe := taxas[moedaOrigem]
+	if !existe {
+		return nil, fmt.Errorf("taxa de câmbio não encontrada para %s -> %s", moedaOrigem, moedaDestino)
+	}
+	taxa, existeTaxa := taxasMoeda[moedaDestino]
+	if !existeTaxa {
+		return nil, fmt.Errorf("taxa de câmbio não encontrada para %s -> %s", moedaOrigem, moedaDestino)
 	}
 
-	return 0, fmt.Errorf("taxa de câmbio não encontrada para %s -> %s", moedaOrigem, moedaDestino)
+	conversao.Taxa = moeda.NewFromFloat(taxa).Round(CASAS_TAXA, moeda.ArredondamentoBancario)
+	conversao.TaxaMedia = conversao.Taxa
+	conversao.ValorDestino = registro.Arredondar(conversao.ValorOrigem.Mul(conversao.Taxa), moedaDestino)
+	return conversao, nil
 }
@@ -0,0 +1,46 @@
+package cambio
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBuscarTaxasParaTodasMoedasResultadoParcial(t *testing.T) {
+	cliente := NewCambioClientComProvedor(NewStaticProvider(map[string]map[string]float64{
+		"USD": {"BRL": 5.0, "EUR": 0.9},
+		"BRL": {"USD": 0.2},
+	}))
+
+	resultado, err := cliente.BuscarTaxasParaTodasMoedas(context.Background())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if !resultado.Parcial() {
+		t.Error("resultado deveria ser parcial")
+	}
+	if resultado.Taxas["USD"]["BRL"] != 5.0 {
+		t.Errorf("taxa USD->BRL esperada 5.0, obtida %v", resultado.Taxas["USD"]["BRL"])
+	}
+
+	falhas := resultado.MoedasComFalha()
+	esperadas := []string{"EUR", "GBP", "JPY"}
+	if len(falhas) != len(esperadas) {
+		t.Fatalf("falhas esperadas %v, obtidas %v", esperadas, falhas)
+	}
+	for i := range esperadas {
+		if falhas[i] != esperadas[i] {
+			t.Errorf("falhas esperadas %v, obtidas %v", esperadas, falhas)
+		}
+	}
+}
+
+func TestBuscarTaxasParaTodasMoedasSemNenhumaTaxa(t *testing.T) {
+	cliente := NewCambioClientComProvedor(NewStaticProvider(nil))
+
+	_, err := cliente.BuscarTaxasParaTodasMoedas(context.Background())
+	if !errors.Is(err, ErrTaxasIndisponiveis) {
+		t.Errorf("esperado ErrTaxasIndisponiveis, obtido %v", err)
+	}
+}
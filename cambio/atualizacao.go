@@ -0,0 +1,180 @@
+package cambio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// TEMPO_MAXIMO_ATUALIZACAO limita uma busca compartilhada, que não depende
+	// do contexto de nenhuma requisição em particular
+	TEMPO_MAXIMO_ATUALIZACAO = 60 * time.Second
+	// INTERVALO_NOVA_TENTATIVA é a espera da atualização automática após uma
+	// falha ou uma busca que não renovou o cache
+	INTERVALO_NOVA_TENTATIVA = time.Minute
+	// MAXIMO_OBSOLETO_PADRAO é a idade máxima de taxas vencidas que ainda podem ser servidas
+	MAXIMO_OBSOLETO_PADRAO = 24 * time.Hour
+)
+
+// atualizacaoEmAndamento é uma busca na API compartilhada por todos que
+// pedirem taxas enquanto ela estiver rodando
+type atualizacaoEmAndamento struct {
+	pronto chan struct{}
+	// forcada dispensa a comparação com as taxas anteriores
+	forcada   bool
+	resultado *ResultadoTaxas
+	renovada  bool
+	err       error
+}
+
+// errTaxasNaoRenovadas indica uma busca que manteve as taxas anteriores: o
+// cache continua vencido e buscar de novo em seguida só sobrecarregaria o provedor
+var errTaxasNaoRenovadas = errors.New("taxas não renovadas: rejeitadas pela validação ou não gravadas no cache")
+
+// iniciarAtualizacao dispara uma busca, ou retorna a que já está em andamento.
+// A busca roda no contexto do serviço: cancelar a requisição que a iniciou
+// não interrompe a busca para os demais.
+func (s *ServicoTaxasCambio) iniciarAtualizacao(forcada bool) *atualizacaoEmAndamento {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.emAndamento != nil {
+		return s.emAndamento
+	}
+
+	a := &atualizacaoEmAndamento{pronto: make(chan struct{}), forcada: forcada}
+	s.emAndamento = a
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ctx, cancel := context.WithTimeout(s.ctxFundo, TEMPO_MAXIMO_ATUALIZACAO)
+		defer cancel()
+		a.resultado, a.renovada, a.err = s.buscarESalvar(ctx, forcada)
+
+		s.mu.Lock()
+		s.emAndamento = nil
+		s.mu.Unlock()
+		close(a.pronto)
+	}()
+
+	return a
+}
+
+// aguardar espera a busca terminar ou ctx ser cancelado
+func aguardar(ctx context.Context, a *atualizacaoEmAndamento) error {
+	select {
+	case <-a.pronto:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("busca de taxas cancelada: %w", ctx.Err())
+	}
+}
+
+// atualizar aguarda a busca compartilhada ou o cancelamento de ctx
+func (s *ServicoTaxasCambio) atualizar(ctx context.Context) (*ResultadoTaxas, error) {
+	a := s.iniciarAtualizacao(false)
+	if err := aguardar(ctx, a); err != nil {
+		return nil, err
+	}
+	return a.resultado, a.err
+}
+
+// atualizarForcado busca taxas sem compará-las com as anteriores. Uma busca
+// comum já em andamento é aguardada; se ela manteve as taxas anteriores, uma
+// busca forçada é disparada em seguida.
+func (s *ServicoTaxasCambio) atualizarForcado(ctx context.Context) (*ResultadoTaxas, error) {
+	a := s.iniciarAtualizacao(true)
+	if err := aguardar(ctx, a); err != nil {
+		return nil, err
+	}
+	if !a.forcada && a.err == nil && !a.renovada {
+		a = s.iniciarAtualizacao(true)
+		if err := aguardar(ctx, a); err != nil {
+			return nil, err
+		}
+	}
+	return a.resultado, a.err
+}
+
+// renovar aguarda a busca compartilhada, como atualizar, mas também falha
+// quando ela não renovou o cache
+func (s *ServicoTaxasCambio) renovar(ctx context.Context) error {
+	a := s.iniciarAtualizacao(false)
+	if err := aguardar(ctx, a); err != nil {
+		return err
+	}
+
+	if a.err != nil {
+		return a.err
+	}
+	if !a.renovada {
+		return errTaxasNaoRenovadas
+	}
+	return nil
+}
+
+// IniciarAtualizacaoAutomatica renova as taxas em segundo plano, antecedencia
+// antes de o cache vencer, para que nenhuma requisição espere pela API.
+// Use Parar para encerrar.
+func (s *ServicoTaxasCambio) IniciarAtualizacaoAutomatica(antecedencia time.Duration) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		espera := s.proximaAtualizacao(antecedencia)
+		for {
+			select {
+			case <-s.ctxFundo.Done():
+				return
+			case <-time.After(espera):
+			}
+
+			if err := s.renovar(s.ctxFundo); err != nil {
+				if s.ctxFundo.Err() != nil {
+					return
+				}
+				fmt.Printf("Aviso: atualização automática falhou, nova tentativa em %s: %v\n", INTERVALO_NOVA_TENTATIVA, err)
+				espera = INTERVALO_NOVA_TENTATIVA
+				continue
+			}
+			espera = s.proximaAtualizacao(antecedencia)
+		}
+	}()
+}
+
+// proximaAtualizacao calcula quanto falta para o cache entrar na janela de renovação
+func (s *ServicoTaxasCambio) proximaAtualizacao(antecedencia time.Duration) time.Duration {
+	resultado, _ := s.cache.CarregarUltimo(s.ctxFundo)
+	if resultado == nil {
+		return 0
+	}
+
+	espera := s.cache.Validade() - antecedencia - resultado.Idade()
+	if espera < 0 {
+		return 0
+	}
+	return espera
+}
+
+// Parar encerra a atualização automática e aguarda as buscas em andamento
+// terminarem, ou ctx ser cancelado
+func (s *ServicoTaxasCambio) Parar(ctx context.Context) error {
+	s.cancelar()
+
+	terminou := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(terminou)
+	}()
+
+	select {
+	case <-terminou:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("atualização de taxas não terminou a tempo: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,188 @@
+package cambio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// provedorLento conta as buscas e só responde quando liberar é fechado
+type provedorLento struct {
+	provedorContador
+	liberar chan struct{}
+}
+
+func (p *provedorLento) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	select {
+	case <-p.liberar:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return p.provedorContador.BuscarTaxas(ctx, moedaBase)
+}
+
+func novoServicoTeste(provedor RateProvider, armazenamento ArmazenamentoCache) *ServicoTaxasCambio {
+	cliente := NewCambioClientComProvedor(provedor)
+	cliente.UsarTriangulacao("USD", 0.5)
+	servico := NewServicoTaxasCambioComCliente(cliente)
+	servico.UsarCache(NewGerenciadorCacheCom(armazenamento, time.Hour))
+	return servico
+}
+
+func taxasUSD() map[string]map[string]float64 {
+	return map[string]map[string]float64{"USD": {"EUR": 0.9, "BRL": 5.4, "GBP": 0.8, "JPY": 150}}
+}
+
+func TestAtualizacoesConcorrentesFazemUmaUnicaBusca(t *testing.T) {
+	provedor := &provedorLento{provedorContador: provedorContador{taxas: taxasUSD()}, liberar: make(chan struct{})}
+	servico := novoServicoTeste(provedor, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := servico.ObterTaxas(context.Background()); err != nil {
+				t.Errorf("erro inesperado: %v", err)
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(provedor.liberar)
+	wg.Wait()
+
+	if n := provedor.chamadas.Load(); n != 1 {
+		t.Errorf("esperada 1 busca compartilhada, obtidas %d", n)
+	}
+}
+
+func TestCancelarRequisicaoNaoInterrompeBuscaCompartilhada(t *testing.T) {
+	provedor := &provedorLento{provedorContador: provedorContador{taxas: taxasUSD()}, liberar: make(chan struct{})}
+	servico := novoServicoTeste(provedor, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := servico.ObterTaxas(ctx); err == nil {
+		t.Fatal("esperado erro para requisição cancelada")
+	}
+
+	close(provedor.liberar)
+	resultado, err := servico.ObterTaxas(context.Background())
+	if err != nil || resultado.Taxas["USD"]["BRL"] != 5.4 {
+		t.Errorf("busca compartilhada deveria concluir, obtido %+v (erro: %v)", resultado, err)
+	}
+}
+
+func TestServeTaxasObsoletasEnquantoAtualiza(t *testing.T) {
+	armazenamento := NewCacheMemoria()
+	vencido := fmt.Sprintf(`{"timestamp": %d, "taxas_cambio": {"USD": {"BRL": 5.0}}}`, time.Now().Add(-2*time.Hour).Unix())
+	armazenamento.Gravar(context.Background(), []byte(vencido))
+
+	provedor := &provedorLento{provedorContador: provedorContador{taxas: taxasUSD()}, liberar: make(chan struct{})}
+	servico := novoServicoTeste(provedor, armazenamento)
+	defer servico.Parar(context.Background())
+
+	resultado, err := servico.ObterTaxas(context.Background())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !resultado.Obsoleto || resultado.Taxas["USD"]["BRL"] != 5.0 {
+		t.Errorf("esperadas taxas obsoletas do cache, obtido %+v", resultado)
+	}
+	if resultado.Idade() < 2*time.Hour-time.Minute {
+		t.Errorf("idade esperada de cerca de 2h, obtida %s", resultado.Idade())
+	}
+
+	// A renovação disparada em segundo plano atualiza o cache
+	close(provedor.liberar)
+	prazo := time.Now().Add(2 * time.Second)
+	for {
+		resultado, err = servico.ObterTaxas(context.Background())
+		if err == nil && !resultado.Obsoleto {
+			break
+		}
+		if time.Now().After(prazo) {
+			t.Fatal("cache deveria ter sido renovado em segundo plano")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if resultado.Taxas["USD"]["BRL"] != 5.4 {
+		t.Errorf("taxa renovada esperada 5.4, obtida %v", resultado.Taxas["USD"]["BRL"])
+	}
+}
+
+func TestSemServirObsoletoAguardaABusca(t *testing.T) {
+	armazenamento := NewCacheMemoria()
+	vencido := fmt.Sprintf(`{"timestamp": %d, "taxas_cambio": {"USD": {"BRL": 5.0}}}`, time.Now().Add(-2*time.Hour).Unix())
+	armazenamento.Gravar(context.Background(), []byte(vencido))
+
+	servico := novoServicoTeste(&provedorContador{taxas: taxasUSD()}, armazenamento)
+	servico.ServirObsoleto(0)
+	defer servico.Parar(context.Background())
+
+	resultado, err := servico.ObterTaxas(context.Background())
+	if err != nil || resultado.Obsoleto || resultado.Taxas["USD"]["BRL"] != 5.4 {
+		t.Errorf("esperadas taxas novas, obtido %+v (erro: %v)", resultado, err)
+	}
+}
+
+func TestAtualizacaoAutomaticaParaNoEncerramento(t *testing.T) {
+	provedor := &provedorContador{taxas: taxasUSD()}
+	armazenamento := NewCacheMemoria()
+	servico := novoServicoTeste(provedor, armazenamento)
+
+	// Sem cache, a primeira renovação é imediata
+	servico.IniciarAtualizacaoAutomatica(5 * time.Minute)
+
+	prazo := time.Now().Add(2 * time.Second)
+	for provedor.chamadas.Load() == 0 {
+		if time.Now().After(prazo) {
+			t.Fatal("atualização automática deveria buscar as taxas")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := servico.Parar(ctx); err != nil {
+		t.Fatalf("Parar deveria encerrar a atualização automática: %v", err)
+	}
+
+	// Com o cache recém-renovado, a próxima renovação só ocorreria em 55 minutos
+	if n := provedor.chamadas.Load(); n != 1 {
+		t.Errorf("esperada 1 busca, obtidas %d", n)
+	}
+}
+
+func TestAtualizacaoAutomaticaAguardaAposRejeicao(t *testing.T) {
+	// O cache vencido tem BRL a 5.0; o provedor insiste em 54, sempre rejeitado
+	armazenamento := NewCacheMemoria()
+	vencido := fmt.Sprintf(`{"timestamp": %d, "taxas_cambio": {"USD": {"BRL": 5.0}}}`, time.Now().Add(-2*time.Hour).Unix())
+	armazenamento.Gravar(context.Background(), []byte(vencido))
+
+	anomalas := taxasUSD()
+	anomalas["USD"]["BRL"] = 54
+	provedor := &provedorContador{taxas: anomalas}
+	servico := novoServicoTeste(provedor, armazenamento)
+	servico.UsarValidador(NewValidadorTaxas(2, 10))
+	servico.UsarAlertas(func(AlertaTaxas) {})
+
+	servico.IniciarAtualizacaoAutomatica(5 * time.Minute)
+	time.Sleep(200 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := servico.Parar(ctx); err != nil {
+		t.Fatalf("Parar deveria encerrar a atualização automática: %v", err)
+	}
+
+	// A rejeição mantém o cache vencido; a nova tentativa espera INTERVALO_NOVA_TENTATIVA
+	if n := provedor.chamadas.Load(); n != 1 {
+		t.Errorf("esperada 1 busca antes da nova tentativa, obtidas %d", n)
+	}
+}
@@ -0,0 +1,56 @@
+package cambio
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// OperacaoAuditoria é o tipo de alteração registrada na trilha de auditoria
+type OperacaoAuditoria string
+
+const (
+	OperacaoCriacao   OperacaoAuditoria = "INSERT"
+	OperacaoAlteracao OperacaoAuditoria = "UPDATE"
+	OperacaoExclusao  OperacaoAuditoria = "DELETE"
+)
+
+// RegistroAuditoria é uma alteração de uma transação: quem a fez, em qual
+// requisição e os valores antes e depois. Os registros nunca são alterados
+// nem apagados.
+type RegistroAuditoria struct {
+	ID          int64             `json:"id"`
+	TransacaoID int               `json:"transacao_id"`
+	Operacao    OperacaoAuditoria `json:"operacao"`
+	// UserID é nil quando a alteração não partiu de um usuário (processos
+	// internos ou manutenção direta no banco)
+	UserID    *int   `json:"user_id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// CamposAlterados lista as colunas que mudaram; vazio na criação e na
+	// exclusão física
+	CamposAlterados   []string        `json:"campos_alterados"`
+	ValoresAnteriores json.RawMessage `json:"valores_anteriores,omitempty"`
+	ValoresNovos      json.RawMessage `json:"valores_novos,omitempty"`
+	AlteradoEm        time.Time       `json:"alterado_em"`
+}
+
+// Autoria identifica quem está alterando as transações, para a trilha de
+// auditoria. UserID zero indica o sistema.
+type Autoria struct {
+	UserID    int
+	RequestID string
+}
+
+type chaveAutoria struct{}
+
+// ComAutoria anexa ao contexto o autor das alterações feitas com ele
+func ComAutoria(ctx context.Context, autoria Autoria) context.Context {
+	return context.WithValue(ctx, chaveAutoria{}, autoria)
+}
+
+// AutoriaDe retorna o autor anexado por ComAutoria; sem ele, as alterações
+// são atribuídas ao sistema
+func AutoriaDe(ctx context.Context) Autoria {
+	autoria, _ := ctx.Value(chaveAutoria{}).(Autoria)
+	return autoria
+}
@@ -0,0 +1,17 @@
+package cambio
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAutoriaDoContexto(t *testing.T) {
+	if autoria := AutoriaDe(context.Background()); autoria != (Autoria{}) {
+		t.Errorf("sem autoria no contexto deveria indicar o sistema, obtido %+v", autoria)
+	}
+
+	ctx := ComAutoria(context.Background(), Autoria{UserID: 3, RequestID: "abc"})
+	if autoria := AutoriaDe(ctx); autoria.UserID != 3 || autoria.RequestID != "abc" {
+		t.Errorf("autoria esperada {3 abc}, obtido %+v", autoria)
+	}
+}
@@ -1,77 +1,198 @@
 package cambio
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"sync"
 	"time"
 )
 
 type CacheData struct {
+	// Versao do formato; caches sem versão são do formato original, sem checksum
+	Versao int `json:"versao,omitempty"`
+	// Checksum é o SHA-256 do próprio CacheData serializado com Checksum vazio
+	Checksum string `json:"checksum,omitempty"`
+
 	Timestamp       int64                         `json:"timestamp"`
 	TaxasCambio     map[string]map[string]float64 `json:"taxas_cambio"`
+	Falhas          map[string]string             `json:"falhas,omitempty"`
+	Fontes          map[string]string             `json:"fontes,omitempty"`
 	ValidadePeriodo int64                         `json:"validade_periodo"`
 }
 
 const (
 	CACHE_FILE     = "taxas_cambio_cache.json"
 	CACHE_VALIDADE = 3600
+	CACHE_VERSAO   = 2
+)
+
+var (
+	// ErrCacheVazio indica que o armazenamento não tem nenhuma taxa gravada
+	ErrCacheVazio = errors.New("cache vazio")
+	// ErrCacheCorrompido indica conteúdo ilegível ou com checksum inválido
+	ErrCacheCorrompido = errors.New("cache corrompido")
+	// ErrCacheAlterado indica que o conteúdo foi regravado ou removido desde a leitura
+	ErrCacheAlterado = errors.New("cache alterado desde a leitura")
 )
 
+// calcularChecksum serializa o cache sem o campo Checksum e retorna seu SHA-256.
+// encoding/json ordena as chaves dos mapas, então o resultado é determinístico.
+func (c CacheData) calcularChecksum() (string, error) {
+	c.Checksum = ""
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	soma := sha256.Sum256(data)
+	return hex.EncodeToString(soma[:]), nil
+}
+
+// decodificarCache interpreta e confere o conteúdo gravado
+func decodificarCache(data []byte) (*CacheData, error) {
+	var cache CacheData
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCacheCorrompido, err)
+	}
+
+	if cache.Versao == 0 {
+		return &cache, nil
+	}
+
+	esperado, err := cache.calcularChecksum()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCacheCorrompido, err)
+	}
+	if cache.Checksum != esperado {
+		return nil, fmt.Errorf("%w: checksum não confere", ErrCacheCorrompido)
+	}
+
+	return &cache, nil
+}
+
+// Quarentenavel é implementado por armazenamentos que conseguem preservar um
+// conteúdo corrompido para análise, retornando onde ele foi guardado. Se o
+// conteúdo atual já não for o corrompido informado, nada é movido e o erro é
+// ErrCacheAlterado.
+type Quarentenavel interface {
+	Quarentenar(ctx context.Context, corrompido []byte) (string, error)
+}
+
+// Descartavel é implementado por armazenamentos que conseguem apagar um
+// conteúdo corrompido sem atingir uma gravação feita depois da leitura,
+// retornando ErrCacheAlterado nesse caso
+type Descartavel interface {
+	Descartar(ctx context.Context, corrompido []byte) error
+}
+
+// ArmazenamentoCache guarda o conteúdo serializado do cache. A validade é
+// controlada pelo GerenciadorCache; o armazenamento só precisa persistir bytes.
+type ArmazenamentoCache interface {
+	// Ler retorna ErrCacheVazio quando não há nada gravado
+	Ler(ctx context.Context) ([]byte, error)
+	Gravar(ctx context.Context, dados []byte) error
+	Remover(ctx context.Context) error
+}
+
 type GerenciadorCache struct {
-	arquivo  string
-	validade int64
+	armazenamento ArmazenamentoCache
+	validade      int64
 }
 
+// NewGerenciadorCache usa o arquivo CACHE_FILE no diretório atual
 func NewGerenciadorCache() *GerenciadorCache {
+	return NewGerenciadorCacheCom(NewCacheArquivo(CACHE_FILE), CACHE_VALIDADE*time.Second)
+}
+
+// NewGerenciadorCacheCom cria um gerenciador sobre o armazenamento informado
+func NewGerenciadorCacheCom(armazenamento ArmazenamentoCache, validade time.Duration) *GerenciadorCache {
 	return &GerenciadorCache{
-		arquivo:  CACHE_FILE,
-		validade: CACHE_VALIDADE,
+		armazenamento: armazenamento,
+		validade:      int64(validade / time.Second),
 	}
 }
 
-func (g *GerenciadorCache) CarregarCache() (map[string]map[string]float64, bool) {
-	// Verificar se o arquivo existe
-	if _, err := os.Stat(g.arquivo); os.IsNotExist(err) {
+// Validade retorna por quanto tempo as taxas gravadas são consideradas atuais
+func (g *GerenciadorCache) Validade() time.Duration {
+	return time.Duration(g.validade) * time.Second
+}
+
+// CarregarCache retorna as taxas em cache apenas se ainda estiverem válidas
+func (g *GerenciadorCache) CarregarCache(ctx context.Context) (*ResultadoTaxas, bool) {
+	resultado, valido := g.CarregarUltimo(ctx)
+	if resultado == nil {
+		return nil, false
+	}
+
+	idade := int64(resultado.Idade() / time.Second)
+	if !valido {
+		fmt.Println("Cache expirado, buscando dados atualizados...")
 		return nil, false
 	}
 
-	data, err := os.ReadFile(g.arquivo)
+	fmt.Printf("Cache válido encontrado (atualizado há %d segundos)\n", idade)
+	return resultado, true
+}
+
+// CarregarUltimo retorna as últimas taxas gravadas, mesmo expiradas, e se
+// ainda estão dentro da validade. Retorna nil se não houver cache utilizável.
+func (g *GerenciadorCache) CarregarUltimo(ctx context.Context) (*ResultadoTaxas, bool) {
+	data, err := g.armazenamento.Ler(ctx)
+	if errors.Is(err, ErrCacheVazio) {
+		return nil, false
+	}
 	if err != nil {
 		fmt.Printf("Erro ao ler cache: %v\n", err)
 		return nil, false
 	}
 
-	var cache CacheData
-	err = json.Unmarshal(data, &cache)
+	cache, err := decodificarCache(data)
 	if err != nil {
-		fmt.Printf("Erro ao deserializar cache: %v\n", err)
+		g.descartarCorrompido(ctx, data, err)
 		return nil, false
 	}
 
-	agora := time.Now().Unix()
-	if agora-cache.Timestamp > g.validade {
-		fmt.Println("Cache expirado, buscando dados atualizados...")
+	if cache.Versao > CACHE_VERSAO {
+		fmt.Printf("Cache na versão %d, mais nova que a suportada (%d); ignorando\n", cache.Versao, CACHE_VERSAO)
 		return nil, false
 	}
 
-	fmt.Printf("Cache válido encontrado (atualizado há %d segundos)\n", agora-cache.Timestamp)
-	return cache.TaxasCambio, true
+	resultado := &ResultadoTaxas{
+		Taxas:    cache.TaxasCambio,
+		Falhas:   cache.Falhas,
+		Fontes:   cache.Fontes,
+		ObtidoEm: time.Unix(cache.Timestamp, 0),
+		DoCache:  true,
+	}
+	return resultado, time.Now().Unix()-cache.Timestamp <= g.validade
 }
 
-func (g *GerenciadorCache) SalvarCache(taxas map[string]map[string]float64) error {
+func (g *GerenciadorCache) SalvarCache(ctx context.Context, resultado *ResultadoTaxas) error {
 	cache := CacheData{
+		Versao:          CACHE_VERSAO,
 		Timestamp:       time.Now().Unix(),
-		TaxasCambio:     taxas,
+		TaxasCambio:     resultado.Taxas,
+		Falhas:          resultado.Falhas,
+		Fontes:          resultado.Fontes,
 		ValidadePeriodo: g.validade,
 	}
 
+	checksum, err := cache.calcularChecksum()
+	if err != nil {
+		return fmt.Errorf("erro ao serializar cache: %w", err)
+	}
+	cache.Checksum = checksum
+
 	data, err := json.MarshalIndent(cache, "", "  ")
 	if err != nil {
 		return fmt.Errorf("erro ao serializar cache: %w", err)
 	}
 
-	err = os.WriteFile(g.arquivo, data, 0644)
+	err = g.armazenamento.Gravar(ctx, data)
 	if err != nil {
 		return fmt.Errorf("erro ao salvar cache: %w", err)
 	}
@@ -80,12 +201,43 @@ func (g *GerenciadorCache) SalvarCache(taxas map[string]map[string]float64) erro
 	return nil
 }
 
-func (g *GerenciadorCache) LimparCache() error {
-	if _, err := os.Stat(g.arquivo); os.IsNotExist(err) {
-		return nil
+// descartarCorrompido tira o conteúdo inválido do caminho, preservando-o em
+// quarentena quando o armazenamento permite, para que a próxima busca regrave o
+// cache. Um conteúdo regravado por outro leitor ou processo depois da leitura
+// é mantido.
+func (g *GerenciadorCache) descartarCorrompido(ctx context.Context, corrompido []byte, causa error) {
+	if q, ok := g.armazenamento.(Quarentenavel); ok {
+		destino, err := q.Quarentenar(ctx, corrompido)
+		if err == nil {
+			fmt.Printf("Aviso: %v; conteúdo movido para %s\n", causa, destino)
+			return
+		}
+		if errors.Is(err, ErrCacheAlterado) {
+			fmt.Printf("Aviso: %v; cache já regravado, mantido\n", causa)
+			return
+		}
+		fmt.Printf("Aviso: %v; falha ao colocar em quarentena: %v\n", causa, err)
+	}
+
+	var err error
+	if d, ok := g.armazenamento.(Descartavel); ok {
+		err = d.Descartar(ctx, corrompido)
+	} else {
+		err = g.armazenamento.Remover(ctx)
+	}
+	if errors.Is(err, ErrCacheAlterado) {
+		fmt.Printf("Aviso: %v; cache já regravado, mantido\n", causa)
+		return
 	}
+	if err != nil {
+		fmt.Printf("Aviso: %v; falha ao remover: %v\n", causa, err)
+		return
+	}
+	fmt.Printf("Aviso: %v; conteúdo descartado\n", causa)
+}
 
-	err := os.Remove(g.arquivo)
+func (g *GerenciadorCache) LimparCache(ctx context.Context) error {
+	err := g.armazenamento.Remover(ctx)
 	if err != nil {
 		return fmt.Errorf("erro ao limpar cache: %w", err)
 	}
@@ -93,3 +245,51 @@ func (g *GerenciadorCache) LimparCache() error {
 	fmt.Println("Cache limpo com sucesso")
 	return nil
 }
+
+// CacheMemoria mantém o cache apenas na memória do processo
+type CacheMemoria struct {
+	mu    sync.RWMutex
+	dados []byte
+}
+
+func NewCacheMemoria() *CacheMemoria {
+	return &CacheMemoria{}
+}
+
+func (c *CacheMemoria) Ler(ctx context.Context) ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.dados == nil {
+		return nil, ErrCacheVazio
+	}
+	return append([]byte(nil), c.dados...), nil
+}
+
+func (c *CacheMemoria) Gravar(ctx context.Context, dados []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dados = append([]byte(nil), dados...)
+	return nil
+}
+
+func (c *CacheMemoria) Remover(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dados = nil
+	return nil
+}
+
+// Descartar remove o conteúdo apenas se ele ainda for o informado
+func (c *CacheMemoria) Descartar(ctx context.Context, corrompido []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dados == nil || !bytes.Equal(c.dados, corrompido) {
+		return ErrCacheAlterado
+	}
+	c.dados = nil
+	return nil
+}
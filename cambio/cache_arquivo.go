@@ -0,0 +1,158 @@
+package cambio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheArquivo grava o cache em um arquivo local. A gravação é atômica
+// (arquivo temporário + rename) e protegida por trava de arquivo, de modo que
+// leitores, no mesmo processo ou em outros, nunca vejam um JSON pela metade.
+type CacheArquivo struct {
+	caminho string
+	mu      sync.RWMutex
+}
+
+func NewCacheArquivo(caminho string) *CacheArquivo {
+	return &CacheArquivo{caminho: caminho}
+}
+
+func (c *CacheArquivo) Ler(ctx context.Context) ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	liberar, err := travarArquivo(c.caminho+".lock", false)
+	if err != nil {
+		return nil, err
+	}
+	defer liberar()
+
+	data, err := os.ReadFile(c.caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheVazio
+	}
+	return data, err
+}
+
+func (c *CacheArquivo) Gravar(ctx context.Context, dados []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	liberar, err := travarArquivo(c.caminho+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer liberar()
+
+	// O temporário fica no mesmo diretório para que o rename seja atômico
+	tmp, err := os.CreateTemp(filepath.Dir(c.caminho), filepath.Base(c.caminho)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo temporário do cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(dados); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao gravar arquivo temporário do cache: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao sincronizar arquivo temporário do cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("erro ao fechar arquivo temporário do cache: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("erro ao ajustar permissões do cache: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.caminho); err != nil {
+		return fmt.Errorf("erro ao substituir arquivo de cache: %w", err)
+	}
+	return nil
+}
+
+func (c *CacheArquivo) Remover(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	liberar, err := travarArquivo(c.caminho+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer liberar()
+
+	err = os.Remove(c.caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Quarentenar renomeia o arquivo corrompido para análise posterior, liberando
+// o caminho para a próxima gravação. A corrupção é detectada sob a trava
+// compartilhada; por isso, já com a trava exclusiva, o arquivo é relido e só
+// é movido se ainda tiver o conteúdo corrompido informado.
+func (c *CacheArquivo) Quarentenar(ctx context.Context, corrompido []byte) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	liberar, err := travarArquivo(c.caminho+".lock", true)
+	if err != nil {
+		return "", err
+	}
+	defer liberar()
+
+	if err := c.conferirConteudo(corrompido); err != nil {
+		return "", err
+	}
+
+	destino := fmt.Sprintf("%s.corrompido-%s", c.caminho, time.Now().Format("20060102-150405.000000000"))
+	if err := os.Rename(c.caminho, destino); err != nil {
+		return "", fmt.Errorf("erro ao mover cache corrompido: %w", err)
+	}
+	return destino, nil
+}
+
+// Descartar remove o arquivo corrompido, nas mesmas condições de Quarentenar
+func (c *CacheArquivo) Descartar(ctx context.Context, corrompido []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	liberar, err := travarArquivo(c.caminho+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer liberar()
+
+	if err := c.conferirConteudo(corrompido); err != nil {
+		return err
+	}
+
+	if err := os.Remove(c.caminho); err != nil {
+		return fmt.Errorf("erro ao remover cache corrompido: %w", err)
+	}
+	return nil
+}
+
+// conferirConteudo relê o arquivo e retorna ErrCacheAlterado se ele foi
+// regravado ou removido desde a leitura; deve ser chamada com a trava exclusiva
+func (c *CacheArquivo) conferirConteudo(lido []byte) error {
+	atual, err := os.ReadFile(c.caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrCacheAlterado
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao reler cache: %w", err)
+	}
+	if !bytes.Equal(atual, lido) {
+		return ErrCacheAlterado
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package cambio
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheRESP guarda o cache em um servidor que fala o protocolo do Redis (RESP),
+// permitindo que várias instâncias da aplicação compartilhem as mesmas taxas
+type CacheRESP struct {
+	endereco string
+	senha    string
+	banco    int
+	chave    string
+	retencao time.Duration
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	leitor *bufio.Reader
+}
+
+// NewCacheRESP cria um cache na chave informada. Com retencao positiva, a chave
+// expira no servidor (SET ... EX) depois desse tempo; ela deve cobrir a validade
+// do cache e o período em que as taxas obsoletas ainda são servidas.
+func NewCacheRESP(endereco, senha string, banco int, chave string, retencao time.Duration) *CacheRESP {
+	return &CacheRESP{
+		endereco: endereco,
+		senha:    senha,
+		banco:    banco,
+		chave:    chave,
+		retencao: retencao,
+		timeout:  5 * time.Second,
+	}
+}
+
+// NewCacheRESPDeURL interpreta endereços no formato redis://[:senha@]host:porta[/banco]
+func NewCacheRESPDeURL(endereco, chave string, retencao time.Duration) (*CacheRESP, error) {
+	u, err := url.Parse(endereco)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "tcp") || u.Host == "" {
+		return nil, fmt.Errorf("URL de cache inválida: %q (esperado redis://host:porta/banco)", endereco)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	senha, _ := u.User.Password()
+
+	banco := 0
+	if caminho := strings.Trim(u.Path, "/"); caminho != "" {
+		banco, err = strconv.Atoi(caminho)
+		if err != nil || banco < 0 {
+			return nil, fmt.Errorf("banco inválido na URL de cache: %q", caminho)
+		}
+	}
+
+	return NewCacheRESP(host, senha, banco, chave, retencao), nil
+}
+
+func (c *CacheRESP) Ler(ctx context.Context) ([]byte, error) {
+	resposta, err := c.comando(ctx, "GET", c.chave)
+	if err != nil {
+		return nil, err
+	}
+	if resposta == nil {
+		return nil, ErrCacheVazio
+	}
+	dados, ok := resposta.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("resposta inesperada do servidor de cache: %v", resposta)
+	}
+	return dados, nil
+}
+
+func (c *CacheRESP) Gravar(ctx context.Context, dados []byte) error {
+	args := []string{"SET", c.chave, string(dados)}
+	if segundos := int64(c.retencao / time.Second); segundos > 0 {
+		args = append(args, "EX", strconv.FormatInt(segundos, 10))
+	}
+	_, err := c.comando(ctx, args...)
+	return err
+}
+
+func (c *CacheRESP) Remover(ctx context.Context) error {
+	_, err := c.comando(ctx, "DEL", c.chave)
+	return err
+}
+
+// Fechar encerra a conexão com o servidor, se houver
+func (c *CacheRESP) Fechar() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.descartarConexao()
+}
+
+// descartarConexao fecha a conexão atual; deve ser chamada com mu travado
+func (c *CacheRESP) descartarConexao() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.leitor = nil, nil
+	return err
+}
+
+// comando envia um comando e lê a resposta, reconectando se necessário.
+// A conexão é reaproveitada entre comandos e descartada após qualquer erro de rede.
+func (c *CacheRESP) comando(ctx context.Context, args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err := c.conectar(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	resposta, err := c.executar(ctx, args...)
+	var erroServidor erroRESP
+	if err != nil && !errors.As(err, &erroServidor) {
+		c.descartarConexao()
+	}
+	return resposta, err
+}
+
+func (c *CacheRESP) conectar(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.endereco)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar ao servidor de cache %s: %w", c.endereco, err)
+	}
+	c.conn, c.leitor = conn, bufio.NewReader(conn)
+
+	if c.senha != "" {
+		if _, err := c.executar(ctx, "AUTH", c.senha); err != nil {
+			c.descartarConexao()
+			return fmt.Errorf("erro ao autenticar no servidor de cache: %w", err)
+		}
+	}
+	if c.banco != 0 {
+		if _, err := c.executar(ctx, "SELECT", strconv.Itoa(c.banco)); err != nil {
+			c.descartarConexao()
+			return fmt.Errorf("erro ao selecionar banco %d do servidor de cache: %w", c.banco, err)
+		}
+	}
+	return nil
+}
+
+func (c *CacheRESP) executar(ctx context.Context, args ...string) (interface{}, error) {
+	prazo := time.Now().Add(c.timeout)
+	if limite, ok := ctx.Deadline(); ok && limite.Before(prazo) {
+		prazo = limite
+	}
+	c.conn.SetDeadline(prazo)
+
+	// Comandos são enviados como array de bulk strings
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, fmt.Errorf("erro ao enviar comando ao servidor de cache: %w", err)
+	}
+
+	return lerRESP(c.leitor)
+}
+
+// erroRESP é um erro devolvido pelo servidor ("-ERR ..."); a conexão continua utilizável
+type erroRESP string
+
+func (e erroRESP) Error() string {
+	return "servidor de cache: " + string(e)
+}
+
+// lerRESP lê uma resposta: string simples, erro, inteiro, bulk string (nil se ausente) ou array
+func lerRESP(r *bufio.Reader) (interface{}, error) {
+	linha, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta do servidor de cache: %w", err)
+	}
+	linha = strings.TrimSuffix(linha, "\r\n")
+	if linha == "" {
+		return nil, fmt.Errorf("resposta vazia do servidor de cache")
+	}
+
+	conteudo := linha[1:]
+	switch linha[0] {
+	case '+':
+		return conteudo, nil
+	case '-':
+		return nil, erroRESP(conteudo)
+	case ':':
+		return strconv.ParseInt(conteudo, 10, 64)
+	case '$':
+		tamanho, err := strconv.Atoi(conteudo)
+		if err != nil {
+			return nil, fmt.Errorf("tamanho inválido na resposta do servidor de cache: %q", conteudo)
+		}
+		if tamanho < 0 {
+			return nil, nil
+		}
+		dados := make([]byte, tamanho+2)
+		if _, err := io.ReadFull(r, dados); err != nil {
+			return nil, fmt.Errorf("erro ao ler resposta do servidor de cache: %w", err)
+		}
+		return dados[:tamanho], nil
+	case '*':
+		quantidade, err := strconv.Atoi(conteudo)
+		if err != nil {
+			return nil, fmt.Errorf("tamanho inválido na resposta do servidor de cache: %q", conteudo)
+		}
+		if quantidade < 0 {
+			return nil, nil
+		}
+		itens := make([]interface{}, quantidade)
+		for i := range itens {
+			if itens[i], err = lerRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return itens, nil
+	default:
+		return nil, fmt.Errorf("resposta desconhecida do servidor de cache: %q", linha)
+	}
+}
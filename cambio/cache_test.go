@@ -0,0 +1,436 @@
+package cambio
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGerenciadorCacheMemoria(t *testing.T) {
+	ctx := context.Background()
+	g := NewGerenciadorCacheCom(NewCacheMemoria(), time.Hour)
+
+	if _, valido := g.CarregarCache(ctx); valido {
+		t.Fatal("cache vazio não deveria ser válido")
+	}
+
+	resultado := NewResultadoTaxas()
+	resultado.Taxas["USD"] = map[string]float64{"BRL": 5.4}
+	resultado.Fontes["USD"] = "ecb"
+	if err := g.SalvarCache(ctx, resultado); err != nil {
+		t.Fatalf("erro ao salvar: %v", err)
+	}
+
+	carregado, valido := g.CarregarCache(ctx)
+	if !valido || carregado.Taxas["USD"]["BRL"] != 5.4 || carregado.Fontes["USD"] != "ecb" {
+		t.Errorf("cache carregado inesperado: %+v (válido: %v)", carregado, valido)
+	}
+
+	if err := g.LimparCache(ctx); err != nil {
+		t.Fatalf("erro ao limpar: %v", err)
+	}
+	if _, valido := g.CarregarCache(ctx); valido {
+		t.Error("cache limpo não deveria ser válido")
+	}
+}
+
+func TestGerenciadorCacheExpirado(t *testing.T) {
+	ctx := context.Background()
+	armazenamento := NewCacheMemoria()
+	antigo := fmt.Sprintf(`{"timestamp": %d, "taxas_cambio": {"USD": {"BRL": 5}}}`, time.Now().Add(-2*time.Hour).Unix())
+	armazenamento.Gravar(ctx, []byte(antigo))
+
+	g := NewGerenciadorCacheCom(armazenamento, time.Hour)
+	if _, valido := g.CarregarCache(ctx); valido {
+		t.Error("cache de duas horas atrás não deveria ser válido com validade de uma hora")
+	}
+}
+
+func TestCacheArquivo(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheArquivo(filepath.Join(t.TempDir(), "cache.json"))
+
+	if _, err := c.Ler(ctx); err != ErrCacheVazio {
+		t.Errorf("esperado ErrCacheVazio, obtido %v", err)
+	}
+	if err := c.Remover(ctx); err != nil {
+		t.Errorf("remover arquivo inexistente não deveria falhar: %v", err)
+	}
+	if err := c.Gravar(ctx, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if dados, err := c.Ler(ctx); err != nil || string(dados) != "{}" {
+		t.Errorf("esperado {}, obtido %q (erro: %v)", dados, err)
+	}
+}
+
+func TestCacheArquivoLidoEmDiretorioSomenteLeitura(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignora as permissões do diretório")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	caminho := filepath.Join(dir, "cache.json")
+	if err := os.WriteFile(caminho, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, 0755)
+
+	// Sem o arquivo de trava e sem poder criá-lo, a leitura segue sem trava
+	if dados, err := NewCacheArquivo(caminho).Ler(ctx); err != nil || string(dados) != "{}" {
+		t.Errorf("esperado {}, obtido %q (erro: %v)", dados, err)
+	}
+}
+
+func TestCacheArquivoLeiturasConcorrentesNuncaVeemArquivoParcial(t *testing.T) {
+	ctx := context.Background()
+	g := NewGerenciadorCacheCom(NewCacheArquivo(filepath.Join(t.TempDir(), "cache.json")), time.Hour)
+
+	resultado := NewResultadoTaxas()
+	for _, base := range []string{"USD", "EUR", "BRL", "GBP", "JPY"} {
+		resultado.Taxas[base] = map[string]float64{}
+		for i := 0; i < 200; i++ {
+			resultado.Taxas[base][fmt.Sprintf("X%03d", i)] = float64(i) + 0.5
+		}
+	}
+	if err := g.SalvarCache(ctx, resultado); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := g.SalvarCache(ctx, resultado); err != nil {
+					t.Errorf("erro ao salvar: %v", err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, valido := g.CarregarCache(ctx); !valido {
+					t.Error("leitura concorrente deveria sempre encontrar cache válido")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCacheArquivoCorrompidoVaiParaQuarentena(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	caminho := filepath.Join(dir, "cache.json")
+	g := NewGerenciadorCacheCom(NewCacheArquivo(caminho), time.Hour)
+
+	// JSON truncado
+	if err := os.WriteFile(caminho, []byte(`{"versao": 2, "taxas_cambio": {"USD": {"BR`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, valido := g.CarregarCache(ctx); valido {
+		t.Fatal("cache truncado não deveria ser válido")
+	}
+	if _, err := os.Stat(caminho); !os.IsNotExist(err) {
+		t.Error("cache corrompido deveria ter saído do caminho original")
+	}
+	quarentena, _ := filepath.Glob(caminho + ".corrompido-*")
+	if len(quarentena) != 1 {
+		t.Errorf("esperado um arquivo em quarentena, obtidos %v", quarentena)
+	}
+}
+
+func TestCacheArquivoRegravadoAposLeituraCorrompidaEMantido(t *testing.T) {
+	ctx := context.Background()
+	caminho := filepath.Join(t.TempDir(), "cache.json")
+	c := NewCacheArquivo(caminho)
+
+	corrompido := []byte(`{"versao": 2, "taxas_cambio": {"USD": {"BR`)
+	if err := c.Gravar(ctx, corrompido); err != nil {
+		t.Fatal(err)
+	}
+	lido, _ := c.Ler(ctx)
+
+	// Outro processo regrava o cache entre a leitura e o descarte
+	valido := []byte(`{"timestamp": 1, "taxas_cambio": {"USD": {"BRL": 5}}}`)
+	if err := c.Gravar(ctx, valido); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Quarentenar(ctx, lido); err != ErrCacheAlterado {
+		t.Errorf("Quarentenar: esperado ErrCacheAlterado, obtido %v", err)
+	}
+	if err := c.Descartar(ctx, lido); err != ErrCacheAlterado {
+		t.Errorf("Descartar: esperado ErrCacheAlterado, obtido %v", err)
+	}
+	if dados, err := c.Ler(ctx); err != nil || string(dados) != string(valido) {
+		t.Errorf("cache regravado deveria ser mantido, obtido %q (erro: %v)", dados, err)
+	}
+	if quarentena, _ := filepath.Glob(caminho + ".corrompido-*"); len(quarentena) != 0 {
+		t.Errorf("nada deveria ir para quarentena, obtidos %v", quarentena)
+	}
+
+	// Ainda com o conteúdo lido, o arquivo é descartado
+	c.Gravar(ctx, corrompido)
+	if err := c.Descartar(ctx, lido); err != nil {
+		t.Errorf("erro inesperado ao descartar: %v", err)
+	}
+	if _, err := c.Ler(ctx); err != ErrCacheVazio {
+		t.Errorf("esperado ErrCacheVazio após descartar, obtido %v", err)
+	}
+}
+
+func TestCacheComChecksumAlteradoEDescartado(t *testing.T) {
+	ctx := context.Background()
+	armazenamento := NewCacheMemoria()
+	g := NewGerenciadorCacheCom(armazenamento, time.Hour)
+
+	resultado := NewResultadoTaxas()
+	resultado.Taxas["USD"] = map[string]float64{"BRL": 5.4}
+	g.SalvarCache(ctx, resultado)
+
+	// Alterar uma taxa sem atualizar o checksum
+	dados, _ := armazenamento.Ler(ctx)
+	var cache CacheData
+	json.Unmarshal(dados, &cache)
+	cache.TaxasCambio["USD"]["BRL"] = 9.9
+	adulterado, _ := json.Marshal(cache)
+	armazenamento.Gravar(ctx, adulterado)
+
+	if _, valido := g.CarregarCache(ctx); valido {
+		t.Fatal("cache com checksum inválido não deveria ser usado")
+	}
+	if _, err := armazenamento.Ler(ctx); err != ErrCacheVazio {
+		t.Errorf("cache corrompido deveria ser removido, obtido %v", err)
+	}
+}
+
+func TestCacheFormatoOriginalSemVersao(t *testing.T) {
+	ctx := context.Background()
+	armazenamento := NewCacheMemoria()
+	original := fmt.Sprintf(`{"timestamp": %d, "taxas_cambio": {"USD": {"BRL": 5}}}`, time.Now().Unix())
+	armazenamento.Gravar(ctx, []byte(original))
+
+	g := NewGerenciadorCacheCom(armazenamento, time.Hour)
+	if resultado, valido := g.CarregarCache(ctx); !valido || resultado.Taxas["USD"]["BRL"] != 5 {
+		t.Errorf("cache no formato original deveria ser aceito, obtido %+v", resultado)
+	}
+}
+
+// servidorRESP é um servidor mínimo que entende AUTH, SELECT, GET, SET e DEL
+type servidorRESP struct {
+	mu       sync.Mutex
+	dados    map[string]string
+	expira   map[string]string
+	prazos   map[string]time.Time
+	senha    string
+	listener net.Listener
+	// deslocamento adianta ou atrasa o relógio do servidor, para simular expiração
+	deslocamento time.Duration
+}
+
+func novoServidorRESP(t *testing.T, senha string) *servidorRESP {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &servidorRESP{dados: map[string]string{}, expira: map[string]string{}, prazos: map[string]time.Time{}, senha: senha, listener: l}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.atender(conn)
+		}
+	}()
+	return s
+}
+
+func (s *servidorRESP) atender(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	autenticado := s.senha == ""
+
+	for {
+		linha, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(linha[1:]))
+		args := make([]string, n)
+		for i := range args {
+			cabecalho, _ := r.ReadString('\n')
+			tamanho, _ := strconv.Atoi(strings.TrimSpace(cabecalho[1:]))
+			buf := make([]byte, tamanho+2)
+			io.ReadFull(r, buf)
+			args[i] = string(buf[:tamanho])
+		}
+
+		s.mu.Lock()
+		switch {
+		case args[0] == "AUTH":
+			autenticado = args[1] == s.senha
+			if autenticado {
+				fmt.Fprint(conn, "+OK\r\n")
+			} else {
+				fmt.Fprint(conn, "-ERR invalid password\r\n")
+			}
+		case !autenticado:
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+		case args[0] == "SELECT":
+			fmt.Fprint(conn, "+OK\r\n")
+		case args[0] == "SET":
+			s.dados[args[1]] = args[2]
+			delete(s.prazos, args[1])
+			if len(args) == 5 {
+				s.expira[args[1]] = args[4]
+				segundos, _ := strconv.Atoi(args[4])
+				s.prazos[args[1]] = s.agora().Add(time.Duration(segundos) * time.Second)
+			}
+			fmt.Fprint(conn, "+OK\r\n")
+		case args[0] == "GET":
+			if prazo, ok := s.prazos[args[1]]; ok && !s.agora().Before(prazo) {
+				delete(s.dados, args[1])
+				delete(s.prazos, args[1])
+			}
+			if v, ok := s.dados[args[1]]; ok {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+			} else {
+				fmt.Fprint(conn, "$-1\r\n")
+			}
+		case args[0] == "DEL":
+			_, ok := s.dados[args[1]]
+			delete(s.dados, args[1])
+			if ok {
+				fmt.Fprint(conn, ":1\r\n")
+			} else {
+				fmt.Fprint(conn, ":0\r\n")
+			}
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		s.mu.Unlock()
+	}
+}
+
+// agora é o relógio do servidor; deve ser chamado com mu travado
+func (s *servidorRESP) agora() time.Time {
+	return time.Now().Add(s.deslocamento)
+}
+
+func (s *servidorRESP) deslocar(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deslocamento = d
+}
+
+func TestCacheRESP(t *testing.T) {
+	ctx := context.Background()
+	srv := novoServidorRESP(t, "segredo")
+
+	c, err := NewCacheRESPDeURL("redis://:segredo@"+srv.listener.Addr().String()+"/2", "cambio:taxas", time.Hour)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	defer c.Fechar()
+
+	if _, err := c.Ler(ctx); err != ErrCacheVazio {
+		t.Errorf("esperado ErrCacheVazio, obtido %v", err)
+	}
+
+	conteudo := "{\"taxas\":\r\n{}}"
+	if err := c.Gravar(ctx, []byte(conteudo)); err != nil {
+		t.Fatalf("erro ao gravar: %v", err)
+	}
+	if dados, err := c.Ler(ctx); err != nil || string(dados) != conteudo {
+		t.Errorf("esperado %q, obtido %q (erro: %v)", conteudo, dados, err)
+	}
+	if srv.expira["cambio:taxas"] != "3600" {
+		t.Errorf("chave deveria expirar em 3600s, obtido %q", srv.expira["cambio:taxas"])
+	}
+
+	if err := c.Remover(ctx); err != nil {
+		t.Fatalf("erro ao remover: %v", err)
+	}
+	if _, err := c.Ler(ctx); err != ErrCacheVazio {
+		t.Errorf("esperado ErrCacheVazio após remover, obtido %v", err)
+	}
+}
+
+func TestCacheRESPServeTaxasObsoletas(t *testing.T) {
+	ctx := context.Background()
+	srv := novoServidorRESP(t, "")
+
+	// Retenção como configurada no servidor: validade mais o período obsoleto
+	c := NewCacheRESP(srv.listener.Addr().String(), "", 0, "cambio:taxas", time.Hour+MAXIMO_OBSOLETO_PADRAO)
+	defer c.Fechar()
+
+	// Taxas gravadas há 2h, vencidas há 1h
+	srv.deslocar(-2 * time.Hour)
+	vencido := fmt.Sprintf(`{"timestamp": %d, "taxas_cambio": {"USD": {"BRL": 5.0}}}`, time.Now().Add(-2*time.Hour).Unix())
+	if err := c.Gravar(ctx, []byte(vencido)); err != nil {
+		t.Fatalf("erro ao gravar: %v", err)
+	}
+	srv.deslocar(0)
+
+	provedor := &provedorLento{provedorContador: provedorContador{taxas: taxasUSD()}, liberar: make(chan struct{})}
+	servico := novoServicoTeste(provedor, c)
+	defer servico.Parar(ctx)
+	defer close(provedor.liberar)
+
+	// Sem o cache, a busca ficaria presa no provedor lento
+	ctxBusca, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	resultado, err := servico.ObterTaxas(ctxBusca)
+	if err != nil {
+		t.Fatalf("esperadas taxas obsoletas do redis, obtido erro: %v", err)
+	}
+	if !resultado.Obsoleto || resultado.Taxas["USD"]["BRL"] != 5.0 {
+		t.Errorf("esperadas taxas obsoletas do redis, obtido %+v", resultado)
+	}
+
+	// Passado o período obsoleto, a chave expira no servidor
+	srv.deslocar(time.Hour + MAXIMO_OBSOLETO_PADRAO)
+	if _, err := c.Ler(ctx); err != ErrCacheVazio {
+		t.Errorf("esperado ErrCacheVazio após a retenção, obtido %v", err)
+	}
+}
+
+func TestCacheRESPSenhaInvalida(t *testing.T) {
+	srv := novoServidorRESP(t, "segredo")
+	c := NewCacheRESP(srv.listener.Addr().String(), "errada", 0, "k", 0)
+	if _, err := c.Ler(context.Background()); err == nil {
+		t.Error("esperado erro de autenticação")
+	}
+}
+
+func TestNewCacheRESPDeURLInvalida(t *testing.T) {
+	for _, u := range []string{"http://localhost", "redis://", "redis://localhost/abc"} {
+		if _, err := NewCacheRESPDeURL(u, "k", 0); err == nil {
+			t.Errorf("%q: esperado erro", u)
+		}
+	}
+	c, err := NewCacheRESPDeURL("redis://cache.interno", "k", 0)
+	if err != nil || c.endereco != "cache.interno:6379" {
+		t.Errorf("porta padrão esperada, obtido %+v (erro: %v)", c, err)
+	}
+}
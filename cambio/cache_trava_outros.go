@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package cambio
+
+// travarArquivo não tem trava entre processos nesta plataforma; a gravação
+// continua atômica (rename) e protegida por mutex dentro do processo.
+func travarArquivo(caminho string, exclusiva bool) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,47 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package cambio
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// travarArquivo obtém uma trava flock(2) sobre o arquivo informado (criado se
+// necessário): compartilhada para leitura ou exclusiva para escrita. A trava
+// vale entre processos e é liberada pela função retornada. Para leitura o
+// arquivo é aberto somente para leitura, o que basta ao flock e funciona mesmo
+// sem permissão de escrita sobre ele; se ele não existir e não puder ser
+// criado (diretório somente leitura), a leitura segue sem trava, já que
+// ninguém consegue gravar o cache ali.
+func travarArquivo(caminho string, exclusiva bool) (func(), error) {
+	flags := os.O_CREATE | os.O_RDONLY
+	if exclusiva {
+		flags = os.O_CREATE | os.O_RDWR
+	}
+	f, err := os.OpenFile(caminho, flags, 0644)
+	if err != nil && !exclusiva {
+		f, err = os.Open(caminho)
+		if err != nil {
+			return func() {}, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir trava do cache: %w", err)
+	}
+
+	modo := syscall.LOCK_SH
+	if exclusiva {
+		modo = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), modo); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("erro ao travar cache: %w", err)
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,75 @@
+package cambio
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EstrategiaCarga define quando o serviço busca as taxas na API
+type EstrategiaCarga string
+
+const (
+	// CargaAntecipada busca as taxas em Iniciar e as mantém em memória até ForcarAtualizacao
+	CargaAntecipada EstrategiaCarga = "antecipada"
+	// CargaSobDemanda busca as taxas na primeira consulta e as mantém em memória
+	CargaSobDemanda EstrategiaCarga = "sob_demanda"
+	// CargaCache usa as taxas do cache enquanto válidas e renova quando vencem
+	CargaCache EstrategiaCarga = "cache"
+	// CargaPeriodica é CargaCache com renovação em segundo plano antes do vencimento
+	CargaPeriodica EstrategiaCarga = "periodica"
+)
+
+// ANTECEDENCIA_ATUALIZACAO_PADRAO é quanto antes do vencimento CargaPeriodica renova as taxas
+const ANTECEDENCIA_ATUALIZACAO_PADRAO = 5 * time.Minute
+
+// Valida verifica se a estratégia é conhecida
+func (e EstrategiaCarga) Valida() error {
+	switch e {
+	case CargaAntecipada, CargaSobDemanda, CargaCache, CargaPeriodica:
+		return nil
+	}
+	return fmt.Errorf("estratégia de carga inválida: %q (use antecipada, sob_demanda, cache ou periodica)", e)
+}
+
+// usaCache indica se as taxas são guardadas no GerenciadorCache ou só na memória
+func (e EstrategiaCarga) usaCache() bool {
+	return e == CargaCache || e == CargaPeriodica
+}
+
+// Iniciar prepara o serviço conforme a estratégia: CargaAntecipada busca as
+// taxas imediatamente e CargaPeriodica inicia a atualização automática.
+// Use Parar para encerrar.
+func (s *ServicoTaxasCambio) Iniciar(ctx context.Context) error {
+	switch s.estrategia {
+	case CargaAntecipada:
+		fmt.Println("Carregando taxas de câmbio da API (apenas na inicialização)...")
+		if _, err := s.atualizar(ctx); err != nil {
+			return fmt.Errorf("erro ao carregar taxas na inicialização: %w", err)
+		}
+		fmt.Println("Taxas carregadas com sucesso!")
+	case CargaPeriodica:
+		s.IniciarAtualizacaoAutomatica(s.antecedencia)
+	}
+	return nil
+}
+
+// ObterTaxas retorna as taxas conforme a estratégia de carga. Nas estratégias
+// em memória, as taxas já carregadas valem até ForcarAtualizacao; nas com
+// cache, valem até vencer (ou até maximoObsoleto depois, marcadas como obsoletas).
+func (s *ServicoTaxasCambio) ObterTaxas(ctx context.Context) (*ResultadoTaxas, error) {
+	if s.estrategia.usaCache() {
+		return s.obterTaxasDoCache(ctx)
+	}
+
+	s.mu.Lock()
+	ultimas := s.ultimas
+	s.mu.Unlock()
+
+	if ultimas != nil {
+		copia := *ultimas
+		copia.DoCache = true
+		return &copia, nil
+	}
+	return s.atualizar(ctx)
+}
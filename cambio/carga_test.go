@@ -0,0 +1,110 @@
+package cambio
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-project/moeda"
+)
+
+func TestCargaAntecipadaBuscaApenasNaInicializacao(t *testing.T) {
+	provedor := &provedorContador{taxas: taxasUSD()}
+	armazenamento := NewCacheMemoria()
+	servico := novoServicoTeste(provedor, armazenamento)
+	servico.UsarEstrategia(CargaAntecipada)
+	defer servico.Parar(context.Background())
+
+	if err := servico.Iniciar(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if n := provedor.chamadas.Load(); n != 1 {
+		t.Fatalf("esperada 1 busca na inicialização, obtidas %d", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		conversao, err := servico.CalcularConversao(context.Background(), moeda.NewFromInt(10), "USD", "BRL")
+		if err != nil || conversao.ValorDestino.String() != "54.00" {
+			t.Fatalf("conversão esperada 54.00, obtido %+v (erro: %v)", conversao, err)
+		}
+	}
+	if n := provedor.chamadas.Load(); n != 1 {
+		t.Errorf("consultas não deveriam buscar novamente, obtidas %d buscas", n)
+	}
+
+	if _, err := armazenamento.Ler(context.Background()); !errors.Is(err, ErrCacheVazio) {
+		t.Errorf("estratégia em memória não deveria gravar no cache, obtido erro %v", err)
+	}
+
+	if _, err := servico.ForcarAtualizacao(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if n := provedor.chamadas.Load(); n != 2 {
+		t.Errorf("ForcarAtualizacao deveria buscar novamente, obtidas %d buscas", n)
+	}
+}
+
+func TestCargaSobDemandaBuscaNaPrimeiraConsulta(t *testing.T) {
+	provedor := &provedorContador{taxas: taxasUSD()}
+	servico := novoServicoTeste(provedor, NewCacheMemoria())
+	servico.UsarEstrategia(CargaSobDemanda)
+	defer servico.Parar(context.Background())
+
+	if err := servico.Iniciar(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if n := provedor.chamadas.Load(); n != 0 {
+		t.Fatalf("carga sob demanda não deveria buscar na inicialização, obtidas %d", n)
+	}
+
+	servico.ObterTaxas(context.Background())
+	servico.ObterTaxas(context.Background())
+	if n := provedor.chamadas.Load(); n != 1 {
+		t.Errorf("esperada 1 busca, obtidas %d", n)
+	}
+
+	// Limpar descarta as taxas em memória
+	if err := servico.LimparCache(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	servico.ObterTaxas(context.Background())
+	if n := provedor.chamadas.Load(); n != 2 {
+		t.Errorf("esperada nova busca após limpar, obtidas %d", n)
+	}
+}
+
+func TestCargaPeriodicaIniciaAtualizacaoAutomatica(t *testing.T) {
+	provedor := &provedorContador{taxas: taxasUSD()}
+	armazenamento := NewCacheMemoria()
+	servico := novoServicoTeste(provedor, armazenamento)
+	servico.UsarEstrategia(CargaPeriodica)
+
+	if err := servico.Iniciar(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	prazo := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := armazenamento.Ler(context.Background()); err == nil {
+			break
+		}
+		if time.Now().After(prazo) {
+			t.Fatal("carga periódica deveria preencher o cache em segundo plano")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := servico.Parar(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+}
+
+func TestEstrategiaCargaValida(t *testing.T) {
+	if err := CargaSobDemanda.Valida(); err != nil {
+		t.Errorf("sob_demanda deveria ser válida: %v", err)
+	}
+	if err := EstrategiaCarga("preguicosa").Valida(); err == nil {
+		t.Error("estratégia desconhecida deveria ser rejeitada")
+	}
+}
@@ -0,0 +1,31 @@
+package cambio
+
+import (
+	"golang-project/moeda"
+)
+
+// CASAS_TAXA é a precisão com que as taxas de câmbio são aplicadas e gravadas
+const CASAS_TAXA = 8
+
+// Conversao representa o resultado de uma conversão entre moedas, com a taxa
+// efetivamente aplicada e os valores já arredondados às regras de cada moeda
+type Conversao struct {
+	ValorOrigem  moeda.Decimal `json:"valor_origem"`
+	ValorDestino moeda.Decimal `json:"valor_destino"`
+	MoedaOrigem  string        `json:"moeda_origem"`
+	MoedaDestino string        `json:"moeda_destino"`
+	Taxa         moeda.Decimal `json:"taxa"`
+	// Fonte indica se a taxa foi buscada agora, veio do cache ou da tabela de fallback
+	Fonte FonteTaxa `json:"fonte,omitempty"`
+
+	// Precificação (vazios em conversões à taxa média)
+	Tipo      string        `json:"tipo,omitempty"`
+	TaxaMedia moeda.Decimal `json:"taxa_media"`
+	Spread    moeda.Decimal `json:"spread"`
+	Comissao  moeda.Decimal `json:"comissao"`
+
+	// IOF, sempre em BRL e cobrado à parte (vazios quando não calculado)
+	CategoriaIOF string        `json:"categoria_iof,omitempty"`
+	AliquotaIOF  moeda.Decimal `json:"aliquota_iof"`
+	ValorIOF     moeda.Decimal `json:"valor_iof"`
+}
@@ -0,0 +1,119 @@
+package cambio
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang-project/moeda"
+)
+
+// VALIDADE_COTACAO_PADRAO é por quanto tempo uma cotação travada pode ser executada
+const VALIDADE_COTACAO_PADRAO = 30 * time.Second
+
+var (
+	// ErrCotacaoNaoEncontrada indica um ID desconhecido, de outro usuário ou já utilizado
+	ErrCotacaoNaoEncontrada = errors.New("cotação não encontrada")
+	// ErrCotacaoExpirada indica que a validade da cotação terminou
+	ErrCotacaoExpirada = errors.New("cotação expirada")
+	// ErrCotacaoDivergente indica que a operação pedida difere da cotada
+	ErrCotacaoDivergente = errors.New("operação diferente da cotada")
+)
+
+// CotacaoTravada é o preço completo de uma operação (taxa, spread, comissão e
+// IOF) garantido até ExpiraEm. Cada cotação pode ser executada uma única vez.
+type CotacaoTravada struct {
+	ID string `json:"id"`
+	*Conversao
+	UserID   int       `json:"-"`
+	CriadaEm time.Time `json:"criada_em"`
+	ExpiraEm time.Time `json:"expira_em"`
+}
+
+// Expirada indica se a cotação não pode mais ser executada no instante informado
+func (c *CotacaoTravada) Expirada(agora time.Time) bool {
+	return !agora.Before(c.ExpiraEm)
+}
+
+// Confere verifica se a operação pedida é exatamente a cotada
+func (c *CotacaoTravada) Confere(tipo, moedaOrigem, moedaDestino string, valorOrigem moeda.Decimal, categoria CategoriaIOF) error {
+	if categoria == "" {
+		categoria = IOFEspecie
+	}
+	if c.Tipo != tipo || c.MoedaOrigem != moedaOrigem || c.MoedaDestino != moedaDestino ||
+		!c.ValorOrigem.Equal(valorOrigem) || c.CategoriaIOF != string(categoria) {
+		return fmt.Errorf("%w: cotado %s %s %s -> %s", ErrCotacaoDivergente, c.Tipo, c.ValorOrigem, c.MoedaOrigem, c.MoedaDestino)
+	}
+	return nil
+}
+
+// CotacaoRepository guarda as cotações travadas até sua execução
+type CotacaoRepository interface {
+	Salvar(ctx context.Context, cotacao *CotacaoTravada) error
+	// Consumir remove e retorna a cotação do usuário, desde que não esteja
+	// expirada e validar não retorne erro. Se validar falhar, a cotação é mantida.
+	Consumir(ctx context.Context, id string, userID int, validar func(*CotacaoTravada) error) (*CotacaoTravada, error)
+}
+
+// CotacoesEmMemoria implementa CotacaoRepository em memória, para uma única instância
+type CotacoesEmMemoria struct {
+	mu       sync.Mutex
+	cotacoes map[string]*CotacaoTravada
+}
+
+func NewCotacoesEmMemoria() *CotacoesEmMemoria {
+	return &CotacoesEmMemoria{cotacoes: make(map[string]*CotacaoTravada)}
+}
+
+func (m *CotacoesEmMemoria) Salvar(ctx context.Context, cotacao *CotacaoTravada) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Descartar cotações vencidas para o mapa não crescer indefinidamente
+	agora := time.Now()
+	for id, c := range m.cotacoes {
+		if c.Expirada(agora) {
+			delete(m.cotacoes, id)
+		}
+	}
+
+	m.cotacoes[cotacao.ID] = cotacao
+	return nil
+}
+
+func (m *CotacoesEmMemoria) Consumir(ctx context.Context, id string, userID int, validar func(*CotacaoTravada) error) (*CotacaoTravada, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cotacao, existe := m.cotacoes[id]
+	if !existe || cotacao.UserID != userID {
+		return nil, ErrCotacaoNaoEncontrada
+	}
+
+	if cotacao.Expirada(time.Now()) {
+		delete(m.cotacoes, id)
+		return nil, ErrCotacaoExpirada
+	}
+
+	if validar != nil {
+		if err := validar(cotacao); err != nil {
+			return nil, err
+		}
+	}
+
+	delete(m.cotacoes, id)
+	return cotacao, nil
+}
+
+// novoIDCotacao gera um identificador aleatório e imprevisível
+func novoIDCotacao() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("erro ao gerar ID da cotação: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
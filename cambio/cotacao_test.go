@@ -0,0 +1,108 @@
+package cambio
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-project/moeda"
+)
+
+func novaCotacaoTeste(id string, userID int, expiraEm time.Time) *CotacaoTravada {
+	return &CotacaoTravada{
+		ID: id,
+		Conversao: &Conversao{
+			ValorOrigem:  moeda.MustFromString("100.00"),
+			MoedaOrigem:  "USD",
+			MoedaDestino: "BRL",
+			Tipo:         "Venda",
+			CategoriaIOF: string(IOFEspecie),
+		},
+		UserID:   userID,
+		CriadaEm: time.Now(),
+		ExpiraEm: expiraEm,
+	}
+}
+
+func TestCotacaoSoPodeSerExecutadaUmaVez(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCotacoesEmMemoria()
+	repo.Salvar(ctx, novaCotacaoTeste("c1", 7, time.Now().Add(time.Minute)))
+
+	if _, err := repo.Consumir(ctx, "c1", 8, nil); !errors.Is(err, ErrCotacaoNaoEncontrada) {
+		t.Errorf("outro usuário não deveria ver a cotação, erro: %v", err)
+	}
+
+	cotacao, err := repo.Consumir(ctx, "c1", 7, nil)
+	if err != nil || cotacao.ID != "c1" {
+		t.Fatalf("esperada cotação c1, obtido %v (erro: %v)", cotacao, err)
+	}
+
+	if _, err := repo.Consumir(ctx, "c1", 7, nil); !errors.Is(err, ErrCotacaoNaoEncontrada) {
+		t.Errorf("cotação já usada deveria ser rejeitada, erro: %v", err)
+	}
+}
+
+func TestCotacaoExpirada(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCotacoesEmMemoria()
+	repo.Salvar(ctx, novaCotacaoTeste("c1", 7, time.Now().Add(-time.Second)))
+
+	if _, err := repo.Consumir(ctx, "c1", 7, nil); !errors.Is(err, ErrCotacaoExpirada) {
+		t.Errorf("esperado ErrCotacaoExpirada, obtido %v", err)
+	}
+}
+
+func TestCotacaoDivergenteNaoEConsumida(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCotacoesEmMemoria()
+	repo.Salvar(ctx, novaCotacaoTeste("c1", 7, time.Now().Add(time.Minute)))
+
+	validar := func(valor string) func(*CotacaoTravada) error {
+		return func(c *CotacaoTravada) error {
+			return c.Confere("Venda", "USD", "BRL", moeda.MustFromString(valor), "")
+		}
+	}
+
+	if _, err := repo.Consumir(ctx, "c1", 7, validar("150")); !errors.Is(err, ErrCotacaoDivergente) {
+		t.Fatalf("esperado ErrCotacaoDivergente, obtido %v", err)
+	}
+
+	// 100 e 100.00 são o mesmo valor; a cotação continua disponível após a divergência
+	if _, err := repo.Consumir(ctx, "c1", 7, validar("100")); err != nil {
+		t.Errorf("cotação deveria continuar válida após pedido divergente, erro: %v", err)
+	}
+}
+
+func TestCotacaoDevolvidaPodeSerExecutada(t *testing.T) {
+	ctx := context.Background()
+	servico := NewServicoTaxasCambioComCliente(NewCambioClientComProvedor(nil))
+	servico.cotacoes.Salvar(ctx, novaCotacaoTeste("c1", 7, time.Now().Add(time.Minute)))
+	servico.cotacoes.Salvar(ctx, novaCotacaoTeste("c2", 7, time.Now().Add(50*time.Millisecond)))
+
+	valor := moeda.MustFromString("100")
+	cotacao, err := servico.ExecutarCotacao(ctx, "c1", 7, valor, "USD", "BRL", "Venda", "")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// A gravação da transação falhou: a cotação volta a valer
+	if err := servico.DevolverCotacao(ctx, cotacao); err != nil {
+		t.Fatalf("erro ao devolver cotação: %v", err)
+	}
+	if _, err := servico.ExecutarCotacao(ctx, "c1", 7, valor, "USD", "BRL", "Venda", ""); err != nil {
+		t.Errorf("cotação devolvida deveria poder ser executada, erro: %v", err)
+	}
+
+	// Cotações expiradas não são devolvidas
+	expirada, err := servico.ExecutarCotacao(ctx, "c2", 7, valor, "USD", "BRL", "Venda", "")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	servico.DevolverCotacao(ctx, expirada)
+	if _, err := servico.ExecutarCotacao(ctx, "c2", 7, valor, "USD", "BRL", "Venda", ""); !errors.Is(err, ErrCotacaoNaoEncontrada) {
+		t.Errorf("cotação expirada não deveria ser devolvida, obtido %v", err)
+	}
+}
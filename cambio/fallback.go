@@ -0,0 +1,133 @@
+package cambio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// FonteTaxa indica de onde veio a taxa aplicada em uma conversão
+type FonteTaxa string
+
+const (
+	// FonteAoVivo é uma taxa buscada no provedor durante a própria requisição
+	FonteAoVivo FonteTaxa = "ao_vivo"
+	// FonteCache é uma taxa buscada antes e reaproveitada do cache ou da memória
+	FonteCache FonteTaxa = "cache"
+	// FonteFallback é uma taxa da tabela offline, usada quando nenhum provedor respondeu
+	FonteFallback FonteTaxa = "fallback"
+)
+
+// NOME_PROVEDOR_FALLBACK identifica as cotações da tabela offline em ResultadoTaxas.Fontes
+const NOME_PROVEDOR_FALLBACK = "fallback"
+
+// ehFonteFallback reconhece o nome gravado por FallbackProvider, com ou sem versão
+func ehFonteFallback(fonte string) bool {
+	return fonte == NOME_PROVEDOR_FALLBACK || strings.HasPrefix(fonte, NOME_PROVEDOR_FALLBACK+":")
+}
+
+// TabelaFallback é o formato do arquivo de taxas offline
+type TabelaFallback struct {
+	// Versao identifica a revisão da tabela e aparece na fonte das cotações
+	Versao string `json:"versao"`
+	// Vigencia é a data (2006-01-02) a partir da qual as taxas valem
+	Vigencia string                        `json:"vigencia"`
+	Taxas    map[string]map[string]float64 `json:"taxas"`
+}
+
+// FallbackProvider serve uma tabela de taxas versionada, para ser o último
+// provedor da cadeia de failover quando as APIs estão indisponíveis
+type FallbackProvider struct {
+	versao   string
+	vigencia time.Time
+	taxas    map[string]map[string]float64
+}
+
+// NewFallbackProvider cria o provedor a partir de uma tabela já validada
+func NewFallbackProvider(versao string, vigencia time.Time, taxas map[string]map[string]float64) *FallbackProvider {
+	return &FallbackProvider{
+		versao:   versao,
+		vigencia: vigencia,
+		taxas:    taxas,
+	}
+}
+
+// CarregarFallbackProvider lê e valida um arquivo no formato
+// {"versao": "2025-06.1", "vigencia": "2025-06-01", "taxas": {"USD": {"BRL": 5.42}}}
+func CarregarFallbackProvider(caminho string) (*FallbackProvider, error) {
+	data, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler tabela de fallback: %w", err)
+	}
+
+	var tabela TabelaFallback
+	if err := json.Unmarshal(data, &tabela); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar tabela de fallback: %w", err)
+	}
+
+	if strings.TrimSpace(tabela.Versao) == "" {
+		return nil, fmt.Errorf("tabela de fallback %s sem versao", caminho)
+	}
+
+	vigencia, err := time.ParseInLocation("2006-01-02", tabela.Vigencia, fusoBrasilia)
+	if err != nil {
+		return nil, fmt.Errorf("tabela de fallback %s com vigencia inválida %q: %w", caminho, tabela.Vigencia, err)
+	}
+
+	if len(tabela.Taxas) == 0 {
+		return nil, fmt.Errorf("tabela de fallback %s sem taxas", caminho)
+	}
+	for base, taxas := range tabela.Taxas {
+		for destino, taxa := range taxas {
+			if taxa <= 0 {
+				return nil, fmt.Errorf("tabela de fallback %s: taxa %s->%s deve ser positiva", caminho, base, destino)
+			}
+		}
+	}
+
+	return NewFallbackProvider(tabela.Versao, vigencia, tabela.Taxas), nil
+}
+
+func (f *FallbackProvider) Nome() string {
+	return NOME_PROVEDOR_FALLBACK
+}
+
+// Versao retorna a revisão da tabela carregada
+func (f *FallbackProvider) Versao() string {
+	return f.versao
+}
+
+// Vigencia retorna a data a partir da qual as taxas da tabela valem
+func (f *FallbackProvider) Vigencia() time.Time {
+	return f.vigencia
+}
+
+func (f *FallbackProvider) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	// Uma tabela publicada antes de entrar em vigor ainda não vale
+	if time.Now().Before(f.vigencia) {
+		return nil, fmt.Errorf("tabela de fallback %s só vale a partir de %s", f.versao, f.vigencia.Format("2006-01-02"))
+	}
+
+	taxasBase, existe := f.taxas[moedaBase]
+	if !existe {
+		return nil, fmt.Errorf("tabela de fallback %s sem taxas para %s", f.versao, moedaBase)
+	}
+
+	copia := make(map[string]float64, len(taxasBase))
+	for moeda, taxa := range taxasBase {
+		copia[moeda] = taxa
+	}
+
+	fmt.Printf("Aviso: usando taxas de fallback (versão %s, vigência %s) para %s\n",
+		f.versao, f.vigencia.Format("2006-01-02"), moedaBase)
+
+	return &Cotacoes{
+		Base:     moedaBase,
+		Taxas:    copia,
+		Fonte:    NOME_PROVEDOR_FALLBACK + ":" + f.versao,
+		ObtidoEm: f.vigencia,
+	}, nil
+}
@@ -0,0 +1,133 @@
+package cambio
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-project/moeda"
+)
+
+func escreverTabelaFallback(t *testing.T, conteudo string) string {
+	t.Helper()
+	caminho := filepath.Join(t.TempDir(), "fallback.json")
+	if err := os.WriteFile(caminho, []byte(conteudo), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return caminho
+}
+
+func TestCarregarFallbackProvider(t *testing.T) {
+	caminho := escreverTabelaFallback(t, `{"versao": "2025-06.1", "vigencia": "2025-06-01", "taxas": {"USD": {"BRL": 5.42}}}`)
+
+	p, err := CarregarFallbackProvider(caminho)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if p.Versao() != "2025-06.1" || p.Vigencia().Format("2006-01-02") != "2025-06-01" {
+		t.Errorf("versão e vigência não conferem: %s %s", p.Versao(), p.Vigencia())
+	}
+
+	cotacoes, err := p.BuscarTaxas(context.Background(), "USD")
+	if err != nil || cotacoes.Taxas["BRL"] != 5.42 {
+		t.Fatalf("esperado USD->BRL 5.42, obtido %+v (erro: %v)", cotacoes, err)
+	}
+	if cotacoes.Fonte != "fallback:2025-06.1" {
+		t.Errorf("fonte esperada fallback:2025-06.1, obtida %s", cotacoes.Fonte)
+	}
+
+	if _, err := p.BuscarTaxas(context.Background(), "EUR"); err == nil {
+		t.Error("esperado erro para base ausente na tabela")
+	}
+}
+
+func TestFallbackAntesDaVigencia(t *testing.T) {
+	amanha := time.Now().Add(24 * time.Hour)
+	futura := NewFallbackProvider("2099-01.1", amanha, map[string]map[string]float64{"USD": {"BRL": 5.0}})
+
+	_, err := futura.BuscarTaxas(context.Background(), "USD")
+	if err == nil || !strings.Contains(err.Error(), "só vale a partir de") {
+		t.Errorf("tabela ainda não vigente não deveria ser usada, obtido %v", err)
+	}
+}
+
+func TestCarregarFallbackProviderRejeitaTabelaInvalida(t *testing.T) {
+	casos := map[string]string{
+		"sem versão":      `{"vigencia": "2025-06-01", "taxas": {"USD": {"BRL": 5.42}}}`,
+		"sem vigência":    `{"versao": "1", "taxas": {"USD": {"BRL": 5.42}}}`,
+		"sem taxas":       `{"versao": "1", "vigencia": "2025-06-01", "taxas": {}}`,
+		"taxa zero":       `{"versao": "1", "vigencia": "2025-06-01", "taxas": {"USD": {"BRL": 0}}}`,
+		"formato antigo":  `{"USD": {"BRL": 5.42}}`,
+		"json malformado": `{"versao": `,
+	}
+
+	for nome, conteudo := range casos {
+		if _, err := CarregarFallbackProvider(escreverTabelaFallback(t, conteudo)); err == nil {
+			t.Errorf("%s: esperado erro", nome)
+		}
+	}
+}
+
+// provedorFalhando simula uma API fora do ar
+type provedorFalhando struct{}
+
+func (provedorFalhando) Nome() string { return "fora" }
+
+func (provedorFalhando) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	return nil, errors.New("serviço indisponível")
+}
+
+func TestConversaoIndicaFonteDaTaxa(t *testing.T) {
+	servico := novoServicoTeste(&provedorContador{taxas: taxasUSD()}, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+
+	conversao, err := servico.CalcularConversao(context.Background(), moeda.NewFromInt(10), "USD", "BRL")
+	if err != nil || conversao.Fonte != FonteAoVivo {
+		t.Fatalf("primeira conversão deveria ser ao_vivo, obtido %+v (erro: %v)", conversao, err)
+	}
+
+	conversao, err = servico.CalcularConversao(context.Background(), moeda.NewFromInt(10), "USD", "BRL")
+	if err != nil || conversao.Fonte != FonteCache {
+		t.Errorf("segunda conversão deveria vir do cache, obtido %+v (erro: %v)", conversao, err)
+	}
+
+	operacao, err := servico.CalcularOperacao(context.Background(), moeda.NewFromInt(10), "USD", "BRL", "Compra")
+	if err != nil || operacao.Fonte != FonteCache {
+		t.Errorf("precificação deveria manter a fonte, obtido %+v (erro: %v)", operacao, err)
+	}
+}
+
+func TestConversaoComFallback(t *testing.T) {
+	fallback := NewFallbackProvider("teste", time.Now(), map[string]map[string]float64{"USD": {"BRL": 5.0}})
+	servico := novoServicoTeste(NewFailoverProvider(provedorFalhando{}, fallback), NewCacheMemoria())
+	defer servico.Parar(context.Background())
+
+	conversao, err := servico.CalcularConversao(context.Background(), moeda.NewFromInt(10), "USD", "BRL")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if conversao.Fonte != FonteFallback || conversao.ValorDestino.String() != "50.00" {
+		t.Errorf("esperada conversão de fallback em 50.00, obtido %+v", conversao)
+	}
+
+	// Reaproveitada do cache, a taxa continua sendo de fallback
+	conversao, err = servico.CalcularConversao(context.Background(), moeda.NewFromInt(10), "USD", "BRL")
+	if err != nil || conversao.Fonte != FonteFallback {
+		t.Errorf("taxa de fallback em cache deveria manter a fonte, obtido %+v (erro: %v)", conversao, err)
+	}
+}
+
+func TestConversaoSemTaxaNaoUsaUmParaUm(t *testing.T) {
+	fallback := NewFallbackProvider("teste", time.Now(), map[string]map[string]float64{"USD": {"BRL": 5.0}})
+	servico := novoServicoTeste(NewFailoverProvider(provedorFalhando{}, fallback), NewCacheMemoria())
+	defer servico.Parar(context.Background())
+
+	_, err := servico.CalcularConversao(context.Background(), moeda.NewFromInt(10), "USD", "GBP")
+	if err == nil || !strings.Contains(err.Error(), "USD -> GBP") {
+		t.Errorf("esperado erro para par sem taxa, obtido %v", err)
+	}
+}
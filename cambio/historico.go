@@ -0,0 +1,96 @@
+package cambio
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-project/moeda"
+)
+
+// MAXIMO_DIAS_INTERVALO limita o intervalo de agregação do histórico (cerca de 10 anos)
+const MAXIMO_DIAS_INTERVALO = 3660
+
+// ConsultaHistorico filtra a série histórica de um par de moedas
+type ConsultaHistorico struct {
+	Origem    string
+	Destino   string
+	De        time.Time
+	Ate       time.Time
+	Intervalo time.Duration
+}
+
+// PontoHistorico agrega as taxas de um intervalo no formato OHLC
+// (abertura, máxima, mínima e fechamento)
+type PontoHistorico struct {
+	Inicio     time.Time     `json:"inicio"`
+	Abertura   moeda.Decimal `json:"abertura"`
+	Maxima     moeda.Decimal `json:"maxima"`
+	Minima     moeda.Decimal `json:"minima"`
+	Fechamento moeda.Decimal `json:"fechamento"`
+	Amostras   int           `json:"amostras"`
+	Fontes     []string      `json:"fontes"`
+}
+
+// HistoricoRepository persiste cada busca de taxas e consulta a série histórica
+type HistoricoRepository interface {
+	Registrar(ctx context.Context, resultado *ResultadoTaxas) error
+	Consultar(ctx context.Context, consulta ConsultaHistorico) ([]PontoHistorico, error)
+}
+
+// taxasDeMercado retorna uma cópia do resultado sem as moedas base servidas
+// pela tabela de fallback, que não são cotações observadas e não entram no
+// histórico. Retorna nil se nenhuma moeda base restar.
+func taxasDeMercado(resultado *ResultadoTaxas) *ResultadoTaxas {
+	copia := *resultado
+	copia.Taxas = make(map[string]map[string]float64, len(resultado.Taxas))
+	for base, taxas := range resultado.Taxas {
+		if ehFonteFallback(resultado.Fontes[base]) {
+			continue
+		}
+		copia.Taxas[base] = taxas
+	}
+
+	if len(copia.Taxas) == 0 {
+		return nil
+	}
+	return &copia
+}
+
+// ParseIntervalo interpreta intervalos como "15m", "1h", "1d" e "1w".
+// Também aceita qualquer duração válida para time.ParseDuration, de 1 minuto
+// até MAXIMO_DIAS_INTERVALO dias.
+func ParseIntervalo(s string) (time.Duration, error) {
+	maximo := time.Duration(MAXIMO_DIAS_INTERVALO) * 24 * time.Hour
+
+	s = strings.TrimSpace(s)
+	if n := len(s); n > 1 && (s[n-1] == 'd' || s[n-1] == 'w') {
+		quantidade, err := strconv.Atoi(s[:n-1])
+		if err != nil || quantidade <= 0 {
+			return 0, fmt.Errorf("intervalo inválido: %q", s)
+		}
+		// Limitar antes de multiplicar, para a duração não estourar
+		dias := quantidade
+		if s[n-1] == 'w' {
+			if quantidade > MAXIMO_DIAS_INTERVALO/7 {
+				return 0, fmt.Errorf("intervalo inválido: %q (máximo %d dias)", s, MAXIMO_DIAS_INTERVALO)
+			}
+			dias *= 7
+		}
+		if dias > MAXIMO_DIAS_INTERVALO {
+			return 0, fmt.Errorf("intervalo inválido: %q (máximo %d dias)", s, MAXIMO_DIAS_INTERVALO)
+		}
+		return time.Duration(dias) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute {
+		return 0, fmt.Errorf("intervalo inválido: %q (mínimo 1m)", s)
+	}
+	if d > maximo {
+		return 0, fmt.Errorf("intervalo inválido: %q (máximo %d dias)", s, MAXIMO_DIAS_INTERVALO)
+	}
+	return d, nil
+}
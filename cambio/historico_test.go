@@ -0,0 +1,109 @@
+package cambio
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseIntervalo(t *testing.T) {
+	casos := map[string]time.Duration{
+		"15m":   15 * time.Minute,
+		"1h":    time.Hour,
+		"1d":    24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"3660d": MAXIMO_DIAS_INTERVALO * 24 * time.Hour,
+	}
+	for entrada, esperado := range casos {
+		d, err := ParseIntervalo(entrada)
+		if err != nil || d != esperado {
+			t.Errorf("%q: esperado %v, obtido %v (erro: %v)", entrada, esperado, d, err)
+		}
+	}
+
+	// Quantidades que estourariam time.Duration (281474976710656d vira 0)
+	// são rejeitadas antes da conversão
+	estouros := []string{"3661d", "523w", "281474976710656d", "40210710958665w", "99999999999999999999d", "87840h1m"}
+	for _, invalido := range append([]string{"", "0d", "-1d", "xd", "10s", "abc"}, estouros...) {
+		if _, err := ParseIntervalo(invalido); err == nil {
+			t.Errorf("%q: esperado erro", invalido)
+		}
+	}
+}
+
+func TestBuscarTaxasRegistraFonteEMomento(t *testing.T) {
+	cliente := NewCambioClientComProvedor(NewStaticProvider(map[string]map[string]float64{
+		"USD": {"BRL": 5.0},
+	}))
+
+	antes := time.Now()
+	resultado, err := cliente.BuscarTaxasParaTodasMoedas(context.Background())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if resultado.Fontes["USD"] != "estatico" {
+		t.Errorf("fonte esperada estatico, obtida %q", resultado.Fontes["USD"])
+	}
+	if resultado.ObtidoEm.Before(antes) {
+		t.Errorf("ObtidoEm deveria ser o momento da busca, obtido %v", resultado.ObtidoEm)
+	}
+}
+
+// historicoFalso guarda os resultados registrados
+type historicoFalso struct {
+	HistoricoRepository
+	registrados []*ResultadoTaxas
+}
+
+func (h *historicoFalso) Registrar(ctx context.Context, resultado *ResultadoTaxas) error {
+	h.registrados = append(h.registrados, resultado)
+	return nil
+}
+
+func TestHistoricoIgnoraTaxasDeFallback(t *testing.T) {
+	fallback := NewFallbackProvider("2025-06.1", time.Now().Add(-24*time.Hour), taxasUSD())
+	servico := novoServicoTeste(fallback, NewCacheMemoria())
+	historico := &historicoFalso{}
+	servico.UsarHistorico(historico)
+	defer servico.Parar(context.Background())
+
+	if _, err := servico.ForcarAtualizacao(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(historico.registrados) != 0 {
+		t.Errorf("taxas de fallback não deveriam entrar no histórico, registrado %+v", historico.registrados)
+	}
+
+	// Taxas de mercado continuam sendo registradas
+	servico = novoServicoTeste(&provedorContador{taxas: taxasUSD()}, NewCacheMemoria())
+	servico.UsarHistorico(historico)
+	defer servico.Parar(context.Background())
+
+	if _, err := servico.ForcarAtualizacao(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(historico.registrados) != 1 || historico.registrados[0].Taxas["EUR"] == nil {
+		t.Errorf("esperado 1 registro com as taxas trianguladas, obtido %+v", historico.registrados)
+	}
+}
+
+func TestTaxasDeMercadoSeparaFontes(t *testing.T) {
+	resultado := &ResultadoTaxas{
+		Taxas:  map[string]map[string]float64{"USD": {"BRL": 5.0}, "EUR": {"BRL": 5.5}, "GBP": {"BRL": 6.5}},
+		Fontes: map[string]string{"USD": "fxratesapi", "EUR": NOME_PROVEDOR_FALLBACK + ":2025-06.1", "GBP": NOME_PROVEDOR_FALLBACK},
+	}
+
+	mercado := taxasDeMercado(resultado)
+	if mercado == nil || len(mercado.Taxas) != 1 || mercado.Taxas["USD"] == nil {
+		t.Errorf("esperadas apenas as taxas de USD, obtido %+v", mercado)
+	}
+	if len(resultado.Taxas) != 3 {
+		t.Errorf("o resultado original não deveria ser alterado: %+v", resultado.Taxas)
+	}
+
+	delete(resultado.Taxas, "USD")
+	if mercado := taxasDeMercado(resultado); mercado != nil {
+		t.Errorf("sem taxas de mercado, esperado nil, obtido %+v", mercado)
+	}
+}
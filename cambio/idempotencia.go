@@ -0,0 +1,147 @@
+package cambio
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RETENCAO_IDEMPOTENCIA_PADRAO é por quanto tempo a resposta de uma
+// requisição fica guardada para ser repetida
+const RETENCAO_IDEMPOTENCIA_PADRAO = 24 * time.Hour
+
+// PRAZO_RESERVA_IDEMPOTENCIA é por quanto tempo uma requisição em andamento
+// segura a chave. Passado esse prazo sem resposta guardada, a requisição é
+// considerada perdida e uma repetição pode reservar a chave de novo.
+const PRAZO_RESERVA_IDEMPOTENCIA = time.Minute
+
+// TAMANHO_MAXIMO_CHAVE_IDEMPOTENCIA limita o cabeçalho Idempotency-Key
+const TAMANHO_MAXIMO_CHAVE_IDEMPOTENCIA = 255
+
+var (
+	// ErrIdempotenciaConflitante indica uma chave já usada com outro corpo de requisição
+	ErrIdempotenciaConflitante = errors.New("chave de idempotência já usada com outra requisição")
+	// ErrIdempotenciaEmAndamento indica que a primeira requisição com a chave ainda não terminou
+	ErrIdempotenciaEmAndamento = errors.New("requisição com esta chave de idempotência ainda em andamento")
+)
+
+// RegistroIdempotencia é a primeira requisição feita com uma chave de
+// idempotência e, depois de concluída, a resposta que ela recebeu
+type RegistroIdempotencia struct {
+	UserID int
+	Chave  string
+	// Hash identifica o corpo da requisição, para detectar reúso da chave
+	Hash string
+	// Status é zero enquanto a primeira requisição está em andamento
+	Status   int
+	Corpo    []byte
+	CriadoEm time.Time
+}
+
+// Conferir verifica se uma nova requisição com o hash informado pode
+// receber a resposta guardada
+func (r *RegistroIdempotencia) Conferir(hash string) error {
+	if r.Hash != hash {
+		return ErrIdempotenciaConflitante
+	}
+	if r.Status == 0 {
+		return ErrIdempotenciaEmAndamento
+	}
+	return nil
+}
+
+// abandonada indica uma reserva ainda em andamento depois do prazo, cuja
+// requisição morreu sem concluir nem liberar a chave
+func (r *RegistroIdempotencia) abandonada(agora time.Time) bool {
+	return r.Status == 0 && r.CriadoEm.Before(agora.Add(-PRAZO_RESERVA_IDEMPOTENCIA))
+}
+
+// HashRequisicao calcula o hash do corpo de uma requisição
+func HashRequisicao(corpo []byte) string {
+	soma := sha256.Sum256(corpo)
+	return hex.EncodeToString(soma[:])
+}
+
+// IdempotenciaRepository guarda as respostas das requisições feitas com
+// chave de idempotência, por usuário e chave
+type IdempotenciaRepository interface {
+	// Reservar registra a chave como em andamento. Se ela já tiver um
+	// registro criado depois de validoDesde, nada é alterado e esse registro
+	// é retornado; registros mais antigos são descartados, assim como
+	// reservas em andamento há mais de PRAZO_RESERVA_IDEMPOTENCIA antes de
+	// registro.CriadoEm.
+	Reservar(ctx context.Context, registro *RegistroIdempotencia, validoDesde time.Time) (*RegistroIdempotencia, error)
+	// Concluir guarda a resposta da requisição que fez a reserva. Se ela já
+	// tiver sido retomada por outra requisição, nada é alterado.
+	Concluir(ctx context.Context, reserva *RegistroIdempotencia, status int, corpo []byte) error
+	// Liberar descarta a reserva de uma requisição que falhou sem efeito,
+	// para que ela possa ser repetida com a mesma chave. Assim como em
+	// Concluir, só a própria reserva é descartada.
+	Liberar(ctx context.Context, reserva *RegistroIdempotencia) error
+}
+
+type chaveIdempotencia struct {
+	userID int
+	chave  string
+}
+
+// IdempotenciaEmMemoria implementa IdempotenciaRepository em memória, para uma única instância
+type IdempotenciaEmMemoria struct {
+	mu        sync.Mutex
+	registros map[chaveIdempotencia]*RegistroIdempotencia
+}
+
+func NewIdempotenciaEmMemoria() *IdempotenciaEmMemoria {
+	return &IdempotenciaEmMemoria{registros: make(map[chaveIdempotencia]*RegistroIdempotencia)}
+}
+
+func (m *IdempotenciaEmMemoria) Reservar(ctx context.Context, registro *RegistroIdempotencia, validoDesde time.Time) (*RegistroIdempotencia, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Descartar registros vencidos para o mapa não crescer indefinidamente
+	for chave, r := range m.registros {
+		if r.CriadoEm.Before(validoDesde) {
+			delete(m.registros, chave)
+		}
+	}
+
+	chave := chaveIdempotencia{userID: registro.UserID, chave: registro.Chave}
+	if existente, existe := m.registros[chave]; existe && !existente.abandonada(registro.CriadoEm) {
+		copia := *existente
+		return &copia, nil
+	}
+
+	reservado := *registro
+	reservado.Status = 0
+	reservado.Corpo = nil
+	m.registros[chave] = &reservado
+	return nil, nil
+}
+
+func (m *IdempotenciaEmMemoria) Concluir(ctx context.Context, reserva *RegistroIdempotencia, status int, corpo []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	registro, existe := m.registros[chaveIdempotencia{userID: reserva.UserID, chave: reserva.Chave}]
+	if !existe || !registro.CriadoEm.Equal(reserva.CriadoEm) {
+		return nil
+	}
+	registro.Status = status
+	registro.Corpo = append([]byte(nil), corpo...)
+	return nil
+}
+
+func (m *IdempotenciaEmMemoria) Liberar(ctx context.Context, reserva *RegistroIdempotencia) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	chaveRegistro := chaveIdempotencia{userID: reserva.UserID, chave: reserva.Chave}
+	if registro, existe := m.registros[chaveRegistro]; existe && registro.Status == 0 && registro.CriadoEm.Equal(reserva.CriadoEm) {
+		delete(m.registros, chaveRegistro)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package cambio
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdempotenciaEmMemoriaRepeteResposta(t *testing.T) {
+	ctx := context.Background()
+	repo := NewIdempotenciaEmMemoria()
+	agora := time.Now()
+	registro := &RegistroIdempotencia{UserID: 1, Chave: "abc", Hash: "h1", CriadoEm: agora}
+
+	existente, err := repo.Reservar(ctx, registro, agora.Add(-time.Hour))
+	if err != nil || existente != nil {
+		t.Fatalf("primeira reserva deveria ser livre, obtido %+v, %v", existente, err)
+	}
+
+	existente, _ = repo.Reservar(ctx, registro, agora.Add(-time.Hour))
+	if err := existente.Conferir("h1"); !errors.Is(err, ErrIdempotenciaEmAndamento) {
+		t.Errorf("esperado ErrIdempotenciaEmAndamento, obtido %v", err)
+	}
+
+	repo.Concluir(ctx, registro, 201, []byte(`{"id":1}`))
+	existente, _ = repo.Reservar(ctx, registro, agora.Add(-time.Hour))
+	if err := existente.Conferir("h1"); err != nil || existente.Status != 201 || string(existente.Corpo) != `{"id":1}` {
+		t.Errorf("esperada a resposta guardada, obtido %+v, %v", existente, err)
+	}
+	if err := existente.Conferir("h2"); !errors.Is(err, ErrIdempotenciaConflitante) {
+		t.Errorf("outro corpo: esperado ErrIdempotenciaConflitante, obtido %v", err)
+	}
+
+	// Uma resposta concluída não é liberada, e a chave é por usuário
+	repo.Liberar(ctx, registro)
+	if existente, _ := repo.Reservar(ctx, registro, agora.Add(-time.Hour)); existente == nil {
+		t.Error("resposta concluída não deveria ser liberada")
+	}
+	outro := &RegistroIdempotencia{UserID: 2, Chave: "abc", Hash: "h1", CriadoEm: agora}
+	if existente, _ := repo.Reservar(ctx, outro, agora.Add(-time.Hour)); existente != nil {
+		t.Error("a mesma chave de outro usuário deveria ser livre")
+	}
+}
+
+func TestIdempotenciaEmMemoriaRetencao(t *testing.T) {
+	ctx := context.Background()
+	repo := NewIdempotenciaEmMemoria()
+	inicio := time.Now()
+
+	primeiro := &RegistroIdempotencia{UserID: 1, Chave: "abc", Hash: "h1", CriadoEm: inicio}
+	repo.Reservar(ctx, primeiro, inicio.Add(-time.Hour))
+	repo.Concluir(ctx, primeiro, 201, []byte(`{}`))
+
+	// Depois da retenção a chave pode ser reutilizada, mesmo com outro corpo
+	depois := inicio.Add(2 * time.Hour)
+	novo := &RegistroIdempotencia{UserID: 1, Chave: "abc", Hash: "h2", CriadoEm: depois}
+	if existente, err := repo.Reservar(ctx, novo, depois.Add(-time.Hour)); err != nil || existente != nil {
+		t.Errorf("chave vencida deveria ser livre, obtido %+v, %v", existente, err)
+	}
+}
+
+func TestIdempotenciaEmMemoriaLiberaReserva(t *testing.T) {
+	ctx := context.Background()
+	repo := NewIdempotenciaEmMemoria()
+	agora := time.Now()
+	registro := &RegistroIdempotencia{UserID: 1, Chave: "abc", Hash: "h1", CriadoEm: agora}
+
+	repo.Reservar(ctx, registro, agora.Add(-time.Hour))
+	repo.Liberar(ctx, registro)
+
+	if existente, _ := repo.Reservar(ctx, registro, agora.Add(-time.Hour)); existente != nil {
+		t.Errorf("reserva liberada deveria ser livre, obtido %+v", existente)
+	}
+}
+
+func TestIdempotenciaEmMemoriaRetomaReservaAbandonada(t *testing.T) {
+	ctx := context.Background()
+	repo := NewIdempotenciaEmMemoria()
+	inicio := time.Now()
+	perdida := &RegistroIdempotencia{UserID: 1, Chave: "abc", Hash: "h1", CriadoEm: inicio}
+	repo.Reservar(ctx, perdida, inicio.Add(-time.Hour))
+
+	// Dentro do prazo, a reserva ainda é da primeira requisição
+	dentro := &RegistroIdempotencia{UserID: 1, Chave: "abc", Hash: "h1", CriadoEm: inicio.Add(PRAZO_RESERVA_IDEMPOTENCIA / 2)}
+	if existente, _ := repo.Reservar(ctx, dentro, inicio.Add(-time.Hour)); existente == nil {
+		t.Fatal("reserva dentro do prazo deveria continuar em andamento")
+	}
+
+	// Depois do prazo, a repetição retoma a chave
+	depois := &RegistroIdempotencia{UserID: 1, Chave: "abc", Hash: "h1", CriadoEm: inicio.Add(2 * PRAZO_RESERVA_IDEMPOTENCIA)}
+	if existente, err := repo.Reservar(ctx, depois, inicio.Add(-time.Hour)); err != nil || existente != nil {
+		t.Fatalf("reserva abandonada deveria ser retomada, obtido %+v, %v", existente, err)
+	}
+
+	// A requisição perdida, se ainda terminar, não mexe na nova reserva
+	repo.Liberar(ctx, perdida)
+	repo.Concluir(ctx, perdida, 500, []byte(`{}`))
+	existente, _ := repo.Reservar(ctx, dentro, inicio.Add(-time.Hour))
+	if existente == nil || !existente.CriadoEm.Equal(depois.CriadoEm) || existente.Status != 0 {
+		t.Fatalf("nova reserva deveria continuar em andamento, obtido %+v", existente)
+	}
+
+	repo.Concluir(ctx, depois, 201, []byte(`{"id":1}`))
+	existente, _ = repo.Reservar(ctx, depois, inicio.Add(-time.Hour))
+	if existente == nil || existente.Status != 201 {
+		t.Errorf("esperada a resposta da nova reserva, obtido %+v", existente)
+	}
+
+	// Respostas concluídas não expiram pelo prazo da reserva
+	muitoDepois := &RegistroIdempotencia{UserID: 1, Chave: "abc", Hash: "h1", CriadoEm: inicio.Add(10 * PRAZO_RESERVA_IDEMPOTENCIA)}
+	if existente, _ := repo.Reservar(ctx, muitoDepois, inicio.Add(-time.Hour)); existente == nil || existente.Status != 201 {
+		t.Errorf("resposta concluída deveria ser mantida, obtido %+v", existente)
+	}
+}
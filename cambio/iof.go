@@ -0,0 +1,180 @@
+package cambio
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"golang-project/moeda"
+)
+
+// CategoriaIOF classifica a operação de câmbio para fins de IOF
+type CategoriaIOF string
+
+const (
+	// IOFEspecie é a compra ou venda de moeda estrangeira em espécie
+	IOFEspecie CategoriaIOF = "especie"
+	// IOFCartao cobre cartões de crédito, débito e pré-pagos internacionais
+	IOFCartao CategoriaIOF = "cartao"
+	// IOFRemessa cobre transferências para o exterior
+	IOFRemessa CategoriaIOF = "remessa"
+	// IOFInvestimento cobre remessas para aplicação no exterior
+	IOFInvestimento CategoriaIOF = "investimento"
+)
+
+// Valida verifica se a categoria é conhecida
+func (c CategoriaIOF) Valida() bool {
+	switch c {
+	case IOFEspecie, IOFCartao, IOFRemessa, IOFInvestimento:
+		return true
+	}
+	return false
+}
+
+// fusoBrasilia é usado para decidir em que dia uma operação ocorreu:
+// as alíquotas mudam à meia-noite de Brasília (sem horário de verão desde 2019)
+var fusoBrasilia = time.FixedZone("BRT", -3*60*60)
+
+// VigenciaIOF são as alíquotas percentuais em vigor a partir de uma data
+type VigenciaIOF struct {
+	Inicio    time.Time
+	Aliquotas map[CategoriaIOF]moeda.Decimal
+}
+
+// TabelaIOF é o histórico de alíquotas de IOF, ordenado por início de vigência
+type TabelaIOF struct {
+	vigencias []VigenciaIOF
+}
+
+func dataBrasilia(ano int, mes time.Month, dia int) time.Time {
+	return time.Date(ano, mes, dia, 0, 0, 0, 0, fusoBrasilia)
+}
+
+func NewTabelaIOF(vigencias []VigenciaIOF) *TabelaIOF {
+	ordenadas := append([]VigenciaIOF(nil), vigencias...)
+	sort.Slice(ordenadas, func(i, j int) bool {
+		return ordenadas[i].Inicio.Before(ordenadas[j].Inicio)
+	})
+	return &TabelaIOF{vigencias: ordenadas}
+}
+
+// TabelaIOFPadrao retorna as alíquotas do Decreto 6.306/2007 e alterações
+func TabelaIOFPadrao() *TabelaIOF {
+	p := moeda.MustFromString
+	return NewTabelaIOF([]VigenciaIOF{
+		{
+			Inicio: dataBrasilia(2023, time.January, 2),
+			Aliquotas: map[CategoriaIOF]moeda.Decimal{
+				IOFEspecie: p("1.1"), IOFCartao: p("5.38"), IOFRemessa: p("0.38"), IOFInvestimento: p("0.38"),
+			},
+		},
+		{
+			Inicio: dataBrasilia(2024, time.January, 2),
+			Aliquotas: map[CategoriaIOF]moeda.Decimal{
+				IOFEspecie: p("1.1"), IOFCartao: p("4.38"), IOFRemessa: p("0.38"), IOFInvestimento: p("0.38"),
+			},
+		},
+		{
+			Inicio: dataBrasilia(2025, time.January, 2),
+			Aliquotas: map[CategoriaIOF]moeda.Decimal{
+				IOFEspecie: p("1.1"), IOFCartao: p("3.38"), IOFRemessa: p("0.38"), IOFInvestimento: p("0.38"),
+			},
+		},
+		{
+			// Decreto 12.499/2025
+			Inicio: dataBrasilia(2025, time.May, 23),
+			Aliquotas: map[CategoriaIOF]moeda.Decimal{
+				IOFEspecie: p("3.5"), IOFCartao: p("3.5"), IOFRemessa: p("3.5"), IOFInvestimento: p("1.1"),
+			},
+		},
+	})
+}
+
+// CarregarTabelaIOF lê as vigências de um arquivo JSON no formato
+// [{"inicio": "2025-05-23", "aliquotas": {"especie": "3.5", ...}}]
+func CarregarTabelaIOF(caminho string) (*TabelaIOF, error) {
+	data, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler tabela de IOF: %w", err)
+	}
+
+	var entradas []struct {
+		Inicio    string                         `json:"inicio"`
+		Aliquotas map[CategoriaIOF]moeda.Decimal `json:"aliquotas"`
+	}
+	if err := json.Unmarshal(data, &entradas); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar tabela de IOF: %w", err)
+	}
+
+	vigencias := make([]VigenciaIOF, 0, len(entradas))
+	for _, e := range entradas {
+		inicio, err := time.ParseInLocation("2006-01-02", e.Inicio, fusoBrasilia)
+		if err != nil {
+			return nil, fmt.Errorf("início de vigência inválido %q: %w", e.Inicio, err)
+		}
+		for categoria, aliquota := range e.Aliquotas {
+			if !categoria.Valida() {
+				return nil, fmt.Errorf("categoria de IOF desconhecida em %s: %s", e.Inicio, categoria)
+			}
+			if aliquota.IsNegative() || aliquota.Cmp(cem) >= 0 {
+				return nil, fmt.Errorf("alíquota de IOF inválida em %s para %s: %s%%", e.Inicio, categoria, aliquota)
+			}
+		}
+		vigencias = append(vigencias, VigenciaIOF{Inicio: inicio, Aliquotas: e.Aliquotas})
+	}
+
+	return NewTabelaIOF(vigencias), nil
+}
+
+// Aliquota retorna a alíquota percentual da categoria em vigor na data
+func (t *TabelaIOF) Aliquota(categoria CategoriaIOF, data time.Time) (moeda.Decimal, error) {
+	for i := len(t.vigencias) - 1; i >= 0; i-- {
+		v := t.vigencias[i]
+		if v.Inicio.After(data) {
+			continue
+		}
+		aliquota, existe := v.Aliquotas[categoria]
+		if !existe {
+			return moeda.Decimal{}, fmt.Errorf("alíquota de IOF para %s não definida em %s", categoria, v.Inicio.Format("2006-01-02"))
+		}
+		return aliquota, nil
+	}
+	return moeda.Decimal{}, fmt.Errorf("nenhuma alíquota de IOF vigente em %s", data.In(fusoBrasilia).Format("2006-01-02"))
+}
+
+// Aplicar calcula o IOF da conversão na data informada. O imposto incide
+// sobre a perna em reais: o valor líquido pago em BRL (após a comissão) ou o
+// valor recebido em BRL. Operações sem BRL não sofrem IOF.
+func (t *TabelaIOF) Aplicar(conversao *Conversao, categoria CategoriaIOF, data time.Time) error {
+	if categoria == "" {
+		categoria = IOFEspecie
+	}
+	if !categoria.Valida() {
+		return fmt.Errorf("categoria de IOF inválida: %s", categoria)
+	}
+
+	var base moeda.Decimal
+	switch {
+	case conversao.MoedaOrigem == "BRL":
+		base = conversao.ValorOrigem.Sub(conversao.Comissao)
+	case conversao.MoedaDestino == "BRL":
+		base = conversao.ValorDestino
+	default:
+		conversao.CategoriaIOF = string(categoria)
+		conversao.AliquotaIOF = moeda.Decimal{}
+		conversao.ValorIOF = moeda.Decimal{}
+		return nil
+	}
+
+	aliquota, err := t.Aliquota(categoria, data)
+	if err != nil {
+		return err
+	}
+
+	conversao.CategoriaIOF = string(categoria)
+	conversao.AliquotaIOF = aliquota
+	conversao.ValorIOF = moeda.Padrao().Arredondar(base.Mul(aliquota).Div(cem, CASAS_TAXA, moeda.ArredondamentoBancario), "BRL")
+	return nil
+}
@@ -0,0 +1,107 @@
+package cambio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang-project/moeda"
+)
+
+func TestAliquotaIOFPorVigencia(t *testing.T) {
+	tabela := TabelaIOFPadrao()
+
+	casos := []struct {
+		categoria CategoriaIOF
+		data      time.Time
+		esperada  string
+	}{
+		{IOFCartao, time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC), "4.38"},
+		{IOFCartao, time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC), "3.38"},
+		{IOFEspecie, time.Date(2025, time.May, 22, 12, 0, 0, 0, time.UTC), "1.1"},
+		// 23/05 03:30 UTC é 23/05 00:30 em Brasília: já vale o Decreto 12.499
+		{IOFEspecie, time.Date(2025, time.May, 23, 3, 30, 0, 0, time.UTC), "3.5"},
+		// 22/05 23:00 em Brasília é 23/05 02:00 UTC, mas ainda vale a alíquota antiga
+		{IOFEspecie, time.Date(2025, time.May, 23, 2, 0, 0, 0, time.UTC), "1.1"},
+		{IOFInvestimento, time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC), "1.1"},
+	}
+
+	for _, c := range casos {
+		aliquota, err := tabela.Aliquota(c.categoria, c.data)
+		if err != nil {
+			t.Fatalf("%s em %v: erro inesperado: %v", c.categoria, c.data, err)
+		}
+		if !aliquota.Equal(moeda.MustFromString(c.esperada)) {
+			t.Errorf("%s em %v: alíquota esperada %s, obtida %s", c.categoria, c.data, c.esperada, aliquota)
+		}
+	}
+
+	if _, err := tabela.Aliquota(IOFEspecie, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("esperado erro para data anterior à primeira vigência")
+	}
+}
+
+func TestAplicarIOFNaPernaEmReais(t *testing.T) {
+	tabela := TabelaIOFPadrao()
+	data := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	// Compra de USD com BRL: IOF sobre o valor pago, descontada a comissão
+	compra := &Conversao{
+		ValorOrigem:  moeda.MustFromString("1010"),
+		Comissao:     moeda.MustFromString("10"),
+		MoedaOrigem:  "BRL",
+		MoedaDestino: "USD",
+		ValorDestino: moeda.MustFromString("180"),
+	}
+	if err := tabela.Aplicar(compra, IOFCartao, data); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !compra.ValorIOF.Equal(moeda.MustFromString("35")) {
+		t.Errorf("IOF esperado 35.00, obtido %s", compra.ValorIOF)
+	}
+
+	// Venda de USD por BRL, categoria padrão (espécie): IOF sobre o valor recebido
+	venda := &Conversao{
+		ValorOrigem:  moeda.MustFromString("100"),
+		MoedaOrigem:  "USD",
+		MoedaDestino: "BRL",
+		ValorDestino: moeda.MustFromString("543.21"),
+	}
+	if err := tabela.Aplicar(venda, "", data); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if venda.CategoriaIOF != "especie" || !venda.ValorIOF.Equal(moeda.MustFromString("19.01")) {
+		t.Errorf("esperado IOF de espécie 19.01, obtido %s %s", venda.CategoriaIOF, venda.ValorIOF)
+	}
+
+	// Sem BRL na operação não há IOF
+	arbitragem := &Conversao{ValorOrigem: moeda.NewFromInt(100), MoedaOrigem: "USD", MoedaDestino: "EUR"}
+	if err := tabela.Aplicar(arbitragem, IOFRemessa, data); err != nil || !arbitragem.ValorIOF.IsZero() {
+		t.Errorf("operação sem BRL não deveria ter IOF, obtido %s (erro: %v)", arbitragem.ValorIOF, err)
+	}
+
+	if err := tabela.Aplicar(compra, "cripto", data); err == nil {
+		t.Error("esperado erro para categoria inválida")
+	}
+}
+
+func TestCarregarTabelaIOF(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), "iof.json")
+	conteudo := `[{"inicio":"2030-01-01","aliquotas":{"especie":"0.5"}},{"inicio":"2029-01-01","aliquotas":{"especie":"1"}}]`
+	if err := os.WriteFile(caminho, []byte(conteudo), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tabela, err := CarregarTabelaIOF(caminho)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	aliquota, err := tabela.Aliquota(IOFEspecie, time.Date(2029, time.June, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil || !aliquota.Equal(moeda.NewFromInt(1)) {
+		t.Errorf("esperada alíquota 1 em 2029, obtida %s (erro: %v)", aliquota, err)
+	}
+	if _, err := tabela.Aliquota(IOFCartao, time.Date(2031, time.January, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("esperado erro para categoria sem alíquota na vigência")
+	}
+}
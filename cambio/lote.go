@@ -0,0 +1,59 @@
+package cambio
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang-project/moeda"
+)
+
+// MAXIMO_ITENS_LOTE limita quantas conversões um lote pode pedir
+const MAXIMO_ITENS_LOTE = 100
+
+// ItemConversao é uma conversão pedida em lote
+type ItemConversao struct {
+	Valor        moeda.Decimal
+	MoedaOrigem  string
+	MoedaDestino string
+}
+
+// ResultadoConversao traz a conversão de um item ou o motivo da falha
+type ResultadoConversao struct {
+	Conversao *Conversao
+	Erro      error
+}
+
+// LoteConversoes reúne as conversões de um lote, todas calculadas com as
+// mesmas taxas, buscadas em ObtidoEm. Resultados segue a ordem dos itens.
+type LoteConversoes struct {
+	Resultados []ResultadoConversao
+	ObtidoEm   time.Time
+	Obsoleto   bool
+}
+
+// ConverterLote converte todos os itens sobre uma única leitura das taxas.
+// Falhas de um item (par sem taxa, base indisponível) ficam no seu
+// resultado; só retorna erro quando as taxas não puderam ser obtidas.
+func (s *ServicoTaxasCambio) ConverterLote(ctx context.Context, itens []ItemConversao) (*LoteConversoes, error) {
+	if len(itens) > MAXIMO_ITENS_LOTE {
+		return nil, fmt.Errorf("lote com %d itens excede o máximo de %d", len(itens), MAXIMO_ITENS_LOTE)
+	}
+
+	resultado, err := s.ObterTaxas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter taxas: %w", err)
+	}
+
+	lote := &LoteConversoes{
+		Resultados: make([]ResultadoConversao, len(itens)),
+		ObtidoEm:   resultado.ObtidoEm,
+		Obsoleto:   resultado.Obsoleto,
+	}
+	for i, item := range itens {
+		conversao, err := s.converterCom(resultado, item.Valor, item.MoedaOrigem, item.MoedaDestino)
+		lote.Resultados[i] = ResultadoConversao{Conversao: conversao, Erro: err}
+	}
+
+	return lote, nil
+}
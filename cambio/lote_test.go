@@ -0,0 +1,54 @@
+package cambio
+
+import (
+	"context"
+	"testing"
+
+	"golang-project/moeda"
+)
+
+func TestConverterLoteUsaUmaUnicaLeitura(t *testing.T) {
+	provedor := &provedorContador{taxas: taxasUSD()}
+	servico := novoServicoTeste(provedor, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+
+	cem := moeda.NewFromInt(100)
+	lote, err := servico.ConverterLote(context.Background(), []ItemConversao{
+		{Valor: cem, MoedaOrigem: "USD", MoedaDestino: "BRL"},
+		{Valor: cem, MoedaOrigem: "EUR", MoedaDestino: "GBP"},
+		{Valor: cem, MoedaOrigem: "USD", MoedaDestino: "XYZ"},
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if n := provedor.chamadas.Load(); n != 1 {
+		t.Errorf("o lote deveria buscar as taxas uma vez, buscou %d", n)
+	}
+	if lote.ObtidoEm.IsZero() {
+		t.Error("lote deveria informar quando as taxas foram obtidas")
+	}
+	if len(lote.Resultados) != 3 {
+		t.Fatalf("esperados 3 resultados, obtidos %d", len(lote.Resultados))
+	}
+
+	if r := lote.Resultados[0]; r.Erro != nil || r.Conversao.ValorDestino.String() != "540.00" {
+		t.Errorf("USD->BRL: esperado 540.00, obtido %+v", r)
+	}
+	if r := lote.Resultados[1]; r.Erro != nil || r.Conversao.MoedaOrigem != "EUR" {
+		t.Errorf("EUR->GBP: esperada conversão derivada, obtido %+v", r)
+	}
+	if r := lote.Resultados[2]; r.Erro == nil || r.Conversao != nil {
+		t.Errorf("USD->XYZ: esperado erro apenas neste item, obtido %+v", r)
+	}
+}
+
+func TestConverterLoteLimitaItens(t *testing.T) {
+	servico := novoServicoTeste(&provedorContador{taxas: taxasUSD()}, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+
+	itens := make([]ItemConversao, MAXIMO_ITENS_LOTE+1)
+	if _, err := servico.ConverterLote(context.Background(), itens); err == nil {
+		t.Error("esperado erro para lote acima do máximo")
+	}
+}
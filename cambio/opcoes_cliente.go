@@ -0,0 +1,180 @@
+package cambio
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TIMEOUT_PADRAO limita cada requisição aos provedores quando ComTimeout não é usado
+const TIMEOUT_PADRAO = 15 * time.Second
+
+// PROVEDORES_PADRAO é a cadeia de failover usada quando nada é configurado
+var PROVEDORES_PADRAO = []string{"fxratesapi", "exchangerate-api", "ecb"}
+
+// transportePadrao é compartilhado por todos os clientes sem transporte ou
+// proxy próprios, para reaproveitar as conexões com os provedores
+var transportePadrao = novoTransporte()
+
+func novoTransporte() *http.Transport {
+	transporte := http.DefaultTransport.(*http.Transport).Clone()
+	transporte.MaxIdleConnsPerHost = 10
+	return transporte
+}
+
+// ChaveAPI é a credencial de um provedor, enviada no cabeçalho Cabecalho ou
+// no parâmetro de consulta Parametro. Sem nenhum dos dois, vai no cabeçalho
+// Authorization.
+type ChaveAPI struct {
+	Valor     string
+	Cabecalho string
+	Parametro string
+}
+
+type opcoesCliente struct {
+	timeout     time.Duration
+	transporte  http.RoundTripper
+	proxy       *url.URL
+	userAgent   string
+	urls        map[string]string
+	chaves      map[string]ChaveAPI
+	resiliencia Resiliencia
+}
+
+// OpcaoCliente ajusta a conexão do CambioClient com os provedores
+type OpcaoCliente func(*opcoesCliente)
+
+// ComTimeout define o tempo máximo de cada requisição a um provedor
+func ComTimeout(timeout time.Duration) OpcaoCliente {
+	return func(o *opcoesCliente) {
+		o.timeout = timeout
+	}
+}
+
+// ComTransporte substitui o transporte HTTP compartilhado (útil para stubs em
+// testes). Tem precedência sobre ComProxy.
+func ComTransporte(transporte http.RoundTripper) OpcaoCliente {
+	return func(o *opcoesCliente) {
+		o.transporte = transporte
+	}
+}
+
+// ComProxy envia as requisições aos provedores pelo proxy informado
+func ComProxy(proxy *url.URL) OpcaoCliente {
+	return func(o *opcoesCliente) {
+		o.proxy = proxy
+	}
+}
+
+// ComUserAgent define o User-Agent enviado aos provedores
+func ComUserAgent(userAgent string) OpcaoCliente {
+	return func(o *opcoesCliente) {
+		o.userAgent = userAgent
+	}
+}
+
+// ComURLBase troca o endereço de um provedor da cadeia, por exemplo para uma
+// conta paga ou um servidor local
+func ComURLBase(provedor, urlBase string) OpcaoCliente {
+	return func(o *opcoesCliente) {
+		o.urls[normalizarProvedor(provedor)] = urlBase
+	}
+}
+
+// ComChaveAPI autentica as requisições a um provedor. A chave só é enviada
+// a ele, nunca aos demais provedores da cadeia.
+func ComChaveAPI(provedor string, chave ChaveAPI) OpcaoCliente {
+	return func(o *opcoesCliente) {
+		o.chaves[normalizarProvedor(provedor)] = chave
+	}
+}
+
+// ComResiliencia define as retentativas, o limite de requisições e o
+// disjuntor de cada provedor; o padrão é ResilienciaPadrao
+func ComResiliencia(resiliencia Resiliencia) OpcaoCliente {
+	return func(o *opcoesCliente) {
+		o.resiliencia = resiliencia
+	}
+}
+
+func novasOpcoesCliente(opcoes []OpcaoCliente) *opcoesCliente {
+	o := &opcoesCliente{
+		timeout:     TIMEOUT_PADRAO,
+		urls:        make(map[string]string),
+		chaves:      make(map[string]ChaveAPI),
+		resiliencia: ResilienciaPadrao(),
+	}
+	for _, opcao := range opcoes {
+		opcao(o)
+	}
+	return o
+}
+
+func normalizarProvedor(nome string) string {
+	return strings.ToLower(strings.TrimSpace(nome))
+}
+
+// httpCliente retorna o cliente compartilhado pelos provedores da cadeia
+func (o *opcoesCliente) httpCliente() *http.Client {
+	var transporte http.RoundTripper = transportePadrao
+	switch {
+	case o.transporte != nil:
+		transporte = o.transporte
+	case o.proxy != nil:
+		comProxy := novoTransporte()
+		comProxy.Proxy = http.ProxyURL(o.proxy)
+		transporte = comProxy
+	}
+
+	if o.userAgent != "" {
+		transporte = &transporteAutenticado{base: transporte, userAgent: o.userAgent}
+	}
+
+	return &http.Client{Transport: transporte, Timeout: o.timeout}
+}
+
+// httpClienteDe retorna o cliente de um provedor: o compartilhado ou, se ele
+// tiver chave, um cliente sobre o mesmo transporte que acrescenta a chave
+func (o *opcoesCliente) httpClienteDe(provedor string, compartilhado *http.Client) *http.Client {
+	chave, existe := o.chaves[normalizarProvedor(provedor)]
+	if !existe {
+		return compartilhado
+	}
+
+	return &http.Client{
+		Transport: &transporteAutenticado{base: compartilhado.Transport, chave: chave},
+		Timeout:   compartilhado.Timeout,
+	}
+}
+
+// transporteAutenticado acrescenta User-Agent e chave de API às requisições
+type transporteAutenticado struct {
+	base      http.RoundTripper
+	userAgent string
+	chave     ChaveAPI
+}
+
+func (t *transporteAutenticado) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Um RoundTripper não deve alterar a requisição recebida
+	req = req.Clone(req.Context())
+
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	if t.chave.Valor != "" {
+		switch {
+		case t.chave.Cabecalho != "":
+			req.Header.Set(t.chave.Cabecalho, t.chave.Valor)
+		case t.chave.Parametro != "":
+			consulta := req.URL.Query()
+			consulta.Set(t.chave.Parametro, t.chave.Valor)
+			req.URL.RawQuery = consulta.Encode()
+		default:
+			req.Header.Set("Authorization", t.chave.Valor)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,112 @@
+package cambio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// transporteContador conta as requisições que passam pelo transporte
+type transporteContador struct {
+	base  http.RoundTripper
+	total atomic.Int32
+}
+
+func (t *transporteContador) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.total.Add(1)
+	return t.base.RoundTrip(req)
+}
+
+func TestOpcoesClienteChaveNoParametroEUserAgent(t *testing.T) {
+	var recebida *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recebida = r
+		w.Write([]byte(`{"rates": {"BRL": 5.4}}`))
+	}))
+	defer srv.Close()
+
+	cliente, err := NewCambioClientConfigurado([]string{"fxratesapi"}, "",
+		ComURLBase("fxratesapi", srv.URL),
+		ComChaveAPI("fxratesapi", ChaveAPI{Valor: "segredo", Parametro: "api_key"}),
+		ComUserAgent("cambio-teste/1.0"),
+	)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	taxas, err := cliente.BuscarTaxasCambio(context.Background(), "USD")
+	if err != nil || taxas["BRL"] != 5.4 {
+		t.Fatalf("esperadas as taxas do servidor local, obtido %v (erro: %v)", taxas, err)
+	}
+	if chave := recebida.URL.Query().Get("api_key"); chave != "segredo" {
+		t.Errorf("chave esperada no parâmetro api_key, obtido %q", chave)
+	}
+	if base := recebida.URL.Query().Get("base"); base != "USD" {
+		t.Errorf("parâmetro base deveria ser mantido, obtido %q", base)
+	}
+	if ua := recebida.Header.Get("User-Agent"); ua != "cambio-teste/1.0" {
+		t.Errorf("User-Agent esperado cambio-teste/1.0, obtido %q", ua)
+	}
+}
+
+func TestOpcoesClienteChaveSoVaiAoSeuProvedor(t *testing.T) {
+	pago := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "segredo" {
+			t.Errorf("provedor pago deveria receber a chave no cabeçalho")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer pago.Close()
+
+	var cabecalhoVazado atomic.Bool
+	publico := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "" {
+			cabecalhoVazado.Store(true)
+		}
+		w.Write([]byte(`{"result": "success", "conversion_rates": {"BRL": 5.5}}`))
+	}))
+	defer publico.Close()
+
+	transporte := &transporteContador{base: http.DefaultTransport}
+	cliente, err := NewCambioClientConfigurado([]string{"fxratesapi", "exchangerate-api"}, "",
+		ComURLBase("fxratesapi", pago.URL),
+		ComURLBase("exchangerate-api", publico.URL),
+		ComChaveAPI("fxratesapi", ChaveAPI{Valor: "segredo", Cabecalho: "X-API-Key"}),
+		ComTransporte(transporte),
+		ComResiliencia(Resiliencia{}),
+	)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	taxas, err := cliente.BuscarTaxasCambio(context.Background(), "USD")
+	if err != nil || taxas["BRL"] != 5.5 {
+		t.Fatalf("esperado failover para o provedor público, obtido %v (erro: %v)", taxas, err)
+	}
+	if cabecalhoVazado.Load() {
+		t.Error("a chave do provedor pago não pode ser enviada a outro provedor")
+	}
+	if n := transporte.total.Load(); n != 2 {
+		t.Errorf("os dois provedores deveriam usar o transporte informado, obtidas %d requisições", n)
+	}
+}
+
+func TestOpcoesClienteProvedorForaDaCadeia(t *testing.T) {
+	_, err := NewCambioClientConfigurado([]string{"ecb"}, "", ComURLBase("fxratesapi", "http://localhost:1"))
+	if err == nil || !strings.Contains(err.Error(), "fora da cadeia") {
+		t.Errorf("esperado erro de provedor fora da cadeia, obtido %v", err)
+	}
+}
+
+func TestOpcoesClienteCompartilhamTransportePadrao(t *testing.T) {
+	o := novasOpcoesCliente(nil)
+	if o.httpCliente().Transport != transportePadrao {
+		t.Error("clientes sem transporte próprio deveriam compartilhar o transporte padrão")
+	}
+	if o.timeout != TIMEOUT_PADRAO {
+		t.Errorf("timeout padrão esperado %s, obtido %s", TIMEOUT_PADRAO, o.timeout)
+	}
+}
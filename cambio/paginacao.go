@@ -0,0 +1,216 @@
+package cambio
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CampoOrdenacao é um campo pelo qual a listagem de transações pode ser ordenada
+type CampoOrdenacao string
+
+const (
+	OrdenarPorData         CampoOrdenacao = "data_transacao"
+	OrdenarPorValorOrigem  CampoOrdenacao = "valor_origem"
+	OrdenarPorValorDestino CampoOrdenacao = "valor_destino"
+	OrdenarPorMoedaOrigem  CampoOrdenacao = "moeda_origem"
+	OrdenarPorMoedaDestino CampoOrdenacao = "moeda_destino"
+	OrdenarPorStatus       CampoOrdenacao = "status"
+)
+
+// camposOrdenacao são os únicos campos aceitos na ordenação
+var camposOrdenacao = []CampoOrdenacao{
+	OrdenarPorData,
+	OrdenarPorValorOrigem,
+	OrdenarPorValorDestino,
+	OrdenarPorMoedaOrigem,
+	OrdenarPorMoedaDestino,
+	OrdenarPorStatus,
+}
+
+var (
+	// ErrOrdenacaoInvalida indica um campo ou direção de ordenação não suportados
+	ErrOrdenacaoInvalida = errors.New("ordenação inválida")
+	// ErrCursorInvalido indica um cursor corrompido ou de outra ordenação
+	ErrCursorInvalido = errors.New("cursor inválido")
+)
+
+// Ordenacao é a ordem da listagem de transações. Empates são desfeitos pelo
+// ID, na mesma direção, para que cada transação tenha uma posição única.
+type Ordenacao struct {
+	Campo       CampoOrdenacao
+	Decrescente bool
+}
+
+// OrdenacaoPadrao lista as transações mais recentes primeiro
+func OrdenacaoPadrao() Ordenacao {
+	return Ordenacao{Campo: OrdenarPorData, Decrescente: true}
+}
+
+// ParseOrdenacao interpreta o campo e a direção (asc ou desc) pedidos. Sem
+// campo, usa a data; sem direção, a ordem decrescente.
+func ParseOrdenacao(campo, direcao string) (Ordenacao, error) {
+	ordenacao := OrdenacaoPadrao()
+
+	if campo = strings.ToLower(strings.TrimSpace(campo)); campo != "" {
+		ordenacao.Campo = CampoOrdenacao(campo)
+		if !ordenacao.Campo.Valido() {
+			return Ordenacao{}, fmt.Errorf("%w: campo %q (use %s)", ErrOrdenacaoInvalida, campo, listaCamposOrdenacao())
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(direcao)) {
+	case "", "desc":
+		ordenacao.Decrescente = true
+	case "asc":
+		ordenacao.Decrescente = false
+	default:
+		return Ordenacao{}, fmt.Errorf("%w: direção %q (use asc ou desc)", ErrOrdenacaoInvalida, direcao)
+	}
+
+	return ordenacao, nil
+}
+
+// Valido indica se o campo pode ser usado na ordenação
+func (c CampoOrdenacao) Valido() bool {
+	for _, campo := range camposOrdenacao {
+		if c == campo {
+			return true
+		}
+	}
+	return false
+}
+
+func listaCamposOrdenacao() string {
+	nomes := make([]string, len(camposOrdenacao))
+	for i, campo := range camposOrdenacao {
+		nomes[i] = string(campo)
+	}
+	return strings.Join(nomes, ", ")
+}
+
+// Direcao retorna a direção da ordenação como nos parâmetros da API
+func (o Ordenacao) Direcao() string {
+	if o.Decrescente {
+		return "desc"
+	}
+	return "asc"
+}
+
+// valorDe retorna o valor do campo de ordenação da transação, no formato
+// gravado no cursor
+func (o Ordenacao) valorDe(t *Transaction) string {
+	switch o.Campo {
+	case OrdenarPorValorOrigem:
+		return t.ValorOrigem.String()
+	case OrdenarPorValorDestino:
+		return t.ValorDestino.String()
+	case OrdenarPorMoedaOrigem:
+		return t.MoedaOrigem
+	case OrdenarPorMoedaDestino:
+		return t.MoedaDestino
+	case OrdenarPorStatus:
+		return t.Status
+	default:
+		return t.DataTransacao.Format(time.RFC3339Nano)
+	}
+}
+
+// Cursor marca a posição de uma transação na listagem ordenada. A próxima
+// página começa depois dela; com Anterior, a página termina antes dela.
+type Cursor struct {
+	Campo       CampoOrdenacao `json:"c"`
+	Decrescente bool           `json:"d"`
+	Valor       string         `json:"v"`
+	ID          int            `json:"i"`
+	Anterior    bool           `json:"a,omitempty"`
+}
+
+// Ordenacao retorna a ordenação da listagem em que o cursor foi gerado
+func (c *Cursor) Ordenacao() Ordenacao {
+	return Ordenacao{Campo: c.Campo, Decrescente: c.Decrescente}
+}
+
+// Codificar gera o token opaco enviado ao cliente
+func (c *Cursor) Codificar() string {
+	dados, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(dados)
+}
+
+// DecodificarCursor lê um token gerado por Codificar
+func DecodificarCursor(token string) (*Cursor, error) {
+	dados, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrCursorInvalido
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(dados, &cursor); err != nil || !cursor.Campo.Valido() || cursor.ID <= 0 {
+		return nil, ErrCursorInvalido
+	}
+
+	return &cursor, nil
+}
+
+// PaginaTransacoes é uma página da listagem por cursor. Os cursores ficam
+// vazios quando não há página seguinte ou anterior.
+type PaginaTransacoes struct {
+	Transacoes     []Transaction
+	ProximoCursor  string
+	CursorAnterior string
+}
+
+// MontarPagina monta a página a partir das transações retornadas por GetAll
+// com filter, mas com limite filter.Limit+1. A transação excedente só indica
+// que há outra página no sentido da leitura e é descartada.
+func MontarPagina(transacoes []Transaction, filter TransactionFilter) *PaginaTransacoes {
+	ordenacao := filter.Ordenacao
+	if ordenacao.Campo == "" {
+		ordenacao = OrdenacaoPadrao()
+	}
+	voltando := filter.Cursor != nil && filter.Cursor.Anterior
+
+	haMais := filter.Limit > 0 && len(transacoes) > filter.Limit
+	if haMais {
+		if voltando {
+			transacoes = transacoes[len(transacoes)-filter.Limit:]
+		} else {
+			transacoes = transacoes[:filter.Limit]
+		}
+	}
+
+	pagina := &PaginaTransacoes{Transacoes: transacoes}
+	if len(transacoes) == 0 {
+		return pagina
+	}
+
+	cursorDe := func(t *Transaction, anterior bool) string {
+		cursor := Cursor{
+			Campo:       ordenacao.Campo,
+			Decrescente: ordenacao.Decrescente,
+			Valor:       ordenacao.valorDe(t),
+			ID:          t.ID,
+			Anterior:    anterior,
+		}
+		return cursor.Codificar()
+	}
+
+	// A transação excedente indica mais páginas no sentido da leitura; do
+	// lado de onde o cursor veio, sempre há
+	temProxima, temAnterior := haMais, filter.Cursor != nil
+	if voltando {
+		temProxima, temAnterior = true, haMais
+	}
+
+	if temProxima {
+		pagina.ProximoCursor = cursorDe(&transacoes[len(transacoes)-1], false)
+	}
+	if temAnterior {
+		pagina.CursorAnterior = cursorDe(&transacoes[0], true)
+	}
+
+	return pagina
+}
@@ -0,0 +1,100 @@
+package cambio
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang-project/moeda"
+)
+
+func TestParseOrdenacao(t *testing.T) {
+	ordenacao, err := ParseOrdenacao("", "")
+	if err != nil || ordenacao != OrdenacaoPadrao() {
+		t.Errorf("sem parâmetros esperada a ordenação padrão, obtido %+v, %v", ordenacao, err)
+	}
+
+	ordenacao, err = ParseOrdenacao("Valor_Origem", "ASC")
+	if err != nil || ordenacao.Campo != OrdenarPorValorOrigem || ordenacao.Decrescente {
+		t.Errorf("esperado valor_origem crescente, obtido %+v, %v", ordenacao, err)
+	}
+
+	for _, caso := range [][2]string{{"user_id", ""}, {"id; DROP TABLE users", ""}, {"status", "para cima"}} {
+		if _, err := ParseOrdenacao(caso[0], caso[1]); !errors.Is(err, ErrOrdenacaoInvalida) {
+			t.Errorf("%q %q: esperado ErrOrdenacaoInvalida, obtido %v", caso[0], caso[1], err)
+		}
+	}
+}
+
+func TestCursorCodificado(t *testing.T) {
+	cursor := &Cursor{Campo: OrdenarPorStatus, Decrescente: true, Valor: "Pendente", ID: 42, Anterior: true}
+
+	lido, err := DecodificarCursor(cursor.Codificar())
+	if err != nil || *lido != *cursor {
+		t.Errorf("esperado %+v, obtido %+v, %v", cursor, lido, err)
+	}
+
+	invalido := (&Cursor{Campo: "user_id", ID: 1}).Codificar()
+	for _, token := range []string{"???", "bm9uc2Vuc2U", invalido} {
+		if _, err := DecodificarCursor(token); !errors.Is(err, ErrCursorInvalido) {
+			t.Errorf("%q: esperado ErrCursorInvalido, obtido %v", token, err)
+		}
+	}
+}
+
+// transacoesPaginadas gera transações com IDs e valores crescentes
+func transacoesPaginadas(ids ...int) []Transaction {
+	transacoes := make([]Transaction, len(ids))
+	for i, id := range ids {
+		transacoes[i] = Transaction{
+			ID:            id,
+			ValorOrigem:   moeda.NewFromInt(int64(id * 10)),
+			DataTransacao: time.Date(2026, 1, id, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	return transacoes
+}
+
+func TestMontarPaginaAvancando(t *testing.T) {
+	filter := TransactionFilter{Limit: 2, Ordenacao: Ordenacao{Campo: OrdenarPorValorOrigem}}
+
+	// Primeira página: a terceira transação só indica que há mais
+	pagina := MontarPagina(transacoesPaginadas(1, 2, 3), filter)
+	if len(pagina.Transacoes) != 2 || pagina.Transacoes[1].ID != 2 || pagina.CursorAnterior != "" {
+		t.Fatalf("esperada a página [1 2] sem anterior, obtido %+v", pagina)
+	}
+	proximo, err := DecodificarCursor(pagina.ProximoCursor)
+	if err != nil || proximo.ID != 2 || proximo.Valor != "20" || proximo.Anterior {
+		t.Fatalf("esperado cursor após a transação 2, obtido %+v, %v", proximo, err)
+	}
+
+	// Última página, alcançada pelo cursor
+	filter.Cursor = proximo
+	pagina = MontarPagina(transacoesPaginadas(3), filter)
+	if pagina.ProximoCursor != "" {
+		t.Error("última página não deveria ter próximo cursor")
+	}
+	anterior, err := DecodificarCursor(pagina.CursorAnterior)
+	if err != nil || anterior.ID != 3 || !anterior.Anterior {
+		t.Errorf("esperado cursor antes da transação 3, obtido %+v, %v", anterior, err)
+	}
+}
+
+func TestMontarPaginaVoltando(t *testing.T) {
+	cursor := &Cursor{Campo: OrdenarPorData, Decrescente: true, ID: 4, Anterior: true}
+	filter := TransactionFilter{Limit: 2, Ordenacao: cursor.Ordenacao(), Cursor: cursor}
+
+	// Voltando, a transação excedente é a mais distante do cursor: a primeira
+	pagina := MontarPagina(transacoesPaginadas(1, 2, 3), filter)
+	if len(pagina.Transacoes) != 2 || pagina.Transacoes[0].ID != 2 {
+		t.Fatalf("esperada a página [2 3], obtido %+v", pagina.Transacoes)
+	}
+	if pagina.ProximoCursor == "" || pagina.CursorAnterior == "" {
+		t.Errorf("esperados os dois cursores, obtido %+v", pagina)
+	}
+
+	pagina = MontarPagina(transacoesPaginadas(1, 2), filter)
+	if pagina.CursorAnterior != "" || pagina.ProximoCursor == "" {
+		t.Errorf("primeira página alcançada voltando deveria ter só o próximo cursor, obtido %+v", pagina)
+	}
+}
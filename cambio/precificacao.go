@@ -0,0 +1,148 @@
+package cambio
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"golang-project/moeda"
+)
+
+// RegraSpread define o spread percentual aplicado sobre a taxa média para um
+// par de moedas e tipo de operação. Campos vazios ou "*" valem para qualquer valor.
+type RegraSpread struct {
+	MoedaOrigem  string        `json:"moeda_origem,omitempty"`
+	MoedaDestino string        `json:"moeda_destino,omitempty"`
+	Tipo         string        `json:"tipo,omitempty"`
+	Percentual   moeda.Decimal `json:"percentual"`
+}
+
+// RegraComissao define a comissão cobrada, na moeda de origem: um valor fixo
+// somado a um percentual do valor de origem
+type RegraComissao struct {
+	MoedaOrigem  string        `json:"moeda_origem,omitempty"`
+	MoedaDestino string        `json:"moeda_destino,omitempty"`
+	Tipo         string        `json:"tipo,omitempty"`
+	Fixa         moeda.Decimal `json:"fixa"`
+	Percentual   moeda.Decimal `json:"percentual"`
+}
+
+// TabelaPrecos reúne as regras de spread e comissão
+type TabelaPrecos struct {
+	Spreads   []RegraSpread   `json:"spreads"`
+	Comissoes []RegraComissao `json:"comissoes"`
+}
+
+// MotorPrecificacao aplica spreads e comissões sobre conversões à taxa média
+type MotorPrecificacao struct {
+	tabela TabelaPrecos
+}
+
+var cem = moeda.NewFromInt(100)
+
+func NewMotorPrecificacao(tabela TabelaPrecos) *MotorPrecificacao {
+	return &MotorPrecificacao{tabela: tabela}
+}
+
+// CarregarMotorPrecificacao lê a tabela de preços de um arquivo JSON
+func CarregarMotorPrecificacao(caminho string) (*MotorPrecificacao, error) {
+	data, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler tabela de preços: %w", err)
+	}
+
+	var tabela TabelaPrecos
+	if err := json.Unmarshal(data, &tabela); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar tabela de preços: %w", err)
+	}
+
+	for _, s := range tabela.Spreads {
+		if s.Percentual.IsNegative() || s.Percentual.Cmp(cem) >= 0 {
+			return nil, fmt.Errorf("spread inválido para %s->%s (%s): %s%%", s.MoedaOrigem, s.MoedaDestino, s.Tipo, s.Percentual)
+		}
+	}
+	for _, c := range tabela.Comissoes {
+		if c.Fixa.IsNegative() || c.Percentual.IsNegative() || c.Percentual.Cmp(cem) >= 0 {
+			return nil, fmt.Errorf("comissão inválida para %s->%s (%s)", c.MoedaOrigem, c.MoedaDestino, c.Tipo)
+		}
+	}
+
+	return NewMotorPrecificacao(tabela), nil
+}
+
+// pontuacao retorna quantos campos da regra casam exatamente com a operação,
+// ou -1 se algum campo não casar. A regra mais específica vence.
+func pontuacao(regraOrigem, regraDestino, regraTipo, origem, destino, tipo string) int {
+	pontos := 0
+	for _, par := range [][2]string{{regraOrigem, origem}, {regraDestino, destino}, {regraTipo, tipo}} {
+		switch par[0] {
+		case "", "*":
+		case par[1]:
+			pontos++
+		default:
+			return -1
+		}
+	}
+	return pontos
+}
+
+func (m *MotorPrecificacao) spread(origem, destino, tipo string) moeda.Decimal {
+	var escolhida *RegraSpread
+	melhor := -1
+	for i, r := range m.tabela.Spreads {
+		if p := pontuacao(r.MoedaOrigem, r.MoedaDestino, r.Tipo, origem, destino, tipo); p > melhor {
+			escolhida, melhor = &m.tabela.Spreads[i], p
+		}
+	}
+	if escolhida == nil {
+		return moeda.Decimal{}
+	}
+	return escolhida.Percentual
+}
+
+func (m *MotorPrecificacao) comissao(origem, destino, tipo string) *RegraComissao {
+	var escolhida *RegraComissao
+	melhor := -1
+	for i, r := range m.tabela.Comissoes {
+		if p := pontuacao(r.MoedaOrigem, r.MoedaDestino, r.Tipo, origem, destino, tipo); p > melhor {
+			escolhida, melhor = &m.tabela.Comissoes[i], p
+		}
+	}
+	return escolhida
+}
+
+// Precificar aplica o spread e a comissão do tipo de operação a uma conversão
+// feita à taxa média. A comissão é descontada do valor de origem antes da
+// conversão e a taxa aplicada é taxa_media × (1 - spread/100).
+func (m *MotorPrecificacao) Precificar(media *Conversao, tipo string) (*Conversao, error) {
+	registro := moeda.Padrao()
+	spread := m.spread(media.MoedaOrigem, media.MoedaDestino, tipo)
+
+	precificada := &Conversao{
+		ValorOrigem:  media.ValorOrigem,
+		MoedaOrigem:  media.MoedaOrigem,
+		MoedaDestino: media.MoedaDestino,
+		Fonte:        media.Fonte,
+		TaxaMedia:    media.Taxa,
+		Spread:       spread,
+		Tipo:         tipo,
+	}
+
+	fator := cem.Sub(spread).Div(cem, CASAS_TAXA, moeda.ArredondamentoBancario)
+	precificada.Taxa = media.Taxa.Mul(fator).Round(CASAS_TAXA, moeda.ArredondamentoBancario)
+
+	if regra := m.comissao(media.MoedaOrigem, media.MoedaDestino, tipo); regra != nil {
+		percentual := media.ValorOrigem.Mul(regra.Percentual).Div(cem, CASAS_TAXA, moeda.ArredondamentoBancario)
+		precificada.Comissao = registro.Arredondar(regra.Fixa.Add(percentual), media.MoedaOrigem)
+	} else {
+		precificada.Comissao = registro.Arredondar(moeda.Decimal{}, media.MoedaOrigem)
+	}
+
+	liquido := media.ValorOrigem.Sub(precificada.Comissao)
+	if !liquido.IsPositive() {
+		return nil, fmt.Errorf("valor %s %s não cobre a comissão de %s", media.MoedaOrigem, media.ValorOrigem, precificada.Comissao)
+	}
+
+	precificada.ValorDestino = registro.Arredondar(liquido.Mul(precificada.Taxa), media.MoedaDestino)
+	return precificada, nil
+}
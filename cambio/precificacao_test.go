@@ -0,0 +1,92 @@
+package cambio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang-project/moeda"
+)
+
+func conversaoMedia(valor, taxa, origem, destino string) *Conversao {
+	v := moeda.MustFromString(valor)
+	t := moeda.MustFromString(taxa)
+	return &Conversao{
+		ValorOrigem:  v,
+		MoedaOrigem:  origem,
+		MoedaDestino: destino,
+		Taxa:         t,
+		TaxaMedia:    t,
+		ValorDestino: moeda.Padrao().Arredondar(v.Mul(t), destino),
+	}
+}
+
+func TestPrecificarAplicaSpreadEComissao(t *testing.T) {
+	motor := NewMotorPrecificacao(TabelaPrecos{
+		Spreads: []RegraSpread{
+			{Percentual: moeda.MustFromString("1")},
+			{MoedaOrigem: "BRL", MoedaDestino: "USD", Tipo: "Compra", Percentual: moeda.MustFromString("2")},
+		},
+		Comissoes: []RegraComissao{
+			{Tipo: "Compra", Fixa: moeda.MustFromString("5"), Percentual: moeda.MustFromString("1")},
+		},
+	})
+
+	conv, err := motor.Precificar(conversaoMedia("1000", "0.2", "BRL", "USD"), "Compra")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// Spread de 2% (regra específica), comissão de 5 + 1% de 1000 = 15
+	esperados := map[string][2]moeda.Decimal{
+		"taxa":          {conv.Taxa, moeda.MustFromString("0.196")},
+		"taxa média":    {conv.TaxaMedia, moeda.MustFromString("0.2")},
+		"spread":        {conv.Spread, moeda.MustFromString("2")},
+		"comissão":      {conv.Comissao, moeda.MustFromString("15")},
+		"valor destino": {conv.ValorDestino, moeda.MustFromString("193.06")},
+	}
+	for campo, par := range esperados {
+		if !par[0].Equal(par[1]) {
+			t.Errorf("%s esperado %s, obtido %s", campo, par[1], par[0])
+		}
+	}
+
+	// Venda no mesmo par cai na regra genérica de 1%, sem comissão
+	venda, err := motor.Precificar(conversaoMedia("1000", "0.2", "BRL", "USD"), "Venda")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !venda.Taxa.Equal(moeda.MustFromString("0.198")) || !venda.Comissao.IsZero() {
+		t.Errorf("venda esperada com taxa 0.198 e sem comissão, obtida %+v", venda)
+	}
+}
+
+func TestPrecificarSemRegrasUsaTaxaMedia(t *testing.T) {
+	media := conversaoMedia("100", "5.4321", "USD", "BRL")
+	conv, err := NewMotorPrecificacao(TabelaPrecos{}).Precificar(media, "Compra")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !conv.Taxa.Equal(media.Taxa) || !conv.ValorDestino.Equal(media.ValorDestino) {
+		t.Errorf("sem regras a conversão deveria ser à taxa média, obtida %+v", conv)
+	}
+}
+
+func TestPrecificarComissaoMaiorQueValor(t *testing.T) {
+	motor := NewMotorPrecificacao(TabelaPrecos{
+		Comissoes: []RegraComissao{{Fixa: moeda.MustFromString("10")}},
+	})
+	if _, err := motor.Precificar(conversaoMedia("10", "5", "USD", "BRL"), "Compra"); err == nil {
+		t.Error("esperado erro quando a comissão consome todo o valor")
+	}
+}
+
+func TestCarregarMotorPrecificacaoRejeitaSpreadInvalido(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), "precos.json")
+	if err := os.WriteFile(caminho, []byte(`{"spreads":[{"percentual":"100"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CarregarMotorPrecificacao(caminho); err == nil {
+		t.Error("esperado erro para spread de 100%")
+	}
+}
@@ -0,0 +1,207 @@
+package cambio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Cotacoes representa as taxas retornadas por um provedor para uma moeda base
+type Cotacoes struct {
+	Base     string
+	Taxas    map[string]float64
+	Fonte    string
+	ObtidoEm time.Time
+}
+
+// RateProvider define uma fonte de taxas de câmbio
+type RateProvider interface {
+	// Nome identifica o provedor em logs e na origem das cotações
+	Nome() string
+	// BuscarTaxas retorna as taxas de conversão da moeda base para as demais moedas
+	BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error)
+}
+
+// FailoverProvider consulta uma lista ordenada de provedores, usando o
+// primeiro que responder com sucesso
+type FailoverProvider struct {
+	provedores []RateProvider
+}
+
+// NewFailoverProvider cria uma cadeia de failover na ordem informada
+func NewFailoverProvider(provedores ...RateProvider) *FailoverProvider {
+	return &FailoverProvider{provedores: provedores}
+}
+
+func (f *FailoverProvider) Nome() string {
+	nomes := make([]string, len(f.provedores))
+	for i, p := range f.provedores {
+		nomes[i] = p.Nome()
+	}
+	return "failover(" + strings.Join(nomes, ",") + ")"
+}
+
+func (f *FailoverProvider) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	if len(f.provedores) == 0 {
+		return nil, fmt.Errorf("nenhum provedor de taxas configurado")
+	}
+
+	var errs []error
+	for i, p := range f.provedores {
+		cotacoes, err := p.BuscarTaxas(ctx, moedaBase)
+		if err == nil {
+			if i > 0 {
+				fmt.Printf("Aviso: taxas para %s obtidas do provedor alternativo %s\n", moedaBase, p.Nome())
+			}
+			return cotacoes, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Nome(), err))
+
+		// Requisição cancelada: não adianta tentar os próximos provedores
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	return nil, fmt.Errorf("todos os provedores falharam: %w", errors.Join(errs...))
+}
+
+// Saude reúne o estado dos provedores da cadeia que relatam saúde
+func (f *FailoverProvider) Saude() []SaudeProvedor {
+	var saude []SaudeProvedor
+	for _, p := range f.provedores {
+		if relator, ok := p.(RelatorSaude); ok {
+			saude = append(saude, relator.Saude()...)
+		}
+	}
+	return saude
+}
+
+// StaticProvider serve taxas fixas, carregadas em memória ou de um arquivo JSON
+type StaticProvider struct {
+	taxas    map[string]map[string]float64
+	obtidoEm time.Time
+}
+
+// NewStaticProvider cria um provedor a partir de uma tabela de taxas
+func NewStaticProvider(taxas map[string]map[string]float64) *StaticProvider {
+	return &StaticProvider{
+		taxas:    taxas,
+		obtidoEm: time.Now(),
+	}
+}
+
+// CarregarStaticProvider lê uma tabela de taxas no formato {"USD": {"BRL": 5.4}}
+func CarregarStaticProvider(caminho string) (*StaticProvider, error) {
+	data, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de taxas: %w", err)
+	}
+
+	var taxas map[string]map[string]float64
+	if err := json.Unmarshal(data, &taxas); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar arquivo de taxas: %w", err)
+	}
+
+	info, err := os.Stat(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de taxas: %w", err)
+	}
+
+	return &StaticProvider{
+		taxas:    taxas,
+		obtidoEm: info.ModTime(),
+	}, nil
+}
+
+func (s *StaticProvider) Nome() string {
+	return "estatico"
+}
+
+func (s *StaticProvider) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	taxasBase, existe := s.taxas[moedaBase]
+	if !existe {
+		return nil, fmt.Errorf("taxas estáticas não encontradas para %s", moedaBase)
+	}
+
+	copia := make(map[string]float64, len(taxasBase))
+	for moeda, taxa := range taxasBase {
+		copia[moeda] = taxa
+	}
+
+	return &Cotacoes{
+		Base:     moedaBase,
+		Taxas:    copia,
+		Fonte:    s.Nome(),
+		ObtidoEm: s.obtidoEm,
+	}, nil
+}
+
+// NovoProvedor cria um provedor HTTP pelo nome usado na configuração. Com
+// urlBase vazia, usa o endereço público do provedor.
+func NovoProvedor(nome, urlBase string, cliente *http.Client) (RateProvider, error) {
+	escolher := func(padrao string) string {
+		if urlBase != "" {
+			return urlBase
+		}
+		return padrao
+	}
+
+	switch normalizarProvedor(nome) {
+	case "fxratesapi":
+		return NewFXRatesAPIProvider(escolher(FXRATESAPI_URL), cliente), nil
+	case "exchangerate-api":
+		return NewExchangeRateAPIProvider(escolher(EXCHANGERATE_API_URL), cliente), nil
+	case "ecb":
+		return NewECBProvider(escolher(ECB_URL), cliente), nil
+	default:
+		return nil, fmt.Errorf("provedor de taxas desconhecido: %s", nome)
+	}
+}
+
+// MontarProvedores cria a cadeia de failover a partir dos nomes configurados,
+// com um http.Client compartilhado e as proteções de resiliência em cada
+// provedor HTTP. Se arquivoFallback for informado, a tabela offline entra
+// como último recurso.
+func MontarProvedores(nomes []string, arquivoFallback string, opcoes ...OpcaoCliente) (RateProvider, error) {
+	o := novasOpcoesCliente(opcoes)
+	compartilhado := o.httpCliente()
+
+	naCadeia := make(map[string]bool, len(nomes))
+	var provedores []RateProvider
+	for _, nome := range nomes {
+		nome = normalizarProvedor(nome)
+		p, err := NovoProvedor(nome, o.urls[nome], o.httpClienteDe(nome, compartilhado))
+		if err != nil {
+			return nil, err
+		}
+		naCadeia[nome] = true
+		provedores = append(provedores, o.resiliencia.Envolver(p))
+	}
+
+	for nome := range o.urls {
+		if !naCadeia[nome] {
+			return nil, fmt.Errorf("URL base configurada para provedor fora da cadeia: %s", nome)
+		}
+	}
+	for nome := range o.chaves {
+		if !naCadeia[nome] {
+			return nil, fmt.Errorf("chave de API configurada para provedor fora da cadeia: %s", nome)
+		}
+	}
+
+	if arquivoFallback != "" {
+		p, err := CarregarFallbackProvider(arquivoFallback)
+		if err != nil {
+			return nil, err
+		}
+		provedores = append(provedores, p)
+	}
+
+	return NewFailoverProvider(provedores...), nil
+}
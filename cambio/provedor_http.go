@@ -0,0 +1,190 @@
+package cambio
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	FXRATESAPI_URL       = "https://api.fxratesapi.com/latest"
+	EXCHANGERATE_API_URL = "https://open.er-api.com/v6/latest"
+	ECB_URL              = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+)
+
+// buscarCorpo faz um GET e retorna o corpo da resposta quando o status é 200
+func buscarCorpo(ctx context.Context, cliente *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar requisição para API: %w", err)
+	}
+
+	resp, err := cliente.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao fazer requisição para API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &ErroHTTP{
+			Status:     resp.StatusCode,
+			RetryAfter: lerRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta da API: %w", err)
+	}
+
+	return body, nil
+}
+
+// decodificarTaxasJSON aceita tanto o formato simples ({"rates": ...})
+// quanto o formato da exchangerate-api ({"conversion_rates": ...})
+func decodificarTaxasJSON(body []byte) (map[string]float64, error) {
+	var simpleData SimpleExchangeResponse
+	err := json.Unmarshal(body, &simpleData)
+	if err == nil && len(simpleData.Rates) > 0 {
+		return simpleData.Rates, nil
+	}
+
+	var exchangeData ExchangeRateResponse
+	err = json.Unmarshal(body, &exchangeData)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao deserializar resposta da API: %w", err)
+	}
+
+	if exchangeData.Result != "" && exchangeData.Result != "success" {
+		return nil, fmt.Errorf("API retornou resultado: %s", exchangeData.Result)
+	}
+
+	if len(exchangeData.ConversionRates) > 0 {
+		return exchangeData.ConversionRates, nil
+	}
+
+	return nil, fmt.Errorf("resposta da API não contém taxas válidas")
+}
+
+// FXRatesAPIProvider consulta a API da fxratesapi.com (GET {url}?base=USD)
+type FXRatesAPIProvider struct {
+	url     string
+	cliente *http.Client
+}
+
+func NewFXRatesAPIProvider(url string, cliente *http.Client) *FXRatesAPIProvider {
+	return &FXRatesAPIProvider{url: url, cliente: cliente}
+}
+
+func (p *FXRatesAPIProvider) Nome() string {
+	return "fxratesapi"
+}
+
+func (p *FXRatesAPIProvider) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	body, err := buscarCorpo(ctx, p.cliente, fmt.Sprintf("%s?base=%s", p.url, moedaBase))
+	if err != nil {
+		return nil, err
+	}
+
+	taxas, err := decodificarTaxasJSON(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cotacoes{Base: moedaBase, Taxas: taxas, Fonte: p.Nome(), ObtidoEm: time.Now()}, nil
+}
+
+// ExchangeRateAPIProvider consulta APIs no estilo exchangerate-api (GET {url}/USD)
+type ExchangeRateAPIProvider struct {
+	url     string
+	cliente *http.Client
+}
+
+func NewExchangeRateAPIProvider(url string, cliente *http.Client) *ExchangeRateAPIProvider {
+	return &ExchangeRateAPIProvider{url: url, cliente: cliente}
+}
+
+func (p *ExchangeRateAPIProvider) Nome() string {
+	return "exchangerate-api"
+}
+
+func (p *ExchangeRateAPIProvider) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	body, err := buscarCorpo(ctx, p.cliente, fmt.Sprintf("%s/%s", p.url, moedaBase))
+	if err != nil {
+		return nil, err
+	}
+
+	taxas, err := decodificarTaxasJSON(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cotacoes{Base: moedaBase, Taxas: taxas, Fonte: p.Nome(), ObtidoEm: time.Now()}, nil
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time string `xml:"time,attr"`
+			Cube []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// ECBProvider lê as taxas de referência diárias do Banco Central Europeu.
+// O BCE publica apenas cotações em EUR; as demais bases são derivadas.
+type ECBProvider struct {
+	url     string
+	cliente *http.Client
+}
+
+func NewECBProvider(url string, cliente *http.Client) *ECBProvider {
+	return &ECBProvider{url: url, cliente: cliente}
+}
+
+func (p *ECBProvider) Nome() string {
+	return "ecb"
+}
+
+func (p *ECBProvider) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	body, err := buscarCorpo(ctx, p.cliente, p.url)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar XML do BCE: %w", err)
+	}
+
+	taxasEUR := map[string]float64{"EUR": 1}
+	for _, c := range envelope.Cube.Cube.Cube {
+		if c.Rate > 0 {
+			taxasEUR[c.Currency] = c.Rate
+		}
+	}
+
+	taxaBase, existe := taxasEUR[moedaBase]
+	if !existe {
+		return nil, fmt.Errorf("BCE não publica taxa para %s", moedaBase)
+	}
+
+	taxas := make(map[string]float64, len(taxasEUR))
+	for moeda, taxa := range taxasEUR {
+		taxas[moeda] = taxa / taxaBase
+	}
+
+	obtidoEm := time.Now()
+	if data, err := time.Parse("2006-01-02", envelope.Cube.Cube.Time); err == nil {
+		obtidoEm = data
+	}
+
+	return &Cotacoes{Base: moedaBase, Taxas: taxas, Fonte: p.Nome(), ObtidoEm: obtidoEm}, nil
+}
@@ -0,0 +1,147 @@
+package cambio
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFXRatesAPIProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("base") != "USD" {
+			t.Errorf("base esperada USD, obtida %q", r.URL.Query().Get("base"))
+		}
+		fmt.Fprint(w, `{"base":"USD","rates":{"BRL":5.4,"EUR":0.92}}`)
+	}))
+	defer srv.Close()
+
+	p := NewFXRatesAPIProvider(srv.URL, srv.Client())
+	cotacoes, err := p.BuscarTaxas(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if cotacoes.Taxas["BRL"] != 5.4 || cotacoes.Fonte != "fxratesapi" {
+		t.Errorf("cotações inesperadas: %+v", cotacoes)
+	}
+}
+
+func TestExchangeRateAPIProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/EUR" {
+			t.Errorf("caminho esperado /EUR, obtido %q", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"result":"success","base_code":"EUR","conversion_rates":{"USD":1.09}}`)
+	}))
+	defer srv.Close()
+
+	p := NewExchangeRateAPIProvider(srv.URL, srv.Client())
+	cotacoes, err := p.BuscarTaxas(context.Background(), "EUR")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if cotacoes.Taxas["USD"] != 1.09 {
+		t.Errorf("taxa EUR->USD esperada 1.09, obtida %v", cotacoes.Taxas["USD"])
+	}
+}
+
+func TestECBProviderDerivaOutrasBases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2025-10-01">
+			<Cube currency="USD" rate="1.10"/>
+			<Cube currency="BRL" rate="5.50"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`)
+	}))
+	defer srv.Close()
+
+	p := NewECBProvider(srv.URL, srv.Client())
+	cotacoes, err := p.BuscarTaxas(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if math.Abs(cotacoes.Taxas["BRL"]-5.0) > 1e-9 {
+		t.Errorf("taxa USD->BRL esperada 5.0, obtida %v", cotacoes.Taxas["BRL"])
+	}
+	if cotacoes.ObtidoEm.Format("2006-01-02") != "2025-10-01" {
+		t.Errorf("data esperada 2025-10-01, obtida %v", cotacoes.ObtidoEm)
+	}
+
+	if _, err := p.BuscarTaxas(context.Background(), "JPY"); err == nil {
+		t.Error("esperado erro para moeda não publicada pelo BCE")
+	}
+}
+
+func TestStaticProviderDeArquivo(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), "taxas.json")
+	if err := os.WriteFile(caminho, []byte(`{"USD":{"BRL":5.42}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := CarregarStaticProvider(caminho)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	cotacoes, err := p.BuscarTaxas(context.Background(), "USD")
+	if err != nil || cotacoes.Taxas["BRL"] != 5.42 {
+		t.Errorf("esperado USD->BRL 5.42, obtido %+v (erro: %v)", cotacoes, err)
+	}
+
+	if _, err := p.BuscarTaxas(context.Background(), "EUR"); err == nil {
+		t.Error("esperado erro para base ausente")
+	}
+}
+
+func TestFailoverProviderUsaProximoProvedor(t *testing.T) {
+	fora := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer fora.Close()
+
+	p := NewFailoverProvider(
+		NewFXRatesAPIProvider(fora.URL, fora.Client()),
+		NewStaticProvider(map[string]map[string]float64{"USD": {"BRL": 5.0}}),
+	)
+
+	cotacoes, err := p.BuscarTaxas(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if cotacoes.Fonte != "estatico" {
+		t.Errorf("fonte esperada estatico, obtida %s", cotacoes.Fonte)
+	}
+
+	if _, err := p.BuscarTaxas(context.Background(), "EUR"); err == nil {
+		t.Error("esperado erro quando todos os provedores falham")
+	}
+}
+
+func TestBuscarTaxasRespeitaCancelamento(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	cliente := NewCambioClientComProvedor(NewFXRatesAPIProvider(srv.URL, srv.Client()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	inicio := time.Now()
+	if _, err := cliente.BuscarTaxasParaTodasMoedas(ctx); err == nil {
+		t.Fatal("esperado erro de cancelamento")
+	}
+	if decorrido := time.Since(inicio); decorrido > 2*time.Second {
+		t.Errorf("busca deveria ser interrompida pelo contexto, levou %v", decorrido)
+	}
+}
@@ -0,0 +1,383 @@
+package cambio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitoAberto indica que o provedor foi suspenso após falhas seguidas
+var ErrCircuitoAberto = errors.New("circuito aberto")
+
+// ErroHTTP é retornado quando a API responde com status diferente de 200.
+// RetryAfter vem do cabeçalho de mesmo nome, quando presente.
+type ErroHTTP struct {
+	Status     int
+	RetryAfter time.Duration
+}
+
+func (e *ErroHTTP) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("API retornou status %d (tentar novamente em %s)", e.Status, e.RetryAfter)
+	}
+	return fmt.Sprintf("API retornou status %d", e.Status)
+}
+
+// lerRetryAfter aceita segundos ou uma data HTTP; retorna zero se ausente ou inválido
+func lerRetryAfter(valor string) time.Duration {
+	valor = strings.TrimSpace(valor)
+	if valor == "" {
+		return 0
+	}
+	if segundos, err := strconv.Atoi(valor); err == nil && segundos > 0 {
+		return time.Duration(segundos) * time.Second
+	}
+	if data, err := http.ParseTime(valor); err == nil {
+		if espera := time.Until(data); espera > 0 {
+			return espera
+		}
+	}
+	return 0
+}
+
+// ehTransitoria indica falhas que podem passar sozinhas: limite de
+// requisições (429), erros 5xx e falhas de rede
+func ehTransitoria(err error) bool {
+	var erroHTTP *ErroHTTP
+	if errors.As(err, &erroHTTP) {
+		return erroHTTP.Status == http.StatusTooManyRequests || erroHTTP.Status >= 500
+	}
+	var erroRede net.Error
+	return errors.As(err, &erroRede)
+}
+
+// PoliticaRetentativa define quantas vezes e com que espera uma busca é
+// repetida. A espera dobra a cada tentativa, até EsperaMaxima, com jitter.
+type PoliticaRetentativa struct {
+	// Tentativas é o total de tentativas, incluindo a primeira
+	Tentativas    int
+	EsperaInicial time.Duration
+	EsperaMaxima  time.Duration
+}
+
+// espera calcula o intervalo antes da tentativa seguinte à de número
+// tentativa (a partir de 1), com jitter total: entre zero e o teto exponencial
+func (p PoliticaRetentativa) espera(tentativa int) time.Duration {
+	teto := p.EsperaInicial << (tentativa - 1)
+	if teto <= 0 || teto > p.EsperaMaxima {
+		teto = p.EsperaMaxima
+	}
+	if teto <= 0 {
+		return 0
+	}
+	return rand.N(teto + 1)
+}
+
+// LimitadorTaxa é um balde de fichas: permite rajadas de até capacidade
+// requisições e repõe porSegundo fichas a cada segundo
+type LimitadorTaxa struct {
+	mu         sync.Mutex
+	capacidade float64
+	porSegundo float64
+	fichas     float64
+	ultimo     time.Time
+}
+
+// NewLimitadorTaxa cria um limitador com o balde cheio
+func NewLimitadorTaxa(porSegundo float64, capacidade int) *LimitadorTaxa {
+	return &LimitadorTaxa{
+		capacidade: float64(capacidade),
+		porSegundo: porSegundo,
+		fichas:     float64(capacidade),
+		ultimo:     time.Now(),
+	}
+}
+
+// Aguardar consome uma ficha, esperando a reposição se necessário
+func (l *LimitadorTaxa) Aguardar(ctx context.Context) error {
+	for {
+		espera := l.reservar()
+		if espera == 0 {
+			return nil
+		}
+
+		select {
+		case <-time.After(espera):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// reservar consome uma ficha se houver e retorna zero; caso contrário,
+// retorna quanto falta para a próxima ficha
+func (l *LimitadorTaxa) reservar() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	agora := time.Now()
+	l.fichas += agora.Sub(l.ultimo).Seconds() * l.porSegundo
+	if l.fichas > l.capacidade {
+		l.fichas = l.capacidade
+	}
+	l.ultimo = agora
+
+	if l.fichas >= 1 {
+		l.fichas--
+		return 0
+	}
+	return time.Duration((1 - l.fichas) / l.porSegundo * float64(time.Second))
+}
+
+// EstadoCircuito é o estado de um Disjuntor
+type EstadoCircuito string
+
+const (
+	CircuitoFechado    EstadoCircuito = "fechado"
+	CircuitoAberto     EstadoCircuito = "aberto"
+	CircuitoMeioAberto EstadoCircuito = "meio_aberto"
+)
+
+// Disjuntor suspende as chamadas a um provedor após limiteFalhas falhas
+// seguidas. Passado tempoAberto, uma única chamada de teste é liberada:
+// se ela funcionar o circuito fecha, senão abre de novo.
+type Disjuntor struct {
+	mu           sync.Mutex
+	limiteFalhas int
+	tempoAberto  time.Duration
+	estado       EstadoCircuito
+	falhas       int
+	abertoEm     time.Time
+	emTeste      bool
+}
+
+// NewDisjuntor cria um disjuntor fechado
+func NewDisjuntor(limiteFalhas int, tempoAberto time.Duration) *Disjuntor {
+	return &Disjuntor{
+		limiteFalhas: limiteFalhas,
+		tempoAberto:  tempoAberto,
+		estado:       CircuitoFechado,
+	}
+}
+
+// Permitir retorna ErrCircuitoAberto enquanto as chamadas estiverem suspensas
+func (d *Disjuntor) Permitir() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch d.estado {
+	case CircuitoAberto:
+		if time.Since(d.abertoEm) < d.tempoAberto {
+			return ErrCircuitoAberto
+		}
+		d.estado = CircuitoMeioAberto
+		d.emTeste = true
+		return nil
+	case CircuitoMeioAberto:
+		if d.emTeste {
+			return ErrCircuitoAberto
+		}
+		d.emTeste = true
+	}
+	return nil
+}
+
+// RegistrarSucesso fecha o circuito e zera as falhas
+func (d *Disjuntor) RegistrarSucesso() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.estado = CircuitoFechado
+	d.falhas = 0
+	d.emTeste = false
+}
+
+// RegistrarFalha conta uma falha, abrindo o circuito ao atingir o limite ou
+// se a chamada de teste falhar
+func (d *Disjuntor) RegistrarFalha() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.falhas++
+	d.emTeste = false
+	if d.estado == CircuitoMeioAberto || d.falhas >= d.limiteFalhas {
+		d.estado = CircuitoAberto
+		d.abertoEm = time.Now()
+	}
+}
+
+// liberarTeste devolve a chamada de teste sem resultado conclusivo (por
+// exemplo, cancelada pelo chamador)
+func (d *Disjuntor) liberarTeste() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.emTeste = false
+}
+
+// SaudeProvedor descreve o estado de um provedor para o health check
+type SaudeProvedor struct {
+	Nome           string         `json:"nome"`
+	Circuito       EstadoCircuito `json:"circuito"`
+	FalhasSeguidas int            `json:"falhas_seguidas"`
+	AbertoAte      *time.Time     `json:"aberto_ate,omitempty"`
+}
+
+// Estado retorna a situação atual do disjuntor para o provedor informado
+func (d *Disjuntor) Estado(nome string) SaudeProvedor {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	saude := SaudeProvedor{
+		Nome:           nome,
+		Circuito:       d.estado,
+		FalhasSeguidas: d.falhas,
+	}
+	if d.estado == CircuitoAberto {
+		ate := d.abertoEm.Add(d.tempoAberto)
+		saude.AbertoAte = &ate
+	}
+	return saude
+}
+
+// RelatorSaude é implementado por provedores que informam seu estado
+type RelatorSaude interface {
+	Saude() []SaudeProvedor
+}
+
+// Resiliencia reúne as proteções aplicadas a cada provedor HTTP
+type Resiliencia struct {
+	Retentativa PoliticaRetentativa
+	// RequisicoesPorSegundo e Rajada configuram o balde de fichas; zero desativa
+	RequisicoesPorSegundo float64
+	Rajada                int
+	// FalhasCircuito é quantas falhas seguidas abrem o circuito; zero desativa
+	FalhasCircuito      int
+	TempoCircuitoAberto time.Duration
+}
+
+// ResilienciaPadrao retorna as proteções usadas quando nada é configurado
+func ResilienciaPadrao() Resiliencia {
+	return Resiliencia{
+		Retentativa: PoliticaRetentativa{
+			Tentativas:    3,
+			EsperaInicial: 500 * time.Millisecond,
+			EsperaMaxima:  10 * time.Second,
+		},
+		RequisicoesPorSegundo: 5,
+		Rajada:                5,
+		FalhasCircuito:        5,
+		TempoCircuitoAberto:   30 * time.Second,
+	}
+}
+
+// Envolver aplica as proteções ao provedor, cada um com seu próprio
+// limitador e disjuntor
+func (r Resiliencia) Envolver(provedor RateProvider) *ProvedorResiliente {
+	p := &ProvedorResiliente{
+		provedor:    provedor,
+		retentativa: r.Retentativa,
+	}
+	if r.RequisicoesPorSegundo > 0 {
+		rajada := r.Rajada
+		if rajada < 1 {
+			rajada = 1
+		}
+		p.limitador = NewLimitadorTaxa(r.RequisicoesPorSegundo, rajada)
+	}
+	if r.FalhasCircuito > 0 {
+		p.disjuntor = NewDisjuntor(r.FalhasCircuito, r.TempoCircuitoAberto)
+	}
+	return p
+}
+
+// ProvedorResiliente repete as falhas transitórias de um provedor com
+// espera exponencial, respeita sua cota de requisições e o suspende após
+// falhas seguidas
+type ProvedorResiliente struct {
+	provedor    RateProvider
+	retentativa PoliticaRetentativa
+	limitador   *LimitadorTaxa
+	disjuntor   *Disjuntor
+}
+
+func (p *ProvedorResiliente) Nome() string {
+	return p.provedor.Nome()
+}
+
+func (p *ProvedorResiliente) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	if p.disjuntor != nil {
+		if err := p.disjuntor.Permitir(); err != nil {
+			return nil, err
+		}
+	}
+
+	cotacoes, err := p.tentar(ctx, moedaBase)
+
+	if p.disjuntor != nil {
+		switch {
+		case err == nil:
+			p.disjuntor.RegistrarSucesso()
+		case ctx.Err() == nil && ehTransitoria(err):
+			p.disjuntor.RegistrarFalha()
+		default:
+			// Erros do chamador ou da própria resposta não indicam provedor fora do ar
+			p.disjuntor.liberarTeste()
+		}
+	}
+	return cotacoes, err
+}
+
+// tentar executa as tentativas da política, parando em erros não transitórios
+func (p *ProvedorResiliente) tentar(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	tentativas := p.retentativa.Tentativas
+	if tentativas < 1 {
+		tentativas = 1
+	}
+
+	for tentativa := 1; ; tentativa++ {
+		if p.limitador != nil {
+			if err := p.limitador.Aguardar(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		cotacoes, err := p.provedor.BuscarTaxas(ctx, moedaBase)
+		if err == nil || tentativa >= tentativas || ctx.Err() != nil || !ehTransitoria(err) {
+			return cotacoes, err
+		}
+
+		espera := p.retentativa.espera(tentativa)
+		var erroHTTP *ErroHTTP
+		if errors.As(err, &erroHTTP) && erroHTTP.RetryAfter > 0 {
+			// Não vale esperar além do limite: o failover tenta o próximo provedor
+			if erroHTTP.RetryAfter > p.retentativa.EsperaMaxima {
+				return nil, err
+			}
+			espera = erroHTTP.RetryAfter
+		}
+
+		fmt.Printf("Aviso: %s falhou para %s (%v); tentativa %d de %d em %s\n",
+			p.Nome(), moedaBase, err, tentativa+1, tentativas, espera.Round(time.Millisecond))
+
+		select {
+		case <-time.After(espera):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Saude informa o estado do circuito do provedor
+func (p *ProvedorResiliente) Saude() []SaudeProvedor {
+	if p.disjuntor == nil {
+		return []SaudeProvedor{{Nome: p.Nome(), Circuito: CircuitoFechado}}
+	}
+	return []SaudeProvedor{p.disjuntor.Estado(p.Nome())}
+}
@@ -0,0 +1,198 @@
+package cambio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// servidorInstavel responde com os status informados, em ordem, e depois 200
+func servidorInstavel(t *testing.T, cabecalhos map[string]string, status ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var chamadas atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(chamadas.Add(1))
+		if n <= len(status) {
+			for k, v := range cabecalhos {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(status[n-1])
+			return
+		}
+		w.Write([]byte(`{"rates": {"BRL": 5.4}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &chamadas
+}
+
+func resilienciaTeste() Resiliencia {
+	return Resiliencia{
+		Retentativa: PoliticaRetentativa{
+			Tentativas:    3,
+			EsperaInicial: time.Millisecond,
+			EsperaMaxima:  5 * time.Millisecond,
+		},
+		FalhasCircuito:      2,
+		TempoCircuitoAberto: 50 * time.Millisecond,
+	}
+}
+
+func TestRetentativaEmFalhaTransitoria(t *testing.T) {
+	srv, chamadas := servidorInstavel(t, nil, http.StatusServiceUnavailable, http.StatusBadGateway)
+	p := resilienciaTeste().Envolver(NewFXRatesAPIProvider(srv.URL, srv.Client()))
+
+	cotacoes, err := p.BuscarTaxas(context.Background(), "USD")
+	if err != nil || cotacoes.Taxas["BRL"] != 5.4 {
+		t.Fatalf("esperado sucesso na terceira tentativa, obtido %+v (erro: %v)", cotacoes, err)
+	}
+	if n := chamadas.Load(); n != 3 {
+		t.Errorf("esperadas 3 chamadas, obtidas %d", n)
+	}
+}
+
+func TestSemRetentativaEmErroDefinitivo(t *testing.T) {
+	srv, chamadas := servidorInstavel(t, nil, http.StatusNotFound)
+	p := resilienciaTeste().Envolver(NewFXRatesAPIProvider(srv.URL, srv.Client()))
+
+	_, err := p.BuscarTaxas(context.Background(), "USD")
+	var erroHTTP *ErroHTTP
+	if !errors.As(err, &erroHTTP) || erroHTTP.Status != http.StatusNotFound {
+		t.Fatalf("esperado ErroHTTP 404, obtido %v", err)
+	}
+	if n := chamadas.Load(); n != 1 {
+		t.Errorf("404 não deveria ser repetido, obtidas %d chamadas", n)
+	}
+}
+
+func TestRespeitaRetryAfter(t *testing.T) {
+	srv, _ := servidorInstavel(t, map[string]string{"Retry-After": "1"}, http.StatusTooManyRequests)
+	resiliencia := resilienciaTeste()
+	resiliencia.Retentativa.EsperaMaxima = 2 * time.Second
+	p := resiliencia.Envolver(NewFXRatesAPIProvider(srv.URL, srv.Client()))
+
+	inicio := time.Now()
+	if _, err := p.BuscarTaxas(context.Background(), "USD"); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if decorrido := time.Since(inicio); decorrido < 900*time.Millisecond {
+		t.Errorf("deveria aguardar o Retry-After de 1s, aguardou %s", decorrido)
+	}
+}
+
+func TestRetryAfterAcimaDoLimiteNaoAguarda(t *testing.T) {
+	srv, chamadas := servidorInstavel(t, map[string]string{"Retry-After": "120"}, http.StatusTooManyRequests)
+	p := resilienciaTeste().Envolver(NewFXRatesAPIProvider(srv.URL, srv.Client()))
+
+	if _, err := p.BuscarTaxas(context.Background(), "USD"); err == nil {
+		t.Fatal("esperado erro para Retry-After acima da espera máxima")
+	}
+	if n := chamadas.Load(); n != 1 {
+		t.Errorf("esperada 1 chamada, obtidas %d", n)
+	}
+}
+
+func TestDisjuntorAbreEFechaAposTeste(t *testing.T) {
+	var falhar atomic.Bool
+	falhar.Store(true)
+	var chamadas atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chamadas.Add(1)
+		if falhar.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"rates": {"BRL": 5.4}}`))
+	}))
+	defer srv.Close()
+
+	resiliencia := resilienciaTeste()
+	resiliencia.Retentativa.Tentativas = 1
+	p := resiliencia.Envolver(NewFXRatesAPIProvider(srv.URL, srv.Client()))
+
+	p.BuscarTaxas(context.Background(), "USD")
+	p.BuscarTaxas(context.Background(), "USD")
+	if estado := p.Saude()[0].Circuito; estado != CircuitoAberto {
+		t.Fatalf("circuito deveria abrir após 2 falhas, estado %s", estado)
+	}
+
+	if _, err := p.BuscarTaxas(context.Background(), "USD"); !errors.Is(err, ErrCircuitoAberto) {
+		t.Errorf("esperado ErrCircuitoAberto, obtido %v", err)
+	}
+	if n := chamadas.Load(); n != 2 {
+		t.Errorf("circuito aberto não deveria chamar a API, obtidas %d chamadas", n)
+	}
+
+	falhar.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := p.BuscarTaxas(context.Background(), "USD"); err != nil {
+		t.Fatalf("chamada de teste deveria passar: %v", err)
+	}
+	if saude := p.Saude()[0]; saude.Circuito != CircuitoFechado || saude.FalhasSeguidas != 0 {
+		t.Errorf("circuito deveria fechar após o teste, obtido %+v", saude)
+	}
+}
+
+func TestDisjuntorReabreSeTesteFalhar(t *testing.T) {
+	d := NewDisjuntor(1, 10*time.Millisecond)
+	d.RegistrarFalha()
+
+	time.Sleep(15 * time.Millisecond)
+	if err := d.Permitir(); err != nil {
+		t.Fatalf("chamada de teste deveria ser liberada: %v", err)
+	}
+	if err := d.Permitir(); !errors.Is(err, ErrCircuitoAberto) {
+		t.Errorf("apenas uma chamada de teste deveria ser liberada, obtido %v", err)
+	}
+
+	d.RegistrarFalha()
+	if saude := d.Estado("teste"); saude.Circuito != CircuitoAberto || saude.AbertoAte == nil {
+		t.Errorf("falha no teste deveria reabrir o circuito, obtido %+v", saude)
+	}
+}
+
+func TestLimitadorTaxa(t *testing.T) {
+	l := NewLimitadorTaxa(20, 1)
+
+	inicio := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Aguardar(context.Background()); err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+	}
+	// Uma ficha imediata e duas repostas a 20/s
+	if decorrido := time.Since(inicio); decorrido < 90*time.Millisecond {
+		t.Errorf("esperada espera de cerca de 100ms, obtida %s", decorrido)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Aguardar(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("esperado cancelamento, obtido %v", err)
+	}
+}
+
+func TestLerRetryAfter(t *testing.T) {
+	if d := lerRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("esperado 3s, obtido %s", d)
+	}
+	data := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if d := lerRetryAfter(data); d < 8*time.Second || d > 10*time.Second {
+		t.Errorf("esperado cerca de 10s, obtido %s", d)
+	}
+	if d := lerRetryAfter("amanhã"); d != 0 {
+		t.Errorf("valor inválido deveria ser ignorado, obtido %s", d)
+	}
+}
+
+func TestEsperaExponencialLimitada(t *testing.T) {
+	p := PoliticaRetentativa{Tentativas: 10, EsperaInicial: 100 * time.Millisecond, EsperaMaxima: time.Second}
+	for tentativa := 1; tentativa <= 8; tentativa++ {
+		if espera := p.espera(tentativa); espera < 0 || espera > time.Second {
+			t.Errorf("tentativa %d: espera %s fora do intervalo", tentativa, espera)
+		}
+	}
+}
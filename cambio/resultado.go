@@ -0,0 +1,89 @@
+package cambio
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrTaxasIndisponiveis indica que nenhuma moeda base pôde ser obtida
+var ErrTaxasIndisponiveis = errors.New("nenhuma taxa de câmbio disponível")
+
+// ResultadoTaxas reúne as taxas obtidas por moeda base e as falhas de cada
+// moeda que não pôde ser buscada
+type ResultadoTaxas struct {
+	Taxas  map[string]map[string]float64 `json:"taxas"`
+	Falhas map[string]string             `json:"falhas,omitempty"`
+
+	// Divergencias lista cotações derivadas por triangulação que diferem das
+	// cotações diretas verificadas
+	Divergencias []Divergencia `json:"divergencias,omitempty"`
+
+	// Fontes indica o provedor que forneceu as taxas de cada moeda base
+	Fontes map[string]string `json:"fontes,omitempty"`
+	// ObtidoEm é o momento em que as taxas foram buscadas
+	ObtidoEm time.Time `json:"obtido_em"`
+	// Obsoleto indica taxas servidas do cache já vencido enquanto uma nova busca está em andamento
+	Obsoleto bool `json:"obsoleto,omitempty"`
+	// DoCache indica taxas reaproveitadas de uma busca anterior, e não buscadas agora
+	DoCache bool `json:"-"`
+}
+
+// NewResultadoTaxas cria um resultado vazio
+func NewResultadoTaxas() *ResultadoTaxas {
+	return &ResultadoTaxas{
+		Taxas:  make(map[string]map[string]float64),
+		Falhas: make(map[string]string),
+		Fontes: make(map[string]string),
+	}
+}
+
+// Completo indica que todas as moedas base foram obtidas
+func (r *ResultadoTaxas) Completo() bool {
+	return len(r.Falhas) == 0
+}
+
+// Parcial indica que apenas parte das moedas base foi obtida
+func (r *ResultadoTaxas) Parcial() bool {
+	return len(r.Falhas) > 0 && len(r.Taxas) > 0
+}
+
+// MoedasComFalha retorna, em ordem alfabética, as moedas base que falharam
+func (r *ResultadoTaxas) MoedasComFalha() []string {
+	moedas := make([]string, 0, len(r.Falhas))
+	for moeda := range r.Falhas {
+		moedas = append(moedas, moeda)
+	}
+	sort.Strings(moedas)
+	return moedas
+}
+
+// Erro agrega as falhas em um único erro, ou nil se não houver falhas
+func (r *ResultadoTaxas) Erro() error {
+	var errs []error
+	for _, moeda := range r.MoedasComFalha() {
+		errs = append(errs, fmt.Errorf("%s: %s", moeda, r.Falhas[moeda]))
+	}
+	return errors.Join(errs...)
+}
+
+// FonteDe classifica a origem das taxas da moeda base: tabela de fallback,
+// cache ou busca ao vivo
+func (r *ResultadoTaxas) FonteDe(moedaBase string) FonteTaxa {
+	if ehFonteFallback(r.Fontes[moedaBase]) {
+		return FonteFallback
+	}
+	if r.DoCache {
+		return FonteCache
+	}
+	return FonteAoVivo
+}
+
+// Idade retorna há quanto tempo as taxas foram buscadas
+func (r *ResultadoTaxas) Idade() time.Duration {
+	if r.ObtidoEm.IsZero() {
+		return 0
+	}
+	return time.Since(r.ObtidoEm)
+}
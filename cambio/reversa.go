@@ -0,0 +1,114 @@
+package cambio
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"golang-project/moeda"
+)
+
+// MAXIMO_DOBRAS_REVERSA limita quantas vezes a estimativa do valor de origem
+// é dobrada à procura de um valor suficiente
+const MAXIMO_DOBRAS_REVERSA = 64
+
+// CalcularConversaoReversa encontra o menor valor na moeda de origem que,
+// convertido, entrega ao menos valorDestino na moeda de destino. Com tipo
+// informado, considera o spread e a comissão da operação, como
+// CalcularOperacao; sem tipo, usa a taxa média, como CalcularConversao.
+// A conversão retornada é a conversão direta do valor encontrado, então o
+// valor de destino pode superar o pedido pelo arredondamento das moedas.
+func (s *ServicoTaxasCambio) CalcularConversaoReversa(ctx context.Context, valorDestino moeda.Decimal, moedaOrigem, moedaDestino, tipo string) (*Conversao, error) {
+	resultado, err := s.ObterTaxas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter taxas: %w", err)
+	}
+
+	registro := s.cliente.moedas()
+	alvo := registro.Arredondar(valorDestino, moedaDestino)
+	if !alvo.IsPositive() {
+		return nil, fmt.Errorf("valor de destino deve ser maior que zero")
+	}
+
+	// Confere o par antes da busca, para que a falta de taxa não seja
+	// confundida com valor insuficiente
+	media, err := s.converterCom(resultado, alvo, moedaOrigem, moedaDestino)
+	if err != nil {
+		return nil, err
+	}
+
+	converter := func(valor moeda.Decimal) (*Conversao, error) {
+		conversao, err := s.converterCom(resultado, valor, moedaOrigem, moedaDestino)
+		if err != nil || tipo == "" {
+			return conversao, err
+		}
+		return s.precificacao.Precificar(conversao, tipo)
+	}
+
+	casas := int32(2)
+	if m, existe := registro.Obter(moedaOrigem); existe {
+		casas = int32(m.CasasDecimais)
+	}
+
+	return resolverValorOrigem(converter, alvo, media.Taxa, casas)
+}
+
+// resolverValorOrigem busca, em unidades da menor casa decimal da moeda de
+// origem, o menor valor cuja conversão alcança o alvo. A conversão cresce
+// com o valor, então basta dobrar a estimativa até ela ser suficiente e
+// depois fazer uma busca binária.
+func resolverValorOrigem(converter func(moeda.Decimal) (*Conversao, error), alvo, taxa moeda.Decimal, casas int32) (*Conversao, error) {
+	valorEm := func(unidades int64) moeda.Decimal {
+		return moeda.New(unidades, casas)
+	}
+	suficiente := func(unidades int64) (*Conversao, bool) {
+		conversao, err := converter(valorEm(unidades))
+		// Valores que não cobrem a comissão são apenas insuficientes
+		if err != nil {
+			return nil, false
+		}
+		return conversao, conversao.ValorDestino.Cmp(alvo) >= 0
+	}
+
+	// Estimativa inicial pela taxa, ignorando spread e comissão
+	estimativa := int64(1)
+	if taxa.IsPositive() {
+		// A estimativa só precisa ser próxima; a busca abaixo é exata
+		unidades := math.Ceil(alvo.Div(taxa, casas, moeda.ArredondamentoParaCima).Float64() * math.Pow10(int(casas)))
+		if unidades >= 1 && unidades < 1<<62 {
+			estimativa = int64(unidades)
+		}
+	}
+
+	var insuficiente int64
+	alto := estimativa
+	melhor, ok := suficiente(alto)
+	for dobras := 0; !ok; dobras++ {
+		if dobras == MAXIMO_DOBRAS_REVERSA || alto > (1<<62) {
+			return nil, fmt.Errorf("não foi possível encontrar valor de origem para %s", alvo)
+		}
+		insuficiente = alto
+		alto *= 2
+		melhor, ok = suficiente(alto)
+	}
+
+	for alto-insuficiente > 1 {
+		meio := insuficiente + (alto-insuficiente)/2
+		if conversao, ok := suficiente(meio); ok {
+			alto, melhor = meio, conversao
+		} else {
+			insuficiente = meio
+		}
+	}
+
+	return melhor, nil
+}
+
+// TaxaEfetiva é quanto se recebe na moeda de destino por unidade paga na
+// moeda de origem, já descontados spread, comissão e arredondamentos
+func (c *Conversao) TaxaEfetiva() moeda.Decimal {
+	if !c.ValorOrigem.IsPositive() {
+		return moeda.Decimal{}
+	}
+	return c.ValorDestino.Div(c.ValorOrigem, CASAS_TAXA, moeda.ArredondamentoBancario)
+}
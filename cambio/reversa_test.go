@@ -0,0 +1,110 @@
+package cambio
+
+import (
+	"context"
+	"testing"
+
+	"golang-project/moeda"
+)
+
+// conferirMinimo verifica que valorOrigem alcança o alvo e que um centavo
+// (ou a menor unidade da moeda) a menos já não alcança
+func conferirMinimo(t *testing.T, calcular func(moeda.Decimal) (*Conversao, error), reversa *Conversao, alvo moeda.Decimal, unidade moeda.Decimal) {
+	t.Helper()
+
+	direta, err := calcular(reversa.ValorOrigem)
+	if err != nil {
+		t.Fatalf("conversão direta de %s falhou: %v", reversa.ValorOrigem, err)
+	}
+	if direta.ValorDestino.Cmp(alvo) < 0 || !direta.ValorDestino.Equal(reversa.ValorDestino) {
+		t.Errorf("%s deveria entregar ao menos %s, entrega %s (reversa informou %s)",
+			reversa.ValorOrigem, alvo, direta.ValorDestino, reversa.ValorDestino)
+	}
+
+	if menor, err := calcular(reversa.ValorOrigem.Sub(unidade)); err == nil && menor.ValorDestino.Cmp(alvo) >= 0 {
+		t.Errorf("%s não é o mínimo: %s já entrega %s", reversa.ValorOrigem, reversa.ValorOrigem.Sub(unidade), menor.ValorDestino)
+	}
+}
+
+func TestConversaoReversaTaxaMedia(t *testing.T) {
+	servico := novoServicoTeste(&provedorContador{taxas: taxasUSD()}, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+	ctx := context.Background()
+
+	alvo := moeda.NewFromInt(1000)
+	reversa, err := servico.CalcularConversaoReversa(ctx, alvo, "BRL", "USD", "")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	calcular := func(valor moeda.Decimal) (*Conversao, error) {
+		return servico.CalcularConversao(ctx, valor, "BRL", "USD")
+	}
+	conferirMinimo(t, calcular, reversa, alvo, moeda.MustFromString("0.01"))
+
+	if reversa.ValorOrigem.String() != "5399.98" {
+		t.Errorf("esperado BRL 5399.98 para USD 1000, obtido %s", reversa.ValorOrigem)
+	}
+}
+
+func TestConversaoReversaComSpreadEComissao(t *testing.T) {
+	servico := novoServicoTeste(&provedorContador{taxas: taxasUSD()}, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+	servico.UsarPrecificacao(NewMotorPrecificacao(TabelaPrecos{
+		Spreads:   []RegraSpread{{Tipo: "Compra", Percentual: moeda.MustFromString("2")}},
+		Comissoes: []RegraComissao{{Tipo: "Compra", Fixa: moeda.MustFromString("5"), Percentual: moeda.MustFromString("1")}},
+	}))
+	ctx := context.Background()
+
+	alvo := moeda.NewFromInt(1000)
+	reversa, err := servico.CalcularConversaoReversa(ctx, alvo, "BRL", "USD", "Compra")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if reversa.Comissao.IsZero() || reversa.Spread.IsZero() {
+		t.Errorf("conversão reversa deveria trazer spread e comissão, obtido %+v", reversa)
+	}
+
+	calcular := func(valor moeda.Decimal) (*Conversao, error) {
+		return servico.CalcularOperacao(ctx, valor, "BRL", "USD", "Compra")
+	}
+	conferirMinimo(t, calcular, reversa, alvo, moeda.MustFromString("0.01"))
+
+	efetiva := reversa.TaxaEfetiva()
+	if efetiva.Cmp(reversa.Taxa) >= 0 {
+		t.Errorf("taxa efetiva %s deveria ser menor que a taxa %s por causa da comissão", efetiva, reversa.Taxa)
+	}
+}
+
+func TestConversaoReversaArredondamentoDaMoeda(t *testing.T) {
+	servico := novoServicoTeste(&provedorContador{taxas: taxasUSD()}, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+	ctx := context.Background()
+
+	// JPY não tem casas decimais: o valor de origem é inteiro
+	alvo := moeda.MustFromString("10.00")
+	reversa, err := servico.CalcularConversaoReversa(ctx, alvo, "JPY", "USD", "")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if reversa.ValorOrigem.CasasDecimais() != 0 {
+		t.Errorf("valor em JPY deveria ser inteiro, obtido %s", reversa.ValorOrigem)
+	}
+
+	calcular := func(valor moeda.Decimal) (*Conversao, error) {
+		return servico.CalcularConversao(ctx, valor, "JPY", "USD")
+	}
+	conferirMinimo(t, calcular, reversa, alvo, moeda.NewFromInt(1))
+}
+
+func TestConversaoReversaSemTaxa(t *testing.T) {
+	servico := novoServicoTeste(&provedorContador{taxas: taxasUSD()}, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+
+	if _, err := servico.CalcularConversaoReversa(context.Background(), moeda.NewFromInt(10), "USD", "XYZ", ""); err == nil {
+		t.Error("esperado erro para par sem taxa")
+	}
+	if _, err := servico.CalcularConversaoReversa(context.Background(), moeda.Decimal{}, "USD", "BRL", ""); err == nil {
+		t.Error("esperado erro para valor de destino zero")
+	}
+}
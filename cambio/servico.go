@@ -1,92 +1,430 @@
 package cambio
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang-project/moeda"
 )
 
+// ServicoTaxas é o serviço de câmbio compartilhado pela CLI, pelo relatório e
+// pelo servidor. ServicoTaxasCambio é a implementação; testes podem usar um falso.
+type ServicoTaxas interface {
+	// Iniciar prepara o serviço conforme a estratégia de carga
+	Iniciar(ctx context.Context) error
+	ObterTaxas(ctx context.Context) (*ResultadoTaxas, error)
+	ForcarAtualizacao(ctx context.Context) (*ResultadoTaxas, error)
+	LimparCache(ctx context.Context) error
+
+	CalcularConversao(ctx context.Context, valor moeda.Decimal, moedaOrigem, moedaDestino string) (*Conversao, error)
+	// ConverterLote converte vários itens com uma única leitura das taxas
+	ConverterLote(ctx context.Context, itens []ItemConversao) (*LoteConversoes, error)
+	CalcularOperacao(ctx context.Context, valor moeda.Decimal, moedaOrigem, moedaDestino, tipo string) (*Conversao, error)
+	// CalcularConversaoReversa encontra o valor de origem que entrega valorDestino
+	CalcularConversaoReversa(ctx context.Context, valorDestino moeda.Decimal, moedaOrigem, moedaDestino, tipo string) (*Conversao, error)
+	AplicarIOF(conversao *Conversao, categoria CategoriaIOF, data time.Time) error
+
+	CriarCotacao(ctx context.Context, userID int, valor moeda.Decimal, moedaOrigem, moedaDestino, tipo string, categoria CategoriaIOF) (*CotacaoTravada, error)
+	ExecutarCotacao(ctx context.Context, id string, userID int, valor moeda.Decimal, moedaOrigem, moedaDestino, tipo string, categoria CategoriaIOF) (*CotacaoTravada, error)
+	// DevolverCotacao torna a executar uma cotação consumida cuja operação não foi gravada
+	DevolverCotacao(ctx context.Context, cotacao *CotacaoTravada) error
+
+	// Saude informa o estado dos provedores de taxas
+	Saude() []SaudeProvedor
+	// Parar encerra as atualizações em segundo plano
+	Parar(ctx context.Context) error
+}
+
 type ServicoTaxasCambio struct {
-	cliente *CambioClient
-	cache   *GerenciadorCache
+	cliente         *CambioClient
+	estrategia      EstrategiaCarga
+	antecedencia    time.Duration
+	cache           *GerenciadorCache
+	precificacao    *MotorPrecificacao
+	iof             *TabelaIOF
+	historico       HistoricoRepository
+	cotacoes        CotacaoRepository
+	validadeCotacao time.Duration
+	permitirParcial bool
+	validador       *ValidadorTaxas
+	alertar         func(AlertaTaxas)
+
+	// Atualização em segundo plano: taxas vencidas há menos de maximoObsoleto
+	// são servidas enquanto uma única busca compartilhada as renova
+	maximoObsoleto time.Duration
+	mu             sync.Mutex
+	emAndamento    *atualizacaoEmAndamento
+	ultimas        *ResultadoTaxas
+	ctxFundo       context.Context
+	cancelar       context.CancelFunc
+	wg             sync.WaitGroup
 }
 
 func NewServicoTaxasCambio() *ServicoTaxasCambio {
+	return NewServicoTaxasCambioComCliente(NewCambioClient())
+}
+
+// NewServicoTaxasCambioComCliente cria o serviço usando um cliente já configurado
+func NewServicoTaxasCambioComCliente(cliente *CambioClient) *ServicoTaxasCambio {
+	ctxFundo, cancelar := context.WithCancel(context.Background())
 	return &ServicoTaxasCambio{
-		cliente: NewCambioClient(),
-		cache:   NewGerenciadorCache(),
+		cliente:         cliente,
+		estrategia:      CargaCache,
+		antecedencia:    ANTECEDENCIA_ATUALIZACAO_PADRAO,
+		cache:           NewGerenciadorCache(),
+		precificacao:    NewMotorPrecificacao(TabelaPrecos{}),
+		iof:             TabelaIOFPadrao(),
+		cotacoes:        NewCotacoesEmMemoria(),
+		validadeCotacao: VALIDADE_COTACAO_PADRAO,
+		permitirParcial: true,
+		validador:       NewValidadorTaxas(TOLERANCIA_INVERSA_PADRAO, VARIACAO_MAXIMA_PADRAO),
+		alertar:         imprimirAlerta,
+		maximoObsoleto:  MAXIMO_OBSOLETO_PADRAO,
+		ctxFundo:        ctxFundo,
+		cancelar:        cancelar,
 	}
 }
 
-func (s *ServicoTaxasCambio) ObterTaxasAtualizadas() (map[string]map[string]float64, error) {
-	if taxas, valido := s.cache.CarregarCache(); valido {
-		return taxas, nil
+// UsarEstrategia define quando as taxas são buscadas na API (padrão: CargaCache)
+func (s *ServicoTaxasCambio) UsarEstrategia(estrategia EstrategiaCarga) {
+	s.estrategia = estrategia
+}
+
+// AnteciparAtualizacao define quanto antes do vencimento do cache CargaPeriodica
+// renova as taxas; zero renova no vencimento
+func (s *ServicoTaxasCambio) AnteciparAtualizacao(antecedencia time.Duration) {
+	s.antecedencia = antecedencia
+}
+
+// PermitirResultadoParcial define se taxas com falha em algumas moedas base
+// podem ser servidas (padrão) ou se devem ser tratadas como erro
+func (s *ServicoTaxasCambio) PermitirResultadoParcial(permitir bool) {
+	s.permitirParcial = permitir
+}
+
+// UsarValidador define as verificações aplicadas às taxas buscadas; nil desativa
+func (s *ServicoTaxasCambio) UsarValidador(validador *ValidadorTaxas) {
+	s.validador = validador
+}
+
+// UsarAlertas define quem é avisado quando uma busca é rejeitada pela validação
+func (s *ServicoTaxasCambio) UsarAlertas(alertar func(AlertaTaxas)) {
+	s.alertar = alertar
+}
+
+// UsarPrecificacao define o motor de spreads e comissões das operações
+func (s *ServicoTaxasCambio) UsarPrecificacao(motor *MotorPrecificacao) {
+	s.precificacao = motor
+}
+
+// ServirObsoleto define por quanto tempo após o vencimento as taxas em cache
+// ainda podem ser servidas (marcadas como obsoletas) enquanto são renovadas.
+// Zero desativa: toda requisição com cache vencido espera pela API.
+func (s *ServicoTaxasCambio) ServirObsoleto(maximo time.Duration) {
+	s.maximoObsoleto = maximo
+}
+
+// UsarCache substitui o cache padrão (arquivo no diretório atual)
+func (s *ServicoTaxasCambio) UsarCache(cache *GerenciadorCache) {
+	s.cache = cache
+}
+
+// UsarHistorico faz com que cada busca bem-sucedida seja gravada no histórico
+func (s *ServicoTaxasCambio) UsarHistorico(historico HistoricoRepository) {
+	s.historico = historico
+}
+
+// UsarCotacoes define onde as cotações travadas são guardadas e por quanto tempo valem
+func (s *ServicoTaxasCambio) UsarCotacoes(cotacoes CotacaoRepository, validade time.Duration) {
+	s.cotacoes = cotacoes
+	s.validadeCotacao = validade
+}
+
+// UsarTabelaIOF substitui a tabela de alíquotas de IOF padrão
+func (s *ServicoTaxasCambio) UsarTabelaIOF(tabela *TabelaIOF) {
+	s.iof = tabela
+}
+
+// obterTaxasDoCache retorna as taxas do cache se válidas. Com o cache
+// vencido há menos de maximoObsoleto, retorna as taxas antigas marcadas como
+// obsoletas e dispara a renovação em segundo plano; caso contrário, aguarda a busca.
+func (s *ServicoTaxasCambio) obterTaxasDoCache(ctx context.Context) (*ResultadoTaxas, error) {
+	resultado, valido := s.cache.CarregarUltimo(ctx)
+	if valido {
+		return resultado, nil
+	}
+
+	if resultado != nil && resultado.Idade() <= s.cache.Validade()+s.maximoObsoleto {
+		fmt.Printf("Cache vencido há %s; servindo taxas obsoletas enquanto atualiza\n",
+			(resultado.Idade() - s.cache.Validade()).Round(time.Second))
+		s.iniciarAtualizacao(false)
+		resultado.Obsoleto = true
+		return resultado, nil
 	}
 
 	fmt.Println("Buscando taxas atualizadas da API...")
-	taxas, err := s.cliente.BuscarTaxasParaTodasMoedas()
+	return s.atualizar(ctx)
+}
+
+func (s *ServicoTaxasCambio) ForcarAtualizacao(ctx context.Context) (*ResultadoTaxas, error) {
+	fmt.Println("Forçando atualização das taxas...")
+	return s.atualizarForcado(ctx)
+}
+
+// buscarESalvar consulta a API e guarda o resultado na memória e, se a
+// estratégia usar cache, no cache, aplicando a política de resultados parciais.
+// renovada é falso quando as taxas anteriores foram mantidas, por rejeição da
+// validação ou falha ao gravar o cache, mesmo sem erro para quem pediu. Uma
+// busca forcada não é comparada com as taxas anteriores.
+func (s *ServicoTaxasCambio) buscarESalvar(ctx context.Context, forcada bool) (*ResultadoTaxas, bool, error) {
+	resultado, err := s.cliente.BuscarTaxasParaTodasMoedas(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar taxas da API: %w", err)
+		return nil, false, fmt.Errorf("erro ao buscar taxas da API: %w", err)
 	}
 
-	err = s.cache.SalvarCache(taxas)
-	if err != nil {
-		fmt.Printf("Aviso: erro ao salvar cache: %v\n", err)
+	if !resultado.Completo() && !s.permitirParcial {
+		return nil, false, fmt.Errorf("taxas incompletas: %w", resultado.Erro())
+	}
+
+	// Taxas anômalas não entram em uso: as últimas válidas são mantidas
+	if s.validador != nil {
+		anterior := s.ultimasValidas(ctx)
+		referencia := anterior
+		if forcada || !s.referenciaRecente(anterior) {
+			referencia = nil
+		}
+		if anomalias := s.validador.Validar(resultado, referencia); len(anomalias) > 0 {
+			mantidas, err := s.rejeitar(anomalias, anterior)
+			return mantidas, false, err
+		}
+	}
+
+	s.mu.Lock()
+	s.ultimas = resultado
+	s.mu.Unlock()
+
+	// Salvar no cache
+	renovada := true
+	if s.estrategia.usaCache() {
+		err = s.cache.SalvarCache(ctx, resultado)
+		if err != nil {
+			fmt.Printf("Aviso: erro ao salvar cache: %v\n", err)
+			renovada = false
+		}
+	}
+
+	// Gravar no histórico; uma falha aqui não impede o uso das taxas
+	if s.historico != nil {
+		if mercado := taxasDeMercado(resultado); mercado != nil {
+			if err := s.historico.Registrar(ctx, mercado); err != nil {
+				fmt.Printf("Aviso: erro ao gravar histórico de taxas: %v\n", err)
+			}
+		}
+	}
+
+	return resultado, renovada, nil
+}
 
+// ultimasValidas retorna as últimas taxas aceitas, da memória ou do cache,
+// mesmo que vencidas; nil se não houver nenhuma
+func (s *ServicoTaxasCambio) ultimasValidas(ctx context.Context) *ResultadoTaxas {
+	s.mu.Lock()
+	ultimas := s.ultimas
+	s.mu.Unlock()
+
+	if ultimas != nil {
+		copia := *ultimas
+		copia.DoCache = true
+		return &copia
+	}
+	if !s.estrategia.usaCache() {
+		return nil
 	}
+	resultado, _ := s.cache.CarregarUltimo(ctx)
+	return resultado
+}
 
-	return taxas, nil
+// referenciaRecente indica se as taxas anteriores ainda servem de referência
+// para a variação: taxas mais velhas que o período em que seriam servidas
+// como obsoletas não dizem nada sobre o mercado atual
+func (s *ServicoTaxasCambio) referenciaRecente(anterior *ResultadoTaxas) bool {
+	return anterior != nil && anterior.Idade() <= s.cache.Validade()+s.maximoObsoleto
 }
 
-func (s *ServicoTaxasCambio) ObterTaxasRapidas() (map[string]map[string]float64, error) {
-	taxas, valido := s.cache.CarregarCache()
-	if !valido {
-		return nil, fmt.Errorf("cache não disponível ou expirado")
+// rejeitar emite o alerta e mantém em uso as taxas anteriores, marcadas como
+// obsoletas se já vencidas. Sem taxas anteriores, retorna ErrTaxasAnomalas.
+func (s *ServicoTaxasCambio) rejeitar(anomalias []AnomaliaTaxa, anterior *ResultadoTaxas) (*ResultadoTaxas, error) {
+	alerta := AlertaTaxas{
+		Momento:   time.Now(),
+		Anomalias: anomalias,
+	}
+	if anterior != nil {
+		alerta.MantidasDesde = anterior.ObtidoEm
+	}
+	if s.alertar != nil {
+		s.alertar(alerta)
+	}
+
+	if anterior == nil {
+		return nil, fmt.Errorf("%w: %d anomalia(s), a primeira: %s", ErrTaxasAnomalas, len(anomalias), anomalias[0].Mensagem)
+	}
+
+	if s.estrategia.usaCache() && anterior.Idade() > s.cache.Validade() {
+		anterior.Obsoleto = true
 	}
-	return taxas, nil
+	return anterior, nil
 }
 
-func (s *ServicoTaxasCambio) ForcarAtualizacao() (map[string]map[string]float64, error) {
-	fmt.Println("Forçando atualização das taxas...")
+// imprimirAlerta é o destino padrão dos alertas de validação
+func imprimirAlerta(alerta AlertaTaxas) {
+	fmt.Printf("ALERTA: %d taxa(s) rejeitada(s) pela validação\n", len(alerta.Anomalias))
+	for _, a := range alerta.Anomalias {
+		fmt.Printf("  - [%s] %s\n", a.Tipo, a.Mensagem)
+	}
+	if !alerta.MantidasDesde.IsZero() {
+		fmt.Printf("  Mantidas em uso as taxas obtidas em %s\n", alerta.MantidasDesde.Format(time.RFC3339))
+	}
+}
 
-	taxas, err := s.cliente.BuscarTaxasParaTodasMoedas()
+// CalcularConversao converte o valor à taxa média das taxas atuais
+func (s *ServicoTaxasCambio) CalcularConversao(ctx context.Context, valor moeda.Decimal, moedaOrigem, moedaDestino string) (*Conversao, error) {
+	resultado, err := s.ObterTaxas(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar taxas da API: %w", err)
+		return nil, fmt.Errorf("erro ao obter taxas: %w", err)
 	}
 
-	// Salvar no cache
-	err = s.cache.SalvarCache(taxas)
+	return s.converterCom(resultado, valor, moedaOrigem, moedaDestino)
+}
+
+// converterCom converte o valor usando as taxas já obtidas
+func (s *ServicoTaxasCambio) converterCom(resultado *ResultadoTaxas, valor moeda.Decimal, moedaOrigem, moedaDestino string) (*Conversao, error) {
+	if falha, existe := resultado.Falhas[moedaOrigem]; existe {
+		return nil, fmt.Errorf("taxas de %s indisponíveis: %s", moedaOrigem, falha)
+	}
+
+	conversao, err := s.cliente.CalcularConversao(valor, moedaOrigem, moedaDestino, resultado.Taxas)
+	if err != nil {
+		return nil, err
+	}
+	conversao.Fonte = resultado.FonteDe(moedaOrigem)
+	return conversao, nil
+}
+
+// CalcularOperacao converte o valor à taxa média e aplica o spread e a
+// comissão do tipo de operação (Compra, Venda...)
+func (s *ServicoTaxasCambio) CalcularOperacao(ctx context.Context, valor moeda.Decimal, moedaOrigem, moedaDestino, tipo string) (*Conversao, error) {
+	media, err := s.CalcularConversao(ctx, valor, moedaOrigem, moedaDestino)
 	if err != nil {
-		fmt.Printf("Aviso: erro ao salvar cache: %v\n", err)
+		return nil, err
 	}
+	return s.precificacao.Precificar(media, tipo)
+}
 
-	return taxas, nil
+// AplicarIOF calcula o IOF da operação com as alíquotas vigentes na data
+func (s *ServicoTaxasCambio) AplicarIOF(conversao *Conversao, categoria CategoriaIOF, data time.Time) error {
+	return s.iof.Aplicar(conversao, categoria, data)
 }
 
-func (s *ServicoTaxasCambio) CalcularConversaoComAPI(valor float64, moedaOrigem, moedaDestino string) (float64, error) {
-	taxas, err := s.ObterTaxasAtualizadas()
+// CriarCotacao calcula o preço completo da operação (spread, comissão e IOF)
+// e o trava para o usuário durante a validade configurada
+func (s *ServicoTaxasCambio) CriarCotacao(ctx context.Context, userID int, valor moeda.Decimal, moedaOrigem, moedaDestino, tipo string, categoria CategoriaIOF) (*CotacaoTravada, error) {
+	conversao, err := s.CalcularOperacao(ctx, valor, moedaOrigem, moedaDestino, tipo)
+	if err != nil {
+		return nil, err
+	}
+
+	agora := time.Now()
+	if err := s.AplicarIOF(conversao, categoria, agora); err != nil {
+		return nil, err
+	}
+
+	id, err := novoIDCotacao()
 	if err != nil {
-		return 0, fmt.Errorf("erro ao obter taxas: %w", err)
+		return nil, err
 	}
 
-	return s.cliente.CalcularConversao(valor, moedaOrigem, moedaDestino, taxas)
+	cotacao := &CotacaoTravada{
+		ID:        id,
+		Conversao: conversao,
+		UserID:    userID,
+		CriadaEm:  agora,
+		ExpiraEm:  agora.Add(s.validadeCotacao),
+	}
+	if err := s.cotacoes.Salvar(ctx, cotacao); err != nil {
+		return nil, fmt.Errorf("erro ao salvar cotação: %w", err)
+	}
+
+	return cotacao, nil
 }
 
-func (s *ServicoTaxasCambio) LimparCache() error {
-	return s.cache.LimparCache()
+// ExecutarCotacao consome a cotação do usuário se ela ainda for válida e
+// corresponder exatamente à operação pedida
+func (s *ServicoTaxasCambio) ExecutarCotacao(ctx context.Context, id string, userID int, valor moeda.Decimal, moedaOrigem, moedaDestino, tipo string, categoria CategoriaIOF) (*CotacaoTravada, error) {
+	return s.cotacoes.Consumir(ctx, id, userID, func(c *CotacaoTravada) error {
+		return c.Confere(tipo, moedaOrigem, moedaDestino, valor, categoria)
+	})
 }
 
-func (s *ServicoTaxasCambio) ExibirStatusTaxas() {
-	taxas, valido := s.cache.CarregarCache()
-	if !valido {
-		fmt.Println("Nenhuma taxa em cache. Execute uma atualização primeiro.")
-		return
+// DevolverCotacao guarda de novo uma cotação consumida por ExecutarCotacao,
+// para que o usuário possa repetir a operação ao preço travado quando a
+// gravação falhou. Cotações já expiradas não são devolvidas.
+func (s *ServicoTaxasCambio) DevolverCotacao(ctx context.Context, cotacao *CotacaoTravada) error {
+	if cotacao.Expirada(time.Now()) {
+		return nil
 	}
+	if err := s.cotacoes.Salvar(ctx, cotacao); err != nil {
+		return fmt.Errorf("erro ao devolver cotação: %w", err)
+	}
+	return nil
+}
 
-	fmt.Println("\n=== STATUS DAS TAXAS DE CÂMBIO ===")
-	for moedaOrigem, conversoes := range taxas {
+// Saude informa o estado dos circuitos dos provedores de taxas
+func (s *ServicoTaxasCambio) Saude() []SaudeProvedor {
+	return s.cliente.Saude()
+}
+
+// LimparCache descarta as taxas em memória e no cache; a próxima consulta busca na API
+func (s *ServicoTaxasCambio) LimparCache(ctx context.Context) error {
+	s.mu.Lock()
+	s.ultimas = nil
+	s.mu.Unlock()
+
+	if !s.estrategia.usaCache() {
+		return nil
+	}
+	return s.cache.LimparCache(ctx)
+}
+
+// ExibirTaxas imprime a tabela de taxas e as moedas base indisponíveis
+func ExibirTaxas(resultado *ResultadoTaxas) {
+	fmt.Println("\n=== TAXAS DE CÂMBIO ===")
+	if resultado.Obsoleto {
+		fmt.Printf("(obsoletas: obtidas há %s)\n", resultado.Idade().Round(time.Second))
+	}
+
+	for _, moedaOrigem := range ordenarChaves(resultado.Taxas) {
 		fmt.Printf("\n%s:", moedaOrigem)
-		for moedaDestino, taxa := range conversoes {
-			fmt.Printf("  %s: %.4f", moedaDestino, taxa)
+		conversoes := resultado.Taxas[moedaOrigem]
+		for _, moedaDestino := range ordenarChaves(conversoes) {
+			fmt.Printf("  %s: %.4f", moedaDestino, conversoes[moedaDestino])
 		}
 		fmt.Println()
 	}
+
+	for _, moeda := range resultado.MoedasComFalha() {
+		fmt.Printf("\n%s: indisponível (%s)\n", moeda, resultado.Falhas[moeda])
+	}
+}
+
+// ordenarChaves retorna as chaves do mapa em ordem alfabética
+func ordenarChaves[V any](m map[string]V) []string {
+	chaves := make([]string, 0, len(m))
+	for chave := range m {
+		chaves = append(chaves, chave)
+	}
+	sort.Strings(chaves)
+	return chaves
 }
@@ -0,0 +1,101 @@
+package cambio
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// StatusTransacao é a etapa do ciclo de vida de uma transação
+type StatusTransacao string
+
+const (
+	// StatusPendente é o status inicial: a operação foi calculada e aguarda confirmação
+	StatusPendente StatusTransacao = "Pendente"
+	// StatusConcluido indica operação confirmada
+	StatusConcluido StatusTransacao = "Concluído"
+	// StatusCancelado indica operação cancelada antes ou depois da confirmação
+	StatusCancelado StatusTransacao = "Cancelado"
+)
+
+// AtorTransicao identifica quem pede a mudança de status
+type AtorTransicao string
+
+const (
+	// AtorCliente é o dono da transação, agindo pela API
+	AtorCliente AtorTransicao = "cliente"
+	// AtorSistema são os processos internos, como liquidação e estorno
+	AtorSistema AtorTransicao = "sistema"
+)
+
+var (
+	// ErrTransacaoNaoEncontrada indica que não existe transação com o ID pedido
+	ErrTransacaoNaoEncontrada = errors.New("transação não encontrada")
+	// ErrTransicaoInvalida indica uma mudança de status que a máquina de estados não prevê
+	ErrTransicaoInvalida = errors.New("transição de status inválida")
+	// ErrTransicaoNaoPermitida indica uma transição prevista, mas não para este ator
+	ErrTransicaoNaoPermitida = errors.New("transição de status não permitida")
+	// ErrTransacaoNaoEditavel indica alteração de uma transação já confirmada ou cancelada
+	ErrTransacaoNaoEditavel = errors.New("apenas transações pendentes podem ser alteradas")
+)
+
+// Transicao é uma mudança de status prevista e os atores que podem pedi-la
+type Transicao struct {
+	De     StatusTransacao
+	Para   StatusTransacao
+	Atores []AtorTransicao
+}
+
+// transicoes é a máquina de estados das transações. Concluído e Cancelado
+// são finais para o cliente; só o sistema estorna uma operação concluída.
+var transicoes = []Transicao{
+	{De: StatusPendente, Para: StatusConcluido, Atores: []AtorTransicao{AtorCliente, AtorSistema}},
+	{De: StatusPendente, Para: StatusCancelado, Atores: []AtorTransicao{AtorCliente, AtorSistema}},
+	{De: StatusConcluido, Para: StatusCancelado, Atores: []AtorTransicao{AtorSistema}},
+}
+
+// Transicoes retorna as mudanças de status previstas
+func Transicoes() []Transicao {
+	return slices.Clone(transicoes)
+}
+
+// ValidarTransicao confere se o ator pode levar a transação de de para para
+func ValidarTransicao(de, para StatusTransacao, ator AtorTransicao) error {
+	for _, t := range transicoes {
+		if t.De != de || t.Para != para {
+			continue
+		}
+		if !slices.Contains(t.Atores, ator) {
+			return fmt.Errorf("%w: %s não pode levar a transação de %s para %s", ErrTransicaoNaoPermitida, ator, de, para)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: de %s para %s", ErrTransicaoInvalida, de, para)
+}
+
+// Transicionar muda o status da transação, se a transição for permitida ao
+// ator, e registra o momento da confirmação ou do cancelamento
+func (t *Transaction) Transicionar(para StatusTransacao, ator AtorTransicao, em time.Time) error {
+	if err := ValidarTransicao(StatusTransacao(t.Status), para, ator); err != nil {
+		return err
+	}
+
+	t.Status = string(para)
+	switch para {
+	case StatusConcluido:
+		t.ConfirmadaEm = &em
+	case StatusCancelado:
+		t.CanceladaEm = &em
+	}
+	return nil
+}
+
+// Editavel informa se os dados da operação ainda podem ser alterados:
+// apenas transações pendentes são recalculadas
+func (t *Transaction) Editavel() error {
+	if StatusTransacao(t.Status) != StatusPendente {
+		return fmt.Errorf("%w: status atual é %s", ErrTransacaoNaoEditavel, t.Status)
+	}
+	return nil
+}
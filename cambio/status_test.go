@@ -0,0 +1,58 @@
+package cambio
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaquinaDeEstadosTransacao(t *testing.T) {
+	casos := []struct {
+		de, para StatusTransacao
+		ator     AtorTransicao
+		esperado error
+	}{
+		{StatusPendente, StatusConcluido, AtorCliente, nil},
+		{StatusPendente, StatusCancelado, AtorCliente, nil},
+		{StatusPendente, StatusCancelado, AtorSistema, nil},
+		{StatusConcluido, StatusCancelado, AtorSistema, nil},
+		{StatusConcluido, StatusCancelado, AtorCliente, ErrTransicaoNaoPermitida},
+		{StatusCancelado, StatusConcluido, AtorSistema, ErrTransicaoInvalida},
+		{StatusConcluido, StatusPendente, AtorSistema, ErrTransicaoInvalida},
+		{StatusPendente, StatusPendente, AtorCliente, ErrTransicaoInvalida},
+	}
+
+	for _, c := range casos {
+		err := ValidarTransicao(c.de, c.para, c.ator)
+		if c.esperado == nil && err != nil || c.esperado != nil && !errors.Is(err, c.esperado) {
+			t.Errorf("%s -> %s por %s: esperado %v, obtido %v", c.de, c.para, c.ator, c.esperado, err)
+		}
+	}
+}
+
+func TestTransicionarRegistraDatas(t *testing.T) {
+	agora := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+
+	confirmada := &Transaction{Status: string(StatusPendente)}
+	if err := confirmada.Transicionar(StatusConcluido, AtorCliente, agora); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if confirmada.Status != string(StatusConcluido) || confirmada.ConfirmadaEm == nil || !confirmada.ConfirmadaEm.Equal(agora) {
+		t.Errorf("esperada transação concluída em %s, obtido %+v", agora, confirmada)
+	}
+
+	if err := confirmada.Transicionar(StatusCancelado, AtorCliente, agora); !errors.Is(err, ErrTransicaoNaoPermitida) {
+		t.Errorf("cliente não deveria cancelar transação concluída, obtido %v", err)
+	}
+	if confirmada.Status != string(StatusConcluido) || confirmada.CanceladaEm != nil {
+		t.Errorf("transição rejeitada não deveria alterar a transação, obtido %+v", confirmada)
+	}
+
+	estornada := agora.Add(time.Hour)
+	if err := confirmada.Transicionar(StatusCancelado, AtorSistema, estornada); err != nil {
+		t.Fatalf("sistema deveria estornar transação concluída: %v", err)
+	}
+	if confirmada.CanceladaEm == nil || !confirmada.CanceladaEm.Equal(estornada) || !confirmada.ConfirmadaEm.Equal(agora) {
+		t.Errorf("esperadas as duas datas de transição, obtido %+v", confirmada)
+	}
+}
@@ -1,24 +1,40 @@
 package cambio
 
 import (
+	"context"
+	"fmt"
+	"golang-project/moeda"
 	"golang-project/utils"
 	"time"
 )
 
+// VALOR_MAXIMO_TRANSACAO limita o valor de origem aceito (prevenir valores absurdos)
+var VALOR_MAXIMO_TRANSACAO = moeda.NewFromInt(1000000000)
+
 // Transaction representa uma transação de câmbio realizada
 type Transaction struct {
-	ID            int       `json:"id"`
-	UserID        int       `json:"user_id"`
-	DataTransacao time.Time `json:"data_transacao"`
-	Tipo          string    `json:"tipo"`
-	MoedaOrigem   string    `json:"moeda_origem"`
-	MoedaDestino  string    `json:"moeda_destino"`
-	ValorOrigem   float64   `json:"valor_origem"`
-	ValorDestino  float64   `json:"valor_destino"`
-	TaxaCambio    float64   `json:"taxa_cambio"`
-	Status        string    `json:"status"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            int           `json:"id"`
+	UserID        int           `json:"user_id"`
+	DataTransacao time.Time     `json:"data_transacao"`
+	Tipo          string        `json:"tipo"`
+	MoedaOrigem   string        `json:"moeda_origem"`
+	MoedaDestino  string        `json:"moeda_destino"`
+	ValorOrigem   moeda.Decimal `json:"valor_origem"`
+	ValorDestino  moeda.Decimal `json:"valor_destino"`
+	TaxaCambio    moeda.Decimal `json:"taxa_cambio"`
+	TaxaMedia     moeda.Decimal `json:"taxa_media"`
+	Spread        moeda.Decimal `json:"spread"`
+	Comissao      moeda.Decimal `json:"comissao"`
+	CategoriaIOF  string        `json:"categoria_iof"`
+	AliquotaIOF   moeda.Decimal `json:"aliquota_iof"`
+	ValorIOF      moeda.Decimal `json:"valor_iof"`
+	FonteTaxa     string        `json:"fonte_taxa"`
+	Status        string        `json:"status"`
+	// ConfirmadaEm e CanceladaEm registram quando cada transição de status ocorreu
+	ConfirmadaEm *time.Time `json:"confirmada_em,omitempty"`
+	CanceladaEm  *time.Time `json:"cancelada_em,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 // TransactionFilter representa os filtros para buscar transações
@@ -32,14 +48,75 @@ type TransactionFilter struct {
 	Status       string     `json:"status,omitempty"`
 	Limit        int        `json:"limit,omitempty"`
 	Offset       int        `json:"offset,omitempty"`
+	// Ordenacao define a ordem da listagem; vazia, as mais recentes primeiro
+	Ordenacao Ordenacao `json:"-"`
+	// Cursor continua a listagem a partir de uma página anterior, sem OFFSET.
+	// Com Cursor.Anterior, GetAll lê para trás, mas retorna em ordem de exibição.
+	Cursor *Cursor `json:"-"`
 }
 
 // CreateTransactionRequest representa os dados para criar uma nova transação
 type CreateTransactionRequest struct {
-	Tipo         string  `json:"tipo" binding:"required"`
-	MoedaOrigem  string  `json:"moeda_origem" binding:"required"`
-	MoedaDestino string  `json:"moeda_destino" binding:"required"`
-	ValorOrigem  float64 `json:"valor_origem" binding:"required"`
+	Tipo         string        `json:"tipo" binding:"required"`
+	MoedaOrigem  string        `json:"moeda_origem" binding:"required"`
+	MoedaDestino string        `json:"moeda_destino" binding:"required"`
+	ValorOrigem  moeda.Decimal `json:"valor_origem" binding:"required"`
+	// CategoriaIOF define a alíquota de IOF (especie, cartao, remessa, investimento).
+	// Quando omitida, a operação é tratada como compra/venda de moeda em espécie.
+	CategoriaIOF string `json:"categoria_iof,omitempty"`
+	// CotacaoID executa a operação ao preço de uma cotação travada (POST /api/cotacoes).
+	// A operação é rejeitada se a cotação tiver expirado ou for diferente da pedida.
+	CotacaoID string `json:"cotacao_id,omitempty"`
+}
+
+// AtualizarTransacaoRequest altera apenas os campos informados de uma
+// transação pendente (PATCH); os demais são mantidos
+type AtualizarTransacaoRequest struct {
+	Tipo         *string        `json:"tipo,omitempty"`
+	MoedaOrigem  *string        `json:"moeda_origem,omitempty"`
+	MoedaDestino *string        `json:"moeda_destino,omitempty"`
+	ValorOrigem  *moeda.Decimal `json:"valor_origem,omitempty"`
+	CategoriaIOF *string        `json:"categoria_iof,omitempty"`
+	CotacaoID    string         `json:"cotacao_id,omitempty"`
+}
+
+// RequisicaoDaTransacao reconstrói o pedido que gera a transação, base para
+// recalculá-la após uma alteração parcial
+func RequisicaoDaTransacao(t *Transaction) CreateTransactionRequest {
+	return CreateTransactionRequest{
+		Tipo:         t.Tipo,
+		MoedaOrigem:  t.MoedaOrigem,
+		MoedaDestino: t.MoedaDestino,
+		ValorOrigem:  t.ValorOrigem,
+		CategoriaIOF: t.CategoriaIOF,
+	}
+}
+
+// Aplicar retorna base com os campos informados substituídos
+func (r *AtualizarTransacaoRequest) Aplicar(base CreateTransactionRequest) CreateTransactionRequest {
+	if r.Tipo != nil {
+		base.Tipo = *r.Tipo
+	}
+	if r.MoedaOrigem != nil {
+		base.MoedaOrigem = *r.MoedaOrigem
+	}
+	if r.MoedaDestino != nil {
+		base.MoedaDestino = *r.MoedaDestino
+	}
+	if r.ValorOrigem != nil {
+		base.ValorOrigem = *r.ValorOrigem
+	}
+	if r.CategoriaIOF != nil {
+		base.CategoriaIOF = *r.CategoriaIOF
+	}
+	base.CotacaoID = r.CotacaoID
+	return base
+}
+
+// ResumoTransacoes reúne totais de um conjunto de transações
+type ResumoTransacoes struct {
+	Quantidade int           `json:"quantidade"`
+	TotalIOF   moeda.Decimal `json:"total_iof"`
 }
 
 // Validate valida os campos da requisição de criação de transação
@@ -75,7 +152,7 @@ func (r *CreateTransactionRequest) Validate() error {
 	} else if !utils.IsValidCurrency(r.MoedaOrigem) {
 		errs = append(errs, utils.ValidationError{
 			Field:   "moeda_origem",
-			Message: "moeda inválida (use: USD, EUR, BRL, GBP, JPY)",
+			Message: utils.InvalidCurrencyMessage(),
 		})
 	}
 
@@ -88,12 +165,12 @@ func (r *CreateTransactionRequest) Validate() error {
 	} else if !utils.IsValidCurrency(r.MoedaDestino) {
 		errs = append(errs, utils.ValidationError{
 			Field:   "moeda_destino",
-			Message: "moeda inválida (use: USD, EUR, BRL, GBP, JPY)",
+			Message: utils.InvalidCurrencyMessage(),
 		})
 	}
 
 	// Validar valor
-	if !utils.IsPositive(r.ValorOrigem) {
+	if !r.ValorOrigem.IsPositive() {
 		errs = append(errs, utils.ValidationError{
 			Field:   "valor_origem",
 			Message: "deve ser maior que zero",
@@ -101,25 +178,55 @@ func (r *CreateTransactionRequest) Validate() error {
 	}
 
 	// Validar limite máximo (prevenir valores absurdos)
-	if r.ValorOrigem > 1000000000 { // 1 bilhão
+	if r.ValorOrigem.Cmp(VALOR_MAXIMO_TRANSACAO) > 0 { // 1 bilhão
 		errs = append(errs, utils.ValidationError{
 			Field:   "valor_origem",
 			Message: "valor muito alto (máximo: 1.000.000.000)",
 		})
 	}
 
+	// Validar categoria de IOF
+	if r.CategoriaIOF != "" && !CategoriaIOF(r.CategoriaIOF).Valida() {
+		errs = append(errs, utils.ValidationError{
+			Field:   "categoria_iof",
+			Message: "deve ser: especie, cartao, remessa ou investimento",
+		})
+	}
+
+	// Validar casas decimais da moeda de origem (ex.: JPY não tem centavos)
+	if m, existe := moeda.Padrao().Obter(r.MoedaOrigem); existe && r.ValorOrigem.CasasDecimais() > int32(m.CasasDecimais) {
+		errs = append(errs, utils.ValidationError{
+			Field:   "valor_origem",
+			Message: fmt.Sprintf("%s aceita no máximo %d casas decimais", m.Codigo, m.CasasDecimais),
+		})
+	}
+
 	if len(errs) > 0 {
 		return errs
 	}
 	return nil
 }
 
-// TransactionRepository define a interface para operações de transações
+// TransactionRepository define a interface para operações de transações.
+// Create, Update, AtualizarStatus e Delete registram a alteração na trilha
+// de auditoria, em nome do autor anexado ao contexto por ComAutoria.
 type TransactionRepository interface {
-	Create(transaction *Transaction) error
-	GetByID(id int) (*Transaction, error)
-	GetAll(filter TransactionFilter) ([]Transaction, error)
-	Update(transaction *Transaction) error
-	Delete(id int) error
-	GetTotalCount(filter TransactionFilter) (int, error)
+	Create(ctx context.Context, transaction *Transaction) error
+	GetByID(ctx context.Context, id int) (*Transaction, error)
+	GetAll(ctx context.Context, filter TransactionFilter) ([]Transaction, error)
+	// Update grava os dados de uma transação pendente, exceto o status e suas
+	// datas; retorna ErrTransacaoNaoEditavel se ela não estiver mais pendente
+	Update(ctx context.Context, transaction *Transaction) error
+	// AtualizarStatus grava o status e as datas de transição da transação,
+	// desde que o status gravado ainda seja de; caso contrário retorna
+	// ErrTransicaoInvalida
+	AtualizarStatus(ctx context.Context, transaction *Transaction, de StatusTransacao) error
+	// Delete exclui a transação logicamente; ela some das consultas, mas
+	// continua gravada para auditoria
+	Delete(ctx context.Context, id int) error
+	GetTotalCount(ctx context.Context, filter TransactionFilter) (int, error)
+	GetResumo(ctx context.Context, filter TransactionFilter) (*ResumoTransacoes, error)
+	// GetAuditoria retorna as alterações registradas da transação, da mais
+	// antiga para a mais recente, inclusive as de transações excluídas
+	GetAuditoria(ctx context.Context, transacaoID int) ([]RegistroAuditoria, error)
 }
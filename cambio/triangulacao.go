@@ -0,0 +1,114 @@
+package cambio
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Divergencia registra uma cotação derivada que difere da cotação direta
+// além da tolerância configurada
+type Divergencia struct {
+	Base                string  `json:"base"`
+	Cotacao             string  `json:"cotacao"`
+	Derivada            float64 `json:"derivada"`
+	Direta              float64 `json:"direta"`
+	DiferencaPercentual float64 `json:"diferenca_percentual"`
+}
+
+// DerivarTaxasCruzadas calcula a matriz completa de taxas a partir das
+// cotações de uma única moeda pivô: taxa(A->B) = pivo(B) / pivo(A)
+func DerivarTaxasCruzadas(pivo string, taxasPivo map[string]float64, moedas []string) *ResultadoTaxas {
+	resultado := NewResultadoTaxas()
+
+	paraPivo := make(map[string]float64, len(taxasPivo)+1)
+	for moeda, taxa := range taxasPivo {
+		paraPivo[moeda] = taxa
+	}
+	paraPivo[pivo] = 1
+
+	for _, origem := range moedas {
+		taxaOrigem, existe := paraPivo[origem]
+		if !existe || taxaOrigem <= 0 {
+			resultado.Falhas[origem] = fmt.Sprintf("cotação %s->%s indisponível para triangulação", pivo, origem)
+			continue
+		}
+
+		taxas := make(map[string]float64)
+		for _, destino := range moedas {
+			if destino == origem {
+				continue
+			}
+			if taxaDestino, existe := paraPivo[destino]; existe {
+				taxas[destino] = taxaDestino / taxaOrigem
+			}
+		}
+		resultado.Taxas[origem] = taxas
+	}
+
+	return resultado
+}
+
+// VerificarConsistencia compara as cotações derivadas de uma base com as
+// cotações diretas da mesma base. A tolerância é percentual (0.5 = 0,5%).
+func VerificarConsistencia(base string, derivadas, diretas map[string]float64, tolerancia float64) []Divergencia {
+	var divergencias []Divergencia
+	for moeda, direta := range diretas {
+		derivada, existe := derivadas[moeda]
+		if !existe || direta == 0 {
+			continue
+		}
+
+		diferenca := math.Abs(derivada-direta) / direta * 100
+		if diferenca > tolerancia {
+			divergencias = append(divergencias, Divergencia{
+				Base:                base,
+				Cotacao:             moeda,
+				Derivada:            derivada,
+				Direta:              direta,
+				DiferencaPercentual: diferenca,
+			})
+		}
+	}
+	return divergencias
+}
+
+// buscarPorTriangulacao busca apenas a moeda pivô e deriva as demais
+func (c *CambioClient) buscarPorTriangulacao(ctx context.Context, moedas []string) *ResultadoTaxas {
+	fmt.Printf("Buscando taxas para %s (pivô de triangulação)...\n", c.pivo)
+
+	cotacoesPivo, err := c.provedor.BuscarTaxas(ctx, c.pivo)
+	if err != nil {
+		resultado := NewResultadoTaxas()
+		for _, moeda := range moedas {
+			resultado.Falhas[moeda] = fmt.Sprintf("erro ao buscar pivô %s: %v", c.pivo, err)
+		}
+		return resultado
+	}
+
+	resultado := DerivarTaxasCruzadas(c.pivo, cotacoesPivo.Taxas, moedas)
+	for base := range resultado.Taxas {
+		resultado.Fontes[base] = cotacoesPivo.Fonte
+	}
+
+	for _, base := range c.basesVerificacao {
+		if base == c.pivo {
+			continue
+		}
+
+		diretas, err := c.BuscarTaxasCambio(ctx, base)
+		if err != nil {
+			fmt.Printf("Aviso: não foi possível verificar consistência de %s: %v\n", base, err)
+			continue
+		}
+
+		divergencias := VerificarConsistencia(base, resultado.Taxas[base], diretas, c.toleranciaConsistencia)
+		for _, d := range divergencias {
+			fmt.Printf("Aviso: cotação derivada %s->%s (%.6f) difere da direta (%.6f) em %.2f%%\n",
+				d.Base, d.Cotacao, d.Derivada, d.Direta, d.DiferencaPercentual)
+		}
+		resultado.Divergencias = append(resultado.Divergencias, divergencias...)
+	}
+
+	return resultado
+}
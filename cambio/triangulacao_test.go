@@ -0,0 +1,63 @@
+package cambio
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDerivarTaxasCruzadas(t *testing.T) {
+	resultado := DerivarTaxasCruzadas("USD", map[string]float64{"BRL": 5.0, "EUR": 0.8}, []string{"USD", "BRL", "EUR", "JPY"})
+
+	if math.Abs(resultado.Taxas["EUR"]["BRL"]-6.25) > 1e-9 {
+		t.Errorf("taxa EUR->BRL esperada 6.25, obtida %v", resultado.Taxas["EUR"]["BRL"])
+	}
+	if math.Abs(resultado.Taxas["BRL"]["USD"]-0.2) > 1e-9 {
+		t.Errorf("taxa BRL->USD esperada 0.2, obtida %v", resultado.Taxas["BRL"]["USD"])
+	}
+	if _, existe := resultado.Falhas["JPY"]; !existe {
+		t.Error("JPY sem cotação no pivô deveria ser registrado como falha")
+	}
+}
+
+func TestVerificarConsistencia(t *testing.T) {
+	derivadas := map[string]float64{"BRL": 6.25, "USD": 1.25}
+	diretas := map[string]float64{"BRL": 6.0, "USD": 1.2501}
+
+	divergencias := VerificarConsistencia("EUR", derivadas, diretas, 1.0)
+	if len(divergencias) != 1 || divergencias[0].Cotacao != "BRL" {
+		t.Errorf("esperada apenas divergência EUR->BRL, obtido %+v", divergencias)
+	}
+}
+
+type provedorContador struct {
+	chamadas atomic.Int32
+	taxas    map[string]map[string]float64
+}
+
+func (p *provedorContador) Nome() string { return "contador" }
+
+func (p *provedorContador) BuscarTaxas(ctx context.Context, moedaBase string) (*Cotacoes, error) {
+	p.chamadas.Add(1)
+	return NewStaticProvider(p.taxas).BuscarTaxas(ctx, moedaBase)
+}
+
+func TestTriangulacaoFazUmaUnicaBusca(t *testing.T) {
+	provedor := &provedorContador{taxas: map[string]map[string]float64{
+		"USD": {"EUR": 0.9, "BRL": 5.4, "GBP": 0.8, "JPY": 150},
+	}}
+	cliente := NewCambioClientComProvedor(provedor)
+	cliente.UsarTriangulacao("USD", 0.5)
+
+	resultado, err := cliente.BuscarTaxasParaTodasMoedas(context.Background())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !resultado.Completo() {
+		t.Errorf("resultado deveria estar completo, falhas: %v", resultado.Falhas)
+	}
+	if n := provedor.chamadas.Load(); n != 1 {
+		t.Errorf("esperada 1 chamada ao provedor, obtidas %d", n)
+	}
+}
@@ -0,0 +1,122 @@
+package cambio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// TOLERANCIA_INVERSA_PADRAO é a diferença percentual aceita entre A->B × B->A e 1
+	TOLERANCIA_INVERSA_PADRAO = 2.0
+	// VARIACAO_MAXIMA_PADRAO é a variação percentual aceita em relação às últimas taxas válidas
+	VARIACAO_MAXIMA_PADRAO = 10.0
+)
+
+// Tipos de anomalia encontrados pelo ValidadorTaxas
+const (
+	AnomaliaTaxaInvalida         = "taxa_invalida"
+	AnomaliaInversaInconsistente = "inversa_inconsistente"
+	AnomaliaVariacaoExcessiva    = "variacao_excessiva"
+)
+
+// ErrTaxasAnomalas indica que as taxas buscadas foram rejeitadas e não há
+// taxas válidas anteriores para manter em uso
+var ErrTaxasAnomalas = errors.New("taxas rejeitadas pela validação")
+
+// AnomaliaTaxa descreve uma cotação rejeitada. Referencia é o produto com a
+// cotação inversa ou a taxa anterior, conforme o tipo.
+type AnomaliaTaxa struct {
+	Tipo       string  `json:"tipo"`
+	Origem     string  `json:"origem"`
+	Destino    string  `json:"destino"`
+	Taxa       float64 `json:"taxa"`
+	Referencia float64 `json:"referencia,omitempty"`
+	Mensagem   string  `json:"mensagem"`
+}
+
+// AlertaTaxas é emitido quando uma busca é rejeitada. MantidasDesde é o
+// momento da busca das taxas que continuam em uso (zero se não houver nenhuma).
+type AlertaTaxas struct {
+	Momento       time.Time      `json:"momento"`
+	Anomalias     []AnomaliaTaxa `json:"anomalias"`
+	MantidasDesde time.Time      `json:"mantidas_desde"`
+}
+
+// ValidadorTaxas confere as taxas de uma busca antes de elas entrarem em uso.
+// Percentuais como 2.0 = 2%; variacaoMaxima zero desativa a comparação com as
+// taxas anteriores.
+type ValidadorTaxas struct {
+	toleranciaInversa float64
+	variacaoMaxima    float64
+}
+
+// NewValidadorTaxas cria um validador com as tolerâncias informadas
+func NewValidadorTaxas(toleranciaInversa, variacaoMaxima float64) *ValidadorTaxas {
+	return &ValidadorTaxas{
+		toleranciaInversa: toleranciaInversa,
+		variacaoMaxima:    variacaoMaxima,
+	}
+}
+
+// Validar retorna as anomalias de novo, comparando-o com anterior (as
+// últimas taxas válidas) quando informado. Bases que anterior obteve da tabela
+// de fallback não entram na comparação. Sem anomalias, retorna nil.
+func (v *ValidadorTaxas) Validar(novo, anterior *ResultadoTaxas) []AnomaliaTaxa {
+	var anomalias []AnomaliaTaxa
+
+	for _, origem := range ordenarChaves(novo.Taxas) {
+		taxas := novo.Taxas[origem]
+		for _, destino := range ordenarChaves(taxas) {
+			taxa := taxas[destino]
+
+			if math.IsNaN(taxa) || math.IsInf(taxa, 0) || taxa <= 0 {
+				anomalias = append(anomalias, AnomaliaTaxa{
+					Tipo:     AnomaliaTaxaInvalida,
+					Origem:   origem,
+					Destino:  destino,
+					Taxa:     taxa,
+					Mensagem: fmt.Sprintf("taxa %s->%s deve ser positiva, recebida %v", origem, destino, taxa),
+				})
+				continue
+			}
+
+			// Cada par é conferido uma vez, a partir da origem em ordem alfabética
+			if inversa, existe := novo.Taxas[destino][origem]; existe && origem < destino && inversa > 0 {
+				produto := taxa * inversa
+				if math.Abs(produto-1)*100 > v.toleranciaInversa {
+					anomalias = append(anomalias, AnomaliaTaxa{
+						Tipo:       AnomaliaInversaInconsistente,
+						Origem:     origem,
+						Destino:    destino,
+						Taxa:       taxa,
+						Referencia: produto,
+						Mensagem: fmt.Sprintf("%s->%s (%v) × %s->%s (%v) = %.6f, esperado ≈ 1",
+							origem, destino, taxa, destino, origem, inversa, produto),
+					})
+				}
+			}
+
+			if anterior == nil || v.variacaoMaxima <= 0 || ehFonteFallback(anterior.Fontes[origem]) {
+				continue
+			}
+			if taxaAnterior, existe := anterior.Taxas[origem][destino]; existe && taxaAnterior > 0 {
+				variacao := math.Abs(taxa-taxaAnterior) / taxaAnterior * 100
+				if variacao > v.variacaoMaxima {
+					anomalias = append(anomalias, AnomaliaTaxa{
+						Tipo:       AnomaliaVariacaoExcessiva,
+						Origem:     origem,
+						Destino:    destino,
+						Taxa:       taxa,
+						Referencia: taxaAnterior,
+						Mensagem: fmt.Sprintf("%s->%s variou %.2f%% (de %v para %v), acima do limite de %.2f%%",
+							origem, destino, variacao, taxaAnterior, taxa, v.variacaoMaxima),
+					})
+				}
+			}
+		}
+	}
+
+	return anomalias
+}
@@ -0,0 +1,170 @@
+package cambio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidarRejeitaTaxasNaoPositivas(t *testing.T) {
+	v := NewValidadorTaxas(TOLERANCIA_INVERSA_PADRAO, VARIACAO_MAXIMA_PADRAO)
+	novo := &ResultadoTaxas{Taxas: map[string]map[string]float64{
+		"USD": {"BRL": 0, "EUR": -0.9, "GBP": math.NaN(), "JPY": 150},
+	}}
+
+	anomalias := v.Validar(novo, nil)
+	if len(anomalias) != 3 {
+		t.Fatalf("esperadas 3 anomalias, obtidas %+v", anomalias)
+	}
+	for _, a := range anomalias {
+		if a.Tipo != AnomaliaTaxaInvalida || a.Destino == "JPY" {
+			t.Errorf("anomalia inesperada: %+v", a)
+		}
+	}
+}
+
+func TestValidarConfereInversas(t *testing.T) {
+	v := NewValidadorTaxas(2, 0)
+
+	consistente := &ResultadoTaxas{Taxas: map[string]map[string]float64{
+		"USD": {"BRL": 5.0},
+		"BRL": {"USD": 0.2005},
+	}}
+	if anomalias := v.Validar(consistente, nil); len(anomalias) != 0 {
+		t.Errorf("inversas consistentes não deveriam gerar anomalia: %+v", anomalias)
+	}
+
+	inconsistente := &ResultadoTaxas{Taxas: map[string]map[string]float64{
+		"USD": {"BRL": 5.0},
+		"BRL": {"USD": 0.5},
+	}}
+	anomalias := v.Validar(inconsistente, nil)
+	if len(anomalias) != 1 || anomalias[0].Tipo != AnomaliaInversaInconsistente {
+		t.Fatalf("esperada 1 anomalia de inversa, obtido %+v", anomalias)
+	}
+	if math.Abs(anomalias[0].Referencia-2.5) > 1e-9 {
+		t.Errorf("produto esperado 2.5, obtido %v", anomalias[0].Referencia)
+	}
+}
+
+func TestValidarVariacaoEmRelacaoAoAnterior(t *testing.T) {
+	v := NewValidadorTaxas(2, 10)
+	anterior := &ResultadoTaxas{Taxas: map[string]map[string]float64{"USD": {"BRL": 5.0, "EUR": 0.9}}}
+	novo := &ResultadoTaxas{Taxas: map[string]map[string]float64{"USD": {"BRL": 5.4, "EUR": 1.2}}}
+
+	anomalias := v.Validar(novo, anterior)
+	if len(anomalias) != 1 || anomalias[0].Tipo != AnomaliaVariacaoExcessiva || anomalias[0].Destino != "EUR" {
+		t.Fatalf("esperada variação excessiva apenas em EUR, obtido %+v", anomalias)
+	}
+
+	if anomalias := NewValidadorTaxas(2, 0).Validar(novo, anterior); len(anomalias) != 0 {
+		t.Errorf("variação máxima zero deveria desativar a comparação: %+v", anomalias)
+	}
+}
+
+func TestServicoMantemUltimasTaxasValidas(t *testing.T) {
+	provedor := &provedorContador{taxas: taxasUSD()}
+	servico := novoServicoTeste(provedor, NewCacheMemoria())
+	defer servico.Parar(context.Background())
+
+	var mu sync.Mutex
+	var alertas []AlertaTaxas
+	servico.UsarAlertas(func(a AlertaTaxas) {
+		mu.Lock()
+		alertas = append(alertas, a)
+		mu.Unlock()
+	})
+
+	if _, err := servico.ForcarAtualizacao(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// A API passa a devolver um valor absurdo
+	provedor.taxas = map[string]map[string]float64{"USD": {"EUR": 0.9, "BRL": 54, "GBP": 0.8, "JPY": 150}}
+	resultado, err := servico.atualizar(context.Background())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if resultado.Taxas["USD"]["BRL"] != 5.4 {
+		t.Errorf("taxa válida anterior deveria continuar em uso, obtida %v", resultado.Taxas["USD"]["BRL"])
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(alertas) != 1 || alertas[0].Anomalias[0].Tipo != AnomaliaVariacaoExcessiva || alertas[0].MantidasDesde.IsZero() {
+		t.Errorf("esperado 1 alerta de variação excessiva, obtido %+v", alertas)
+	}
+}
+
+func TestAtualizacaoForcadaAceitaVariacao(t *testing.T) {
+	provedor := &provedorContador{taxas: taxasUSD()}
+	servico := novoServicoTeste(provedor, NewCacheMemoria())
+	servico.UsarAlertas(nil)
+	defer servico.Parar(context.Background())
+
+	if _, err := servico.ForcarAtualizacao(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// Um movimento real acima do limite é aceito quando a atualização é forçada
+	provedor.taxas = map[string]map[string]float64{"USD": {"EUR": 0.9, "BRL": 6.5, "GBP": 0.8, "JPY": 150}}
+	resultado, err := servico.ForcarAtualizacao(context.Background())
+	if err != nil || resultado.Taxas["USD"]["BRL"] != 6.5 {
+		t.Errorf("atualização forçada deveria aceitar a nova taxa, obtido %+v (erro: %v)", resultado, err)
+	}
+
+	// Mas não taxas inválidas
+	provedor.taxas = map[string]map[string]float64{"USD": {"EUR": 0.9, "BRL": 0, "GBP": 0.8, "JPY": 150}}
+	resultado, _ = servico.ForcarAtualizacao(context.Background())
+	if resultado == nil || resultado.Taxas["USD"]["BRL"] != 6.5 {
+		t.Errorf("taxa inválida não deveria ser aceita, obtido %+v", resultado)
+	}
+}
+
+func TestVariacaoIgnoraReferenciaAntiga(t *testing.T) {
+	// Taxas de três dias atrás, além da validade e do período obsoleto
+	armazenamento := NewCacheMemoria()
+	antigo := fmt.Sprintf(`{"timestamp": %d, "taxas_cambio": {"USD": {"BRL": 5.0}}}`, time.Now().Add(-72*time.Hour).Unix())
+	armazenamento.Gravar(context.Background(), []byte(antigo))
+
+	provedor := &provedorContador{taxas: map[string]map[string]float64{"USD": {"EUR": 0.9, "BRL": 6.5, "GBP": 0.8, "JPY": 150}}}
+	servico := novoServicoTeste(provedor, armazenamento)
+	servico.UsarValidador(NewValidadorTaxas(2, 10))
+	servico.UsarAlertas(func(a AlertaTaxas) { t.Errorf("alerta inesperado: %+v", a) })
+	defer servico.Parar(context.Background())
+
+	resultado, err := servico.ObterTaxas(context.Background())
+	if err != nil || resultado.Obsoleto || resultado.Taxas["USD"]["BRL"] != 6.5 {
+		t.Errorf("taxas atuais deveriam ser aceitas, obtido %+v (erro: %v)", resultado, err)
+	}
+}
+
+func TestValidarIgnoraReferenciaDoFallback(t *testing.T) {
+	v := NewValidadorTaxas(2, 10)
+	anterior := &ResultadoTaxas{
+		Taxas:  map[string]map[string]float64{"USD": {"BRL": 5.0}, "EUR": {"BRL": 5.5}},
+		Fontes: map[string]string{"USD": NOME_PROVEDOR_FALLBACK + ":2025-01", "EUR": "fxratesapi"},
+	}
+	novo := &ResultadoTaxas{Taxas: map[string]map[string]float64{"USD": {"BRL": 6.5}, "EUR": {"BRL": 7.0}}}
+
+	anomalias := v.Validar(novo, anterior)
+	if len(anomalias) != 1 || anomalias[0].Origem != "EUR" {
+		t.Errorf("esperada variação excessiva apenas em EUR, obtido %+v", anomalias)
+	}
+}
+
+func TestServicoSemTaxasAnterioresRetornaErro(t *testing.T) {
+	provedor := &provedorContador{taxas: map[string]map[string]float64{"USD": {"BRL": 0, "EUR": 0.9}}}
+	servico := novoServicoTeste(provedor, NewCacheMemoria())
+	servico.UsarAlertas(nil)
+	defer servico.Parar(context.Background())
+
+	_, err := servico.ObterTaxas(context.Background())
+	if !errors.Is(err, ErrTaxasAnomalas) {
+		t.Errorf("esperado ErrTaxasAnomalas, obtido %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"log"
+	"net/url"
+	"strings"
+
+	"golang-project/cambio"
+)
+
+// OpcoesCliente traduz a configuração de conexão com os provedores (chave de
+// API, URLs, proxy, timeout e resiliência), para que o servidor e as
+// ferramentas de linha de comando montem o cliente da mesma forma. Valores
+// inválidos são registrados e ignorados.
+func (c *Config) OpcoesCliente() []cambio.OpcaoCliente {
+	opcoes := []cambio.OpcaoCliente{
+		cambio.ComTimeout(c.Timeout),
+		cambio.ComResiliencia(c.Resiliencia()),
+	}
+
+	if c.UserAgent != "" {
+		opcoes = append(opcoes, cambio.ComUserAgent(c.UserAgent))
+	}
+
+	if c.Proxy != "" {
+		proxy, err := url.Parse(c.Proxy)
+		if err != nil || proxy.Host == "" {
+			log.Printf("CAMBIO_PROXY inválido, conectando sem proxy: %q\n", c.Proxy)
+		} else {
+			opcoes = append(opcoes, cambio.ComProxy(proxy))
+		}
+	}
+
+	for _, par := range c.URLsProvedores {
+		nome, endereco, ok := strings.Cut(par, "=")
+		if !ok || strings.TrimSpace(nome) == "" || strings.TrimSpace(endereco) == "" {
+			log.Printf("CAMBIO_API_URLS: entrada inválida %q, esperado nome=url\n", par)
+			continue
+		}
+		opcoes = append(opcoes, cambio.ComURLBase(nome, strings.TrimSpace(endereco)))
+	}
+
+	if c.ChaveAPI != "" {
+		provedor := c.ChaveAPIProvedor
+		if provedor == "" && len(c.Provedores) > 0 {
+			provedor = c.Provedores[0]
+		}
+		opcoes = append(opcoes, cambio.ComChaveAPI(provedor, cambio.ChaveAPI{
+			Valor:     c.ChaveAPI,
+			Cabecalho: c.ChaveAPICabecalho,
+			Parametro: c.ChaveAPIParametro,
+		}))
+	}
+
+	return opcoes
+}
+
+// Resiliencia monta as proteções dos provedores a partir da configuração
+func (c *Config) Resiliencia() cambio.Resiliencia {
+	return cambio.Resiliencia{
+		Retentativa: cambio.PoliticaRetentativa{
+			Tentativas:    c.Tentativas,
+			EsperaInicial: c.EsperaInicial,
+			EsperaMaxima:  c.EsperaMaxima,
+		},
+		RequisicoesPorSegundo: c.RequisicoesPorSegundo,
+		Rajada:                c.Rajada,
+		FalhasCircuito:        c.FalhasCircuito,
+		TempoCircuitoAberto:   c.TempoCircuitoAberto,
+	}
+}
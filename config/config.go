@@ -0,0 +1,223 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config reúne as configurações da aplicação lidas de variáveis de ambiente
+type Config struct {
+	// Provedores lista, em ordem de prioridade, as fontes de taxas (CAMBIO_PROVEDORES)
+	Provedores []string
+	// ArquivoTaxasFallback é a tabela versionada usada como último recurso (CAMBIO_TAXAS_FALLBACK);
+	// por padrão, taxas_fallback.json se existir no diretório atual
+	ArquivoTaxasFallback string
+
+	// Timeout limita cada requisição a um provedor (CAMBIO_TIMEOUT)
+	Timeout time.Duration
+	// UserAgent é enviado nas requisições aos provedores (CAMBIO_USER_AGENT)
+	UserAgent string
+	// Proxy é o endereço do proxy HTTP usado para chegar aos provedores (CAMBIO_PROXY)
+	Proxy string
+	// URLsProvedores troca o endereço de provedores, no formato nome=url (CAMBIO_API_URLS)
+	URLsProvedores []string
+	// ChaveAPI autentica as requisições a ChaveAPIProvedor (CAMBIO_API_CHAVE)
+	ChaveAPI string
+	// ChaveAPIProvedor recebe a chave; padrão é o primeiro provedor (CAMBIO_API_CHAVE_PROVEDOR)
+	ChaveAPIProvedor string
+	// ChaveAPICabecalho é o cabeçalho da chave (CAMBIO_API_CHAVE_CABECALHO)
+	ChaveAPICabecalho string
+	// ChaveAPIParametro envia a chave como parâmetro de consulta em vez de cabeçalho (CAMBIO_API_CHAVE_PARAMETRO)
+	ChaveAPIParametro string
+
+	// Tentativas é o total de tentativas por busca em um provedor, incluindo a primeira (CAMBIO_TENTATIVAS)
+	Tentativas int
+	// EsperaInicial é a espera antes da segunda tentativa; dobra a cada nova falha (CAMBIO_TENTATIVAS_ESPERA)
+	EsperaInicial time.Duration
+	// EsperaMaxima limita a espera entre tentativas e o Retry-After respeitado (CAMBIO_TENTATIVAS_ESPERA_MAXIMA)
+	EsperaMaxima time.Duration
+	// RequisicoesPorSegundo é a cota de cada provedor; 0 desativa o limite (CAMBIO_LIMITE_REQUISICOES)
+	RequisicoesPorSegundo float64
+	// Rajada é quantas requisições podem ser feitas de uma vez dentro da cota (CAMBIO_LIMITE_RAJADA)
+	Rajada int
+	// FalhasCircuito é quantas falhas seguidas suspendem um provedor; 0 desativa (CAMBIO_CIRCUITO_FALHAS)
+	FalhasCircuito int
+	// TempoCircuitoAberto é por quanto tempo um provedor fica suspenso (CAMBIO_CIRCUITO_ABERTO)
+	TempoCircuitoAberto time.Duration
+
+	// Pivo ativa a triangulação a partir de uma única moeda (CAMBIO_PIVO)
+	Pivo string
+	// BasesVerificacao são buscadas diretamente para conferir as taxas derivadas (CAMBIO_VERIFICAR_BASES)
+	BasesVerificacao []string
+	// ToleranciaConsistencia é a diferença percentual aceita entre derivada e direta (CAMBIO_TOLERANCIA_CONSISTENCIA)
+	ToleranciaConsistencia float64
+
+	// ToleranciaInversa é a diferença percentual aceita entre A->B × B->A e 1 (CAMBIO_VALIDACAO_TOLERANCIA_INVERSA)
+	ToleranciaInversa float64
+	// VariacaoMaxima é a variação percentual aceita em relação às últimas taxas válidas;
+	// 0 desativa a comparação (CAMBIO_VALIDACAO_VARIACAO_MAXIMA)
+	VariacaoMaxima float64
+
+	// ArquivoMoedas define o registro de moedas a partir de um JSON (CAMBIO_MOEDAS_ARQUIVO).
+	// Sem arquivo, o registro é lido da tabela moedas, se existir.
+	ArquivoMoedas string
+
+	// ArquivoPrecos define spreads e comissões por par e tipo de operação (CAMBIO_PRECOS_ARQUIVO).
+	// Sem arquivo, as operações são feitas à taxa média, sem comissão.
+	ArquivoPrecos string
+
+	// ArquivoIOF substitui a tabela de alíquotas de IOF embutida (CAMBIO_IOF_ARQUIVO)
+	ArquivoIOF string
+
+	// Cache seleciona onde as taxas ficam guardadas: arquivo, memoria, postgres ou redis (CAMBIO_CACHE)
+	Cache string
+	// ArquivoCache é o caminho usado por Cache=arquivo (CAMBIO_CACHE_ARQUIVO)
+	ArquivoCache string
+	// URLCacheRedis é o servidor usado por Cache=redis, como redis://:senha@host:6379/0 (CAMBIO_CACHE_REDIS_URL)
+	URLCacheRedis string
+	// ChaveCache identifica as taxas nos caches compartilhados (CAMBIO_CACHE_CHAVE)
+	ChaveCache string
+	// ValidadeCache é por quanto tempo as taxas em cache são usadas sem nova busca (CAMBIO_CACHE_VALIDADE)
+	ValidadeCache time.Duration
+	// MaximoObsoleto é por quanto tempo após vencer o cache ainda é servido enquanto
+	// as taxas são renovadas; 0 faz as requisições aguardarem a API (CAMBIO_CACHE_MAXIMO_OBSOLETO)
+	MaximoObsoleto time.Duration
+	// Carga é a estratégia de busca das taxas: antecipada, sob_demanda, cache ou periodica (CAMBIO_CARGA)
+	Carga string
+	// AntecedenciaAtualizacao renova as taxas em segundo plano esse tempo antes de o
+	// cache vencer, com Carga periodica; 0 renova no vencimento (CAMBIO_ATUALIZACAO_ANTECEDENCIA)
+	AntecedenciaAtualizacao time.Duration
+
+	// ValidadeCotacao é por quanto tempo uma cotação travada pode ser executada (CAMBIO_COTACAO_VALIDADE)
+	ValidadeCotacao time.Duration
+
+	// RetencaoIdempotencia é por quanto tempo a resposta de um POST /api/transacoes com
+	// Idempotency-Key é repetida para a mesma chave (CAMBIO_IDEMPOTENCIA_RETENCAO)
+	RetencaoIdempotencia time.Duration
+}
+
+// Carregar lê a configuração do ambiente, aplicando valores padrão
+func Carregar() *Config {
+	return &Config{
+		Provedores:           lista(os.Getenv("CAMBIO_PROVEDORES"), []string{"fxratesapi", "exchangerate-api", "ecb"}),
+		ArquivoTaxasFallback: arquivoOpcional(os.Getenv("CAMBIO_TAXAS_FALLBACK"), "taxas_fallback.json"),
+
+		Timeout:           duracao(os.Getenv("CAMBIO_TIMEOUT"), 15*time.Second),
+		UserAgent:         strings.TrimSpace(os.Getenv("CAMBIO_USER_AGENT")),
+		Proxy:             strings.TrimSpace(os.Getenv("CAMBIO_PROXY")),
+		URLsProvedores:    lista(os.Getenv("CAMBIO_API_URLS"), nil),
+		ChaveAPI:          strings.TrimSpace(os.Getenv("CAMBIO_API_CHAVE")),
+		ChaveAPIProvedor:  strings.TrimSpace(os.Getenv("CAMBIO_API_CHAVE_PROVEDOR")),
+		ChaveAPICabecalho: strings.TrimSpace(os.Getenv("CAMBIO_API_CHAVE_CABECALHO")),
+		ChaveAPIParametro: strings.TrimSpace(os.Getenv("CAMBIO_API_CHAVE_PARAMETRO")),
+
+		Tentativas:            inteiro(os.Getenv("CAMBIO_TENTATIVAS"), 3),
+		EsperaInicial:         duracao(os.Getenv("CAMBIO_TENTATIVAS_ESPERA"), 500*time.Millisecond),
+		EsperaMaxima:          duracao(os.Getenv("CAMBIO_TENTATIVAS_ESPERA_MAXIMA"), 10*time.Second),
+		RequisicoesPorSegundo: decimal(os.Getenv("CAMBIO_LIMITE_REQUISICOES"), 5),
+		Rajada:                inteiro(os.Getenv("CAMBIO_LIMITE_RAJADA"), 5),
+		FalhasCircuito:        inteiro(os.Getenv("CAMBIO_CIRCUITO_FALHAS"), 5),
+		TempoCircuitoAberto:   duracao(os.Getenv("CAMBIO_CIRCUITO_ABERTO"), 30*time.Second),
+
+		Pivo:                   strings.ToUpper(os.Getenv("CAMBIO_PIVO")),
+		BasesVerificacao:       lista(strings.ToUpper(os.Getenv("CAMBIO_VERIFICAR_BASES")), nil),
+		ToleranciaConsistencia: decimal(os.Getenv("CAMBIO_TOLERANCIA_CONSISTENCIA"), 0.5),
+
+		ToleranciaInversa: decimal(os.Getenv("CAMBIO_VALIDACAO_TOLERANCIA_INVERSA"), 2.0),
+		VariacaoMaxima:    decimal(os.Getenv("CAMBIO_VALIDACAO_VARIACAO_MAXIMA"), 10.0),
+
+		ArquivoMoedas: os.Getenv("CAMBIO_MOEDAS_ARQUIVO"),
+		ArquivoPrecos: os.Getenv("CAMBIO_PRECOS_ARQUIVO"),
+		ArquivoIOF:    os.Getenv("CAMBIO_IOF_ARQUIVO"),
+
+		Cache:         strings.ToLower(valor(os.Getenv("CAMBIO_CACHE"), "arquivo")),
+		ArquivoCache:  valor(os.Getenv("CAMBIO_CACHE_ARQUIVO"), "taxas_cambio_cache.json"),
+		URLCacheRedis: valor(os.Getenv("CAMBIO_CACHE_REDIS_URL"), "redis://localhost:6379/0"),
+		ChaveCache:    valor(os.Getenv("CAMBIO_CACHE_CHAVE"), "cambio:taxas"),
+		ValidadeCache: duracao(os.Getenv("CAMBIO_CACHE_VALIDADE"), time.Hour),
+
+		MaximoObsoleto:          duracaoOpcional(os.Getenv("CAMBIO_CACHE_MAXIMO_OBSOLETO"), 24*time.Hour),
+		Carga:                   valor(os.Getenv("CAMBIO_CARGA"), "periodica"),
+		AntecedenciaAtualizacao: duracaoOpcional(os.Getenv("CAMBIO_ATUALIZACAO_ANTECEDENCIA"), 5*time.Minute),
+
+		ValidadeCotacao: duracao(os.Getenv("CAMBIO_COTACAO_VALIDADE"), 30*time.Second),
+
+		RetencaoIdempotencia: duracao(os.Getenv("CAMBIO_IDEMPOTENCIA_RETENCAO"), 24*time.Hour),
+	}
+}
+
+// valor retorna o padrão quando a variável não foi definida
+func valor(v, padrao string) string {
+	if strings.TrimSpace(v) == "" {
+		return padrao
+	}
+	return strings.TrimSpace(v)
+}
+
+// arquivoOpcional retorna o arquivo informado ou, sem valor, o padrão se ele
+// existir; "-" desativa o recurso
+func arquivoOpcional(v, padrao string) string {
+	v = strings.TrimSpace(v)
+	switch {
+	case v == "-":
+		return ""
+	case v != "":
+		return v
+	}
+	if _, err := os.Stat(padrao); err != nil {
+		return ""
+	}
+	return padrao
+}
+
+// decimal converte um valor numérico, retornando o padrão se vazio ou inválido
+func decimal(valor string, padrao float64) float64 {
+	n, err := strconv.ParseFloat(strings.TrimSpace(valor), 64)
+	if err != nil {
+		return padrao
+	}
+	return n
+}
+
+// inteiro converte um valor inteiro não negativo, retornando o padrão se vazio ou inválido
+func inteiro(valor string, padrao int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(valor))
+	if err != nil || n < 0 {
+		return padrao
+	}
+	return n
+}
+
+// duracao converte um valor como "30s" ou "2m", retornando o padrão se vazio ou inválido
+func duracao(valor string, padrao time.Duration) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(valor))
+	if err != nil || d <= 0 {
+		return padrao
+	}
+	return d
+}
+
+// duracaoOpcional é como duracao, mas aceita "0" para desativar o recurso
+func duracaoOpcional(valor string, padrao time.Duration) time.Duration {
+	if strings.TrimSpace(valor) == "0" {
+		return 0
+	}
+	return duracao(valor, padrao)
+}
+
+// lista separa um valor por vírgulas, ignorando itens vazios
+func lista(valor string, padrao []string) []string {
+	if strings.TrimSpace(valor) == "" {
+		return padrao
+	}
+
+	var itens []string
+	for _, item := range strings.Split(valor, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			itens = append(itens, item)
+		}
+	}
+	return itens
+}
@@ -0,0 +1,11 @@
+-- Exclusão lógica: transações excluídas pela API continuam gravadas para auditoria
+ALTER TABLE transacoes_cambio
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- As consultas da aplicação só leem transações não excluídas
+CREATE INDEX IF NOT EXISTS idx_transacoes_ativas_user_data
+    ON transacoes_cambio(user_id, data_transacao DESC)
+    WHERE deleted_at IS NULL;
+
+-- Comentários para documentação
+COMMENT ON COLUMN transacoes_cambio.deleted_at IS 'Momento da exclusão lógica; NULL para transações ativas';
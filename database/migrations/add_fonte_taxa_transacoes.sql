@@ -0,0 +1,10 @@
+-- Registra de onde veio a taxa aplicada em cada transação
+ALTER TABLE transacoes_cambio
+ADD COLUMN IF NOT EXISTS fonte_taxa VARCHAR(20) NOT NULL DEFAULT 'ao_vivo'
+    CHECK (fonte_taxa IN ('ao_vivo', 'cache', 'fallback'));
+
+-- Índice para auditar operações feitas com taxas de fallback
+CREATE INDEX IF NOT EXISTS idx_transacoes_fonte_taxa ON transacoes_cambio(fonte_taxa);
+
+-- Comentários para documentação
+COMMENT ON COLUMN transacoes_cambio.fonte_taxa IS 'Origem da taxa aplicada: ao_vivo (buscada na operação), cache ou fallback (tabela offline)';
@@ -0,0 +1,30 @@
+-- Índices da paginação por cursor: cada ordenação aceita por GET /api/transacoes
+-- percorre (user_id, campo, id) direto no índice, sem OFFSET
+DROP INDEX IF EXISTS idx_transacoes_ativas_user_data;
+
+CREATE INDEX IF NOT EXISTS idx_transacoes_ativas_user_data
+    ON transacoes_cambio(user_id, data_transacao, id)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transacoes_ativas_user_valor_origem
+    ON transacoes_cambio(user_id, valor_origem, id)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transacoes_ativas_user_valor_destino
+    ON transacoes_cambio(user_id, valor_destino, id)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transacoes_ativas_user_moeda_origem
+    ON transacoes_cambio(user_id, moeda_origem, id)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transacoes_ativas_user_moeda_destino
+    ON transacoes_cambio(user_id, moeda_destino, id)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transacoes_ativas_user_status
+    ON transacoes_cambio(user_id, status, id)
+    WHERE deleted_at IS NULL;
+
+-- Comentários para documentação
+COMMENT ON INDEX idx_transacoes_ativas_user_data IS 'Paginação por cursor ordenada por data; lido nos dois sentidos';
@@ -0,0 +1,14 @@
+-- Adiciona o IOF calculado em cada transação
+ALTER TABLE transacoes_cambio
+ADD COLUMN IF NOT EXISTS categoria_iof VARCHAR(20) NOT NULL DEFAULT 'especie'
+    CHECK (categoria_iof IN ('especie', 'cartao', 'remessa', 'investimento')),
+ADD COLUMN IF NOT EXISTS aliquota_iof DECIMAL(7, 4) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS valor_iof DECIMAL(20, 4) NOT NULL DEFAULT 0;
+
+-- Índice para os totais de IOF por período
+CREATE INDEX IF NOT EXISTS idx_transacoes_categoria_iof ON transacoes_cambio(categoria_iof);
+
+-- Comentários para documentação
+COMMENT ON COLUMN transacoes_cambio.categoria_iof IS 'Categoria da operação para IOF: especie, cartao, remessa ou investimento';
+COMMENT ON COLUMN transacoes_cambio.aliquota_iof IS 'Alíquota percentual de IOF vigente na data da transação';
+COMMENT ON COLUMN transacoes_cambio.valor_iof IS 'IOF cobrado, sempre em BRL, sobre a perna em reais da operação';
@@ -0,0 +1,16 @@
+-- Adiciona os dados de precificação (spread e comissão) às transações
+ALTER TABLE transacoes_cambio
+ADD COLUMN IF NOT EXISTS taxa_media DECIMAL(18, 8),
+ADD COLUMN IF NOT EXISTS spread_percentual DECIMAL(9, 4) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS comissao DECIMAL(20, 4) NOT NULL DEFAULT 0;
+
+-- Transações anteriores foram executadas à taxa média
+UPDATE transacoes_cambio SET taxa_media = taxa_cambio WHERE taxa_media IS NULL;
+
+ALTER TABLE transacoes_cambio ALTER COLUMN taxa_media SET NOT NULL;
+
+-- Comentários para documentação
+COMMENT ON COLUMN transacoes_cambio.taxa_cambio IS 'Taxa aplicada ao cliente, já com o spread';
+COMMENT ON COLUMN transacoes_cambio.taxa_media IS 'Taxa média de mercado no momento da operação';
+COMMENT ON COLUMN transacoes_cambio.spread_percentual IS 'Spread percentual aplicado sobre a taxa média';
+COMMENT ON COLUMN transacoes_cambio.comissao IS 'Comissão cobrada, na moeda de origem';
@@ -0,0 +1,20 @@
+-- Ciclo de vida das transações: novas operações começam Pendente e são
+-- confirmadas ou canceladas pela API
+ALTER TABLE transacoes_cambio
+ALTER COLUMN status SET DEFAULT 'Pendente';
+
+ALTER TABLE transacoes_cambio
+ADD COLUMN IF NOT EXISTS confirmada_em TIMESTAMP,
+ADD COLUMN IF NOT EXISTS cancelada_em TIMESTAMP;
+
+-- Transações anteriores eram gravadas já concluídas
+UPDATE transacoes_cambio SET confirmada_em = created_at
+WHERE status = 'Concluído' AND confirmada_em IS NULL;
+
+UPDATE transacoes_cambio SET cancelada_em = updated_at
+WHERE status = 'Cancelado' AND cancelada_em IS NULL;
+
+-- Comentários para documentação
+COMMENT ON COLUMN transacoes_cambio.status IS 'Status da transação: Pendente (inicial), Concluído ou Cancelado';
+COMMENT ON COLUMN transacoes_cambio.confirmada_em IS 'Momento da transição Pendente -> Concluído';
+COMMENT ON COLUMN transacoes_cambio.cancelada_em IS 'Momento da transição para Cancelado';
@@ -0,0 +1,18 @@
+-- Amplia a precisão dos valores monetários e da taxa de câmbio.
+-- DECIMAL(10, 4) truncava taxas pequenas (ex.: JPY -> USD = 0,00668896).
+ALTER TABLE transacoes_cambio
+    ALTER COLUMN valor_origem TYPE DECIMAL(20, 4),
+    ALTER COLUMN valor_destino TYPE DECIMAL(20, 4),
+    ALTER COLUMN taxa_cambio TYPE DECIMAL(18, 8);
+
+-- Modo de arredondamento por moeda (half_up, half_even, down, up)
+ALTER TABLE moedas
+ADD COLUMN IF NOT EXISTS arredondamento VARCHAR(10) NOT NULL DEFAULT 'half_up'
+    CHECK (arredondamento IN ('half_up', 'half_even', 'down', 'up'));
+
+-- Real arredondado pela regra do par mais próximo (ABNT NBR 5891)
+UPDATE moedas SET arredondamento = 'half_even' WHERE codigo = 'BRL';
+
+-- Comentários para documentação
+COMMENT ON COLUMN transacoes_cambio.taxa_cambio IS 'Taxa aplicada na conversão, com 8 casas decimais';
+COMMENT ON COLUMN moedas.arredondamento IS 'Modo de arredondamento dos valores na moeda: half_up (meio para cima) ou half_even (bancário)';
@@ -0,0 +1,10 @@
+-- Cache de taxas compartilhado entre instâncias (CAMBIO_CACHE=postgres)
+CREATE TABLE IF NOT EXISTS cache_taxas (
+    chave VARCHAR(100) PRIMARY KEY,
+    dados TEXT NOT NULL,
+    atualizado_em TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Comentários para documentação
+COMMENT ON TABLE cache_taxas IS 'Última busca de taxas serializada em JSON, por chave de cache';
+COMMENT ON COLUMN cache_taxas.dados IS 'Conteúdo do cache; a validade é controlada pela aplicação';
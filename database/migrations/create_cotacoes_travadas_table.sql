@@ -0,0 +1,18 @@
+-- Cotações travadas por POST /api/cotacoes, compartilhadas entre as instâncias
+-- da aplicação até serem executadas ou expirarem
+CREATE TABLE IF NOT EXISTS cotacoes_travadas (
+    id VARCHAR(32) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversao JSONB NOT NULL,
+    criada_em TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expira_em TIMESTAMPTZ NOT NULL
+);
+
+-- Expurgo das cotações vencidas
+CREATE INDEX IF NOT EXISTS idx_cotacoes_travadas_expira_em ON cotacoes_travadas(expira_em);
+
+-- Comentários para documentação
+COMMENT ON TABLE cotacoes_travadas IS 'Cotações travadas ainda não executadas; cada uma é apagada ao ser executada';
+COMMENT ON COLUMN cotacoes_travadas.id IS 'Identificador aleatório devolvido ao cliente e informado em cotacao_id';
+COMMENT ON COLUMN cotacoes_travadas.conversao IS 'Preço completo cotado (taxa, spread, comissão e IOF); o JSONB guarda os decimais sem perda de precisão';
+COMMENT ON COLUMN cotacoes_travadas.expira_em IS 'Fim da validade (CAMBIO_COTACAO_VALIDADE); depois disso a cotação não pode ser executada';
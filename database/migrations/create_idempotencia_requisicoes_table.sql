@@ -0,0 +1,21 @@
+-- Respostas de POST /api/transacoes guardadas por Idempotency-Key, para que
+-- cliques duplos e retentativas não criem transações repetidas
+CREATE TABLE IF NOT EXISTS idempotencia_requisicoes (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chave VARCHAR(255) NOT NULL,
+    hash_requisicao VARCHAR(64) NOT NULL,
+    status INTEGER,
+    corpo BYTEA,
+    criado_em TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, chave)
+);
+
+-- Expurgo dos registros fora da janela de retenção
+CREATE INDEX IF NOT EXISTS idx_idempotencia_criado_em ON idempotencia_requisicoes(criado_em);
+
+-- Comentários para documentação
+COMMENT ON TABLE idempotencia_requisicoes IS 'Primeira requisição e resposta de cada chave de idempotência, por usuário';
+COMMENT ON COLUMN idempotencia_requisicoes.hash_requisicao IS 'SHA-256 do corpo da requisição; outro corpo com a mesma chave é rejeitado';
+COMMENT ON COLUMN idempotencia_requisicoes.status IS 'Status HTTP da resposta guardada; NULL enquanto a requisição está em andamento (reserva retomada por outra requisição após 1 minuto)';
+COMMENT ON COLUMN idempotencia_requisicoes.corpo IS 'Corpo da resposta, repetido byte a byte nas retentativas';
+COMMENT ON COLUMN idempotencia_requisicoes.criado_em IS 'Início da janela de retenção (CAMBIO_IDEMPOTENCIA_RETENCAO); identifica também a reserva ao concluir ou liberar';
@@ -0,0 +1,27 @@
+-- Tabela de moedas suportadas pelo sistema (registro de moedas)
+CREATE TABLE IF NOT EXISTS moedas (
+    codigo CHAR(3) PRIMARY KEY CHECK (codigo ~ '^[A-Z]{3}$'),
+    nome VARCHAR(100) NOT NULL,
+    casas_decimais SMALLINT NOT NULL DEFAULT 2 CHECK (casas_decimais >= 0),
+    simbolo VARCHAR(10) NOT NULL DEFAULT '',
+    habilitada BOOLEAN NOT NULL DEFAULT TRUE,
+    ordem SMALLINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Moedas padrão
+INSERT INTO moedas (codigo, nome, casas_decimais, simbolo, habilitada, ordem) VALUES
+    ('USD', 'Dólar americano', 2, 'US$', TRUE, 1),
+    ('EUR', 'Euro', 2, '€', TRUE, 2),
+    ('BRL', 'Real brasileiro', 2, 'R$', TRUE, 3),
+    ('GBP', 'Libra esterlina', 2, '£', TRUE, 4),
+    ('JPY', 'Iene japonês', 0, '¥', TRUE, 5),
+    ('ARS', 'Peso argentino', 2, '$', FALSE, 6),
+    ('CHF', 'Franco suíço', 2, 'CHF', FALSE, 7)
+ON CONFLICT (codigo) DO NOTHING;
+
+-- Comentários para documentação
+COMMENT ON TABLE moedas IS 'Registro de moedas ISO 4217 suportadas na validação, busca de taxas e /api/moedas';
+COMMENT ON COLUMN moedas.casas_decimais IS 'Casas decimais da unidade monetária (ex.: JPY = 0, BRL = 2)';
+COMMENT ON COLUMN moedas.habilitada IS 'Moedas desabilitadas permanecem cadastradas mas não são aceitas nem buscadas';
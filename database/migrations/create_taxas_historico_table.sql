@@ -0,0 +1,19 @@
+-- Histórico de todas as taxas obtidas dos provedores
+CREATE TABLE IF NOT EXISTS taxas_historico (
+    id BIGSERIAL PRIMARY KEY,
+    moeda_base CHAR(3) NOT NULL,
+    moeda_cotacao CHAR(3) NOT NULL,
+    taxa DECIMAL(18, 8) NOT NULL CHECK (taxa > 0),
+    fonte VARCHAR(50) NOT NULL DEFAULT '',
+    obtido_em TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Índice para as consultas de série histórica por par e período
+CREATE INDEX IF NOT EXISTS idx_taxas_historico_par_data
+    ON taxas_historico(moeda_base, moeda_cotacao, obtido_em);
+
+-- Comentários para documentação
+COMMENT ON TABLE taxas_historico IS 'Cotações obtidas a cada busca bem-sucedida, para gráficos e auditoria';
+COMMENT ON COLUMN taxas_historico.taxa IS 'Quantidade de moeda_cotacao por 1 unidade de moeda_base';
+COMMENT ON COLUMN taxas_historico.fonte IS 'Provedor que forneceu a cotação (fxratesapi, ecb, estatico...)';
+COMMENT ON COLUMN taxas_historico.obtido_em IS 'Momento da busca';
@@ -0,0 +1,94 @@
+-- Trilha de auditoria das transações de câmbio: uma linha por alteração,
+-- gravada por trigger, inclusive para alterações feitas direto no banco
+CREATE TABLE IF NOT EXISTS transacoes_auditoria (
+    id BIGSERIAL PRIMARY KEY,
+    -- Sem chave estrangeira: o histórico sobrevive mesmo à exclusão física da transação
+    transacao_id INTEGER NOT NULL,
+    operacao VARCHAR(10) NOT NULL CHECK (operacao IN ('INSERT', 'UPDATE', 'DELETE')),
+    user_id INTEGER,
+    request_id VARCHAR(100),
+    campos_alterados TEXT[] NOT NULL DEFAULT '{}',
+    valores_anteriores JSONB,
+    valores_novos JSONB,
+    alterado_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transacoes_auditoria_transacao
+    ON transacoes_auditoria(transacao_id, id);
+
+-- Registra cada alteração de transacoes_cambio. O autor e a requisição vêm
+-- das configurações app.user_id e app.request_id, definidas pela aplicação
+-- com set_config(..., true) na mesma transação da escrita.
+CREATE OR REPLACE FUNCTION auditar_transacao_cambio() RETURNS TRIGGER AS $$
+DECLARE
+    anteriores JSONB;
+    novos JSONB;
+    campos TEXT[] := '{}';
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        anteriores := to_jsonb(OLD);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        novos := to_jsonb(NEW);
+    END IF;
+
+    IF TG_OP = 'UPDATE' THEN
+        SELECT COALESCE(array_agg(n.key ORDER BY n.key), '{}')
+          INTO campos
+          FROM jsonb_each(novos) n
+         WHERE n.key <> 'updated_at'
+           AND n.value IS DISTINCT FROM anteriores -> n.key;
+
+        -- Regravar os mesmos valores não é uma alteração
+        IF cardinality(campos) = 0 THEN
+            RETURN NEW;
+        END IF;
+    END IF;
+
+    INSERT INTO transacoes_auditoria (
+        transacao_id, operacao, user_id, request_id,
+        campos_alterados, valores_anteriores, valores_novos
+    ) VALUES (
+        COALESCE(NEW.id, OLD.id),
+        TG_OP,
+        NULLIF(current_setting('app.user_id', true), '')::INTEGER,
+        NULLIF(current_setting('app.request_id', true), ''),
+        campos,
+        anteriores,
+        novos
+    );
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_auditar_transacoes_cambio ON transacoes_cambio;
+CREATE TRIGGER trg_auditar_transacoes_cambio
+    AFTER INSERT OR UPDATE OR DELETE ON transacoes_cambio
+    FOR EACH ROW EXECUTE FUNCTION auditar_transacao_cambio();
+
+-- A trilha é somente de inclusão: alterar ou apagar registros é rejeitado
+CREATE OR REPLACE FUNCTION impedir_alteracao_auditoria() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'transacoes_auditoria é somente de inclusão (% rejeitado)', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_transacoes_auditoria_imutavel ON transacoes_auditoria;
+CREATE TRIGGER trg_transacoes_auditoria_imutavel
+    BEFORE UPDATE OR DELETE ON transacoes_auditoria
+    FOR EACH ROW EXECUTE FUNCTION impedir_alteracao_auditoria();
+
+DROP TRIGGER IF EXISTS trg_transacoes_auditoria_sem_truncate ON transacoes_auditoria;
+CREATE TRIGGER trg_transacoes_auditoria_sem_truncate
+    BEFORE TRUNCATE ON transacoes_auditoria
+    FOR EACH STATEMENT EXECUTE FUNCTION impedir_alteracao_auditoria();
+
+-- Comentários para documentação
+COMMENT ON TABLE transacoes_auditoria IS 'Trilha de auditoria somente de inclusão das alterações em transacoes_cambio';
+COMMENT ON COLUMN transacoes_auditoria.operacao IS 'Operação registrada: INSERT, UPDATE ou DELETE';
+COMMENT ON COLUMN transacoes_auditoria.user_id IS 'Usuário autor da alteração; NULL para o sistema ou manutenção direta no banco';
+COMMENT ON COLUMN transacoes_auditoria.request_id IS 'ID da requisição HTTP que originou a alteração (X-Request-Id)';
+COMMENT ON COLUMN transacoes_auditoria.campos_alterados IS 'Colunas cujo valor mudou, exceto updated_at; vazio em INSERT e DELETE';
+COMMENT ON COLUMN transacoes_auditoria.valores_anteriores IS 'Linha completa antes da alteração; NULL em INSERT';
+COMMENT ON COLUMN transacoes_auditoria.valores_novos IS 'Linha completa depois da alteração; NULL em DELETE';
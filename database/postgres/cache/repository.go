@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"golang-project/cambio"
+)
+
+// Repository implementa cambio.ArmazenamentoCache usando uma tabela do PostgreSQL,
+// compartilhada entre todas as instâncias da aplicação
+type Repository struct {
+	db    *sql.DB
+	chave string
+}
+
+// New cria um armazenamento de cache na chave informada
+func New(db *sql.DB, chave string) *Repository {
+	return &Repository{db: db, chave: chave}
+}
+
+// Ler retorna o conteúdo gravado na chave, ou cambio.ErrCacheVazio
+func (r *Repository) Ler(ctx context.Context) ([]byte, error) {
+	query := `SELECT dados FROM cache_taxas WHERE chave = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var dados []byte
+	err := r.db.QueryRowContext(ctx, query, r.chave).Scan(&dados)
+	if err == sql.ErrNoRows {
+		return nil, cambio.ErrCacheVazio
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler cache do banco: %w", err)
+	}
+
+	return dados, nil
+}
+
+// Gravar cria ou substitui o conteúdo da chave
+func (r *Repository) Gravar(ctx context.Context, dados []byte) error {
+	query := `
+		INSERT INTO cache_taxas (chave, dados, atualizado_em)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (chave) DO UPDATE
+		SET dados = EXCLUDED.dados,
+		    atualizado_em = EXCLUDED.atualizado_em
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, r.chave, string(dados)); err != nil {
+		return fmt.Errorf("erro ao gravar cache no banco: %w", err)
+	}
+
+	return nil
+}
+
+// Remover apaga a chave; não é erro se ela não existir
+func (r *Repository) Remover(ctx context.Context) error {
+	query := `DELETE FROM cache_taxas WHERE chave = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, r.chave); err != nil {
+		return fmt.Errorf("erro ao limpar cache do banco: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package cotacao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang-project/cambio"
+)
+
+// Repository implementa cambio.CotacaoRepository usando PostgreSQL, para que
+// uma cotação travada em uma instância possa ser executada em outra
+type Repository struct {
+	db *sql.DB
+}
+
+// New cria uma nova instância do repository de cotações
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Salvar grava a cotação, expurgando antes as já vencidas
+func (r *Repository) Salvar(ctx context.Context, cotacao *cambio.CotacaoTravada) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM cotacoes_travadas WHERE expira_em <= $1`, time.Now()); err != nil {
+		return fmt.Errorf("erro ao expurgar cotações vencidas: %w", err)
+	}
+
+	conversao, err := json.Marshal(cotacao.Conversao)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar cotação: %w", err)
+	}
+
+	query := `
+		INSERT INTO cotacoes_travadas (id, user_id, conversao, criada_em, expira_em)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	_, err = r.db.ExecContext(ctx, query, cotacao.ID, cotacao.UserID, conversao, cotacao.CriadaEm, cotacao.ExpiraEm)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar cotação: %w", err)
+	}
+
+	return nil
+}
+
+// Consumir apaga e retorna a cotação do usuário na mesma transação em que a
+// lê, de modo que duas instâncias não executem a mesma cotação
+func (r *Repository) Consumir(ctx context.Context, id string, userID int, validar func(*cambio.CotacaoTravada) error) (*cambio.CotacaoTravada, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT conversao, criada_em, expira_em
+		FROM cotacoes_travadas
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`
+
+	cotacao := cambio.CotacaoTravada{ID: id, UserID: userID, Conversao: &cambio.Conversao{}}
+	var conversao []byte
+
+	err = tx.QueryRowContext(ctx, query, id, userID).Scan(&conversao, &cotacao.CriadaEm, &cotacao.ExpiraEm)
+	if err == sql.ErrNoRows {
+		return nil, cambio.ErrCotacaoNaoEncontrada
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar cotação: %w", err)
+	}
+
+	if err := json.Unmarshal(conversao, cotacao.Conversao); err != nil {
+		return nil, fmt.Errorf("erro ao ler cotação: %w", err)
+	}
+
+	expirada := cotacao.Expirada(time.Now())
+	if !expirada && validar != nil {
+		if err := validar(&cotacao); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cotacoes_travadas WHERE id = $1`, id); err != nil {
+		return nil, fmt.Errorf("erro ao consumir cotação: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	if expirada {
+		return nil, cambio.ErrCotacaoExpirada
+	}
+	return &cotacao, nil
+}
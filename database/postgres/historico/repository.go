@@ -0,0 +1,123 @@
+package historico
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang-project/cambio"
+	"golang-project/moeda"
+)
+
+// Repository implementa cambio.HistoricoRepository usando PostgreSQL
+type Repository struct {
+	db *sql.DB
+}
+
+// New cria uma nova instância do repository de histórico de taxas
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Registrar grava todas as cotações de uma busca, em uma única transação
+func (r *Repository) Registrar(ctx context.Context, resultado *cambio.ResultadoTaxas) error {
+	query := `
+		INSERT INTO taxas_historico (moeda_base, moeda_cotacao, taxa, fonte, obtido_em)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	obtidoEm := resultado.ObtidoEm
+	if obtidoEm.IsZero() {
+		obtidoEm = time.Now()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar gravação do histórico: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao preparar gravação do histórico: %w", err)
+	}
+	defer stmt.Close()
+
+	for base, taxas := range resultado.Taxas {
+		fonte := resultado.Fontes[base]
+		for cotacao, taxa := range taxas {
+			valor := moeda.NewFromFloat(taxa).Round(cambio.CASAS_TAXA, moeda.ArredondamentoBancario)
+			if _, err := stmt.ExecContext(ctx, base, cotacao, valor, fonte, obtidoEm); err != nil {
+				return fmt.Errorf("erro ao gravar taxa %s->%s no histórico: %w", base, cotacao, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar gravação do histórico: %w", err)
+	}
+
+	return nil
+}
+
+// Consultar agrupa as taxas do par em intervalos fixos (alinhados ao epoch, em UTC)
+// e retorna abertura, máxima, mínima e fechamento de cada intervalo
+func (r *Repository) Consultar(ctx context.Context, consulta cambio.ConsultaHistorico) ([]cambio.PontoHistorico, error) {
+	query := `
+		SELECT to_timestamp(floor(extract(epoch FROM obtido_em) / $5) * $5) AS inicio,
+		       (array_agg(taxa ORDER BY obtido_em ASC))[1] AS abertura,
+		       MAX(taxa) AS maxima,
+		       MIN(taxa) AS minima,
+		       (array_agg(taxa ORDER BY obtido_em DESC))[1] AS fechamento,
+		       COUNT(*) AS amostras,
+		       string_agg(DISTINCT fonte, ',') AS fontes
+		FROM taxas_historico
+		WHERE moeda_base = $1
+		  AND moeda_cotacao = $2
+		  AND obtido_em >= $3
+		  AND obtido_em < $4
+		GROUP BY 1
+		ORDER BY 1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query,
+		consulta.Origem,
+		consulta.Destino,
+		consulta.De,
+		consulta.Ate,
+		consulta.Intervalo.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar histórico de taxas: %w", err)
+	}
+	defer rows.Close()
+
+	pontos := []cambio.PontoHistorico{}
+	for rows.Next() {
+		var p cambio.PontoHistorico
+		var fontes sql.NullString
+		err := rows.Scan(&p.Inicio, &p.Abertura, &p.Maxima, &p.Minima, &p.Fechamento, &p.Amostras, &fontes)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear histórico de taxas: %w", err)
+		}
+		p.Fontes = []string{}
+		if fontes.String != "" {
+			p.Fontes = strings.Split(fontes.String, ",")
+		}
+		pontos = append(pontos, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao iterar histórico de taxas: %w", err)
+	}
+
+	return pontos, nil
+}
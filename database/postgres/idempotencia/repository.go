@@ -0,0 +1,147 @@
+package idempotencia
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"golang-project/cambio"
+)
+
+// Repository implementa cambio.IdempotenciaRepository usando PostgreSQL,
+// compartilhado entre todas as instâncias da aplicação
+type Repository struct {
+	db *sql.DB
+}
+
+// New cria uma nova instância do repository de idempotência
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Reservar grava a chave como em andamento, reaproveitando a linha de um
+// registro vencido ou de uma reserva abandonada. Se a chave já tiver um
+// registro válido, retorna-o.
+func (r *Repository) Reservar(ctx context.Context, registro *cambio.RegistroIdempotencia, validoDesde time.Time) (*cambio.RegistroIdempotencia, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Expurgar registros fora da janela de retenção
+	_, err := r.db.ExecContext(ctx, `DELETE FROM idempotencia_requisicoes WHERE criado_em < $1`, validoDesde)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao expurgar chaves de idempotência: %w", err)
+	}
+
+	query := `
+		INSERT INTO idempotencia_requisicoes (user_id, chave, hash_requisicao, criado_em)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, chave) DO UPDATE
+		SET hash_requisicao = EXCLUDED.hash_requisicao,
+		    status = NULL,
+		    corpo = NULL,
+		    criado_em = EXCLUDED.criado_em
+		WHERE idempotencia_requisicoes.criado_em < $5
+		   OR (idempotencia_requisicoes.status IS NULL AND idempotencia_requisicoes.criado_em < $6)
+	`
+
+	criadoEm := momentoReserva(registro)
+	abandonadaAntes := criadoEm.Add(-cambio.PRAZO_RESERVA_IDEMPOTENCIA)
+
+	// A reserva concorrente pode ser liberada entre o INSERT e o SELECT;
+	// nesse caso a chave está livre e vale tentar de novo
+	for tentativa := 0; tentativa < 2; tentativa++ {
+		result, err := r.db.ExecContext(ctx, query,
+			registro.UserID, registro.Chave, registro.Hash, criadoEm, validoDesde, abandonadaAntes)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao reservar chave de idempotência: %w", err)
+		}
+
+		reservadas, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao verificar linhas afetadas: %w", err)
+		}
+		if reservadas > 0 {
+			return nil, nil
+		}
+
+		existente, err := r.buscar(ctx, registro.UserID, registro.Chave)
+		if err != sql.ErrNoRows {
+			return existente, err
+		}
+	}
+
+	return nil, fmt.Errorf("erro ao reservar chave de idempotência: %w", cambio.ErrIdempotenciaEmAndamento)
+}
+
+// momentoReserva identifica a reserva em Concluir e Liberar. É truncado para
+// microssegundos, a precisão do TIMESTAMPTZ, para que a comparação com o
+// valor gravado seja exata.
+func momentoReserva(registro *cambio.RegistroIdempotencia) time.Time {
+	return registro.CriadoEm.Truncate(time.Microsecond)
+}
+
+// buscar lê o registro da chave do usuário
+func (r *Repository) buscar(ctx context.Context, userID int, chave string) (*cambio.RegistroIdempotencia, error) {
+	query := `
+		SELECT hash_requisicao, status, corpo, criado_em
+		FROM idempotencia_requisicoes
+		WHERE user_id = $1 AND chave = $2
+	`
+
+	registro := cambio.RegistroIdempotencia{UserID: userID, Chave: chave}
+	var status sql.NullInt64
+
+	err := r.db.QueryRowContext(ctx, query, userID, chave).Scan(
+		&registro.Hash,
+		&status,
+		&registro.Corpo,
+		&registro.CriadoEm,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar chave de idempotência: %w", err)
+	}
+
+	registro.Status = int(status.Int64)
+	return &registro, nil
+}
+
+// Concluir guarda a resposta da requisição que reservou a chave, se a reserva
+// ainda for dela
+func (r *Repository) Concluir(ctx context.Context, reserva *cambio.RegistroIdempotencia, status int, corpo []byte) error {
+	query := `
+		UPDATE idempotencia_requisicoes
+		SET status = $1, corpo = $2
+		WHERE user_id = $3 AND chave = $4 AND criado_em = $5
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, status, corpo, reserva.UserID, reserva.Chave, momentoReserva(reserva)); err != nil {
+		return fmt.Errorf("erro ao guardar resposta idempotente: %w", err)
+	}
+
+	return nil
+}
+
+// Liberar apaga a reserva, se ainda estiver em andamento e não tiver sido
+// retomada por outra requisição
+func (r *Repository) Liberar(ctx context.Context, reserva *cambio.RegistroIdempotencia) error {
+	query := `
+		DELETE FROM idempotencia_requisicoes
+		WHERE user_id = $1 AND chave = $2 AND criado_em = $3 AND status IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, query, reserva.UserID, reserva.Chave, momentoReserva(reserva)); err != nil {
+		return fmt.Errorf("erro ao liberar chave de idempotência: %w", err)
+	}
+
+	return nil
+}
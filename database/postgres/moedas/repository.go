@@ -0,0 +1,65 @@
+package moedas
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"golang-project/moeda"
+)
+
+// Repository lê o registro de moedas do PostgreSQL
+type Repository struct {
+	db *sql.DB
+}
+
+// New cria uma nova instância do repository de moedas
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Listar retorna todas as moedas cadastradas, na ordem configurada
+func (r *Repository) Listar(ctx context.Context) ([]moeda.Moeda, error) {
+	query := `
+		SELECT codigo, nome, casas_decimais, simbolo, habilitada, arredondamento
+		FROM moedas
+		ORDER BY ordem, codigo
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar moedas: %w", err)
+	}
+	defer rows.Close()
+
+	var moedas []moeda.Moeda
+	for rows.Next() {
+		var m moeda.Moeda
+		if err := rows.Scan(&m.Codigo, &m.Nome, &m.CasasDecimais, &m.Simbolo, &m.Habilitada, &m.Arredondamento); err != nil {
+			return nil, fmt.Errorf("erro ao escanear moeda: %w", err)
+		}
+		moedas = append(moedas, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao iterar moedas: %w", err)
+	}
+
+	return moedas, nil
+}
+
+// CarregarRegistro monta um registro de moedas a partir da tabela
+func (r *Repository) CarregarRegistro(ctx context.Context) (*moeda.Registro, error) {
+	moedas, err := r.Listar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(moedas) == 0 {
+		return nil, fmt.Errorf("nenhuma moeda cadastrada")
+	}
+	return moeda.NewRegistro(moedas)
+}
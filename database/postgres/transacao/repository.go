@@ -4,9 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
 	"golang-project/cambio"
+
+	"github.com/lib/pq"
 )
 
 // Repository implementa cambio.TransactionRepository usando PostgreSQL
@@ -19,32 +23,80 @@ func New(db *sql.DB) cambio.TransactionRepository {
 	return &Repository{db: db}
 }
 
+// colunasTransacao lista as colunas lidas por scanTransacao, na mesma ordem
+const colunasTransacao = `
+	id, user_id, data_transacao, tipo, moeda_origem, moeda_destino,
+	valor_origem, valor_destino, taxa_cambio, taxa_media, spread_percentual, comissao,
+	categoria_iof, aliquota_iof, valor_iof, fonte_taxa,
+	status, confirmada_em, cancelada_em, created_at, updated_at
+`
+
+// scanTransacao lê uma linha com as colunas de colunasTransacao
+func scanTransacao(row interface{ Scan(...interface{}) error }, t *cambio.Transaction) error {
+	return row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.DataTransacao,
+		&t.Tipo,
+		&t.MoedaOrigem,
+		&t.MoedaDestino,
+		&t.ValorOrigem,
+		&t.ValorDestino,
+		&t.TaxaCambio,
+		&t.TaxaMedia,
+		&t.Spread,
+		&t.Comissao,
+		&t.CategoriaIOF,
+		&t.AliquotaIOF,
+		&t.ValorIOF,
+		&t.FonteTaxa,
+		&t.Status,
+		&t.ConfirmadaEm,
+		&t.CanceladaEm,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+}
+
 // Create insere uma nova transação no banco de dados
-func (r *Repository) Create(transaction *cambio.Transaction) error {
+func (r *Repository) Create(ctx context.Context, transaction *cambio.Transaction) error {
 	query := `
 		INSERT INTO transacoes_cambio (
 			user_id, data_transacao, tipo, moeda_origem, moeda_destino,
-			valor_origem, valor_destino, taxa_cambio, status
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			valor_origem, valor_destino, taxa_cambio, taxa_media, spread_percentual, comissao,
+			categoria_iof, aliquota_iof, valor_iof, fonte_taxa,
+			status, confirmada_em, cancelada_em
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING id, created_at, updated_at
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	err := r.db.QueryRowContext(
-		ctx,
-		query,
-		transaction.UserID,
-		transaction.DataTransacao,
-		transaction.Tipo,
-		transaction.MoedaOrigem,
-		transaction.MoedaDestino,
-		transaction.ValorOrigem,
-		transaction.ValorDestino,
-		transaction.TaxaCambio,
-		transaction.Status,
-	).Scan(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
+	err := r.comAutoria(ctx, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(
+			ctx,
+			query,
+			transaction.UserID,
+			transaction.DataTransacao,
+			transaction.Tipo,
+			transaction.MoedaOrigem,
+			transaction.MoedaDestino,
+			transaction.ValorOrigem,
+			transaction.ValorDestino,
+			transaction.TaxaCambio,
+			transaction.TaxaMedia,
+			transaction.Spread,
+			transaction.Comissao,
+			transaction.CategoriaIOF,
+			transaction.AliquotaIOF,
+			transaction.ValorIOF,
+			transaction.FonteTaxa,
+			transaction.Status,
+			transaction.ConfirmadaEm,
+			transaction.CanceladaEm,
+		).Scan(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
+	})
 
 	if err != nil {
 		return fmt.Errorf("erro ao criar transação: %w", err)
@@ -54,36 +106,20 @@ func (r *Repository) Create(transaction *cambio.Transaction) error {
 }
 
 // GetByID busca uma transação pelo ID
-func (r *Repository) GetByID(id int) (*cambio.Transaction, error) {
-	query := `
-		SELECT id, user_id, data_transacao, tipo, moeda_origem, moeda_destino,
-		       valor_origem, valor_destino, taxa_cambio, status,
-		       created_at, updated_at
+func (r *Repository) GetByID(ctx context.Context, id int) (*cambio.Transaction, error) {
+	query := `SELECT ` + colunasTransacao + `
 		FROM transacoes_cambio
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var transaction cambio.Transaction
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&transaction.ID,
-		&transaction.UserID,
-		&transaction.DataTransacao,
-		&transaction.Tipo,
-		&transaction.MoedaOrigem,
-		&transaction.MoedaDestino,
-		&transaction.ValorOrigem,
-		&transaction.ValorDestino,
-		&transaction.TaxaCambio,
-		&transaction.Status,
-		&transaction.CreatedAt,
-		&transaction.UpdatedAt,
-	)
+	err := scanTransacao(r.db.QueryRowContext(ctx, query, id), &transaction)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("transação não encontrada")
+		return nil, cambio.ErrTransacaoNaoEncontrada
 	}
 
 	if err != nil {
@@ -93,65 +129,112 @@ func (r *Repository) GetByID(id int) (*cambio.Transaction, error) {
 	return &transaction, nil
 }
 
-// GetAll busca todas as transações com filtros opcionais
-func (r *Repository) GetAll(filter cambio.TransactionFilter) ([]cambio.Transaction, error) {
-	query := `
-		SELECT id, user_id, data_transacao, tipo, moeda_origem, moeda_destino,
-		       valor_origem, valor_destino, taxa_cambio, status,
-		       created_at, updated_at
-		FROM transacoes_cambio
-		WHERE 1=1
-	`
-
+// filtrosTransacao monta as condições WHERE de um TransactionFilter, a partir
+// do parâmetro $1. Retorna as condições, os argumentos e o próximo parâmetro livre.
+// Transações excluídas nunca entram nas consultas.
+func filtrosTransacao(filter cambio.TransactionFilter) (string, []interface{}, int) {
+	where := " WHERE deleted_at IS NULL"
 	var args []interface{}
 	argCount := 1
 
 	// Filtrar por usuário
 	if filter.UserID > 0 {
-		query += fmt.Sprintf(" AND user_id = $%d", argCount)
+		where += fmt.Sprintf(" AND user_id = $%d", argCount)
 		args = append(args, filter.UserID)
 		argCount++
 	}
 
-	// Adicionar filtros dinamicamente
 	if filter.DataInicio != nil {
-		query += fmt.Sprintf(" AND data_transacao >= $%d", argCount)
+		where += fmt.Sprintf(" AND data_transacao >= $%d", argCount)
 		args = append(args, *filter.DataInicio)
 		argCount++
 	}
 
 	if filter.DataFim != nil {
-		query += fmt.Sprintf(" AND data_transacao <= $%d", argCount)
+		where += fmt.Sprintf(" AND data_transacao <= $%d", argCount)
 		args = append(args, *filter.DataFim)
 		argCount++
 	}
 
 	if filter.Tipo != "" {
-		query += fmt.Sprintf(" AND tipo = $%d", argCount)
+		where += fmt.Sprintf(" AND tipo = $%d", argCount)
 		args = append(args, filter.Tipo)
 		argCount++
 	}
 
 	if filter.MoedaOrigem != "" {
-		query += fmt.Sprintf(" AND moeda_origem = $%d", argCount)
+		where += fmt.Sprintf(" AND moeda_origem = $%d", argCount)
 		args = append(args, filter.MoedaOrigem)
 		argCount++
 	}
 
 	if filter.MoedaDestino != "" {
-		query += fmt.Sprintf(" AND moeda_destino = $%d", argCount)
+		where += fmt.Sprintf(" AND moeda_destino = $%d", argCount)
 		args = append(args, filter.MoedaDestino)
 		argCount++
 	}
 
 	if filter.Status != "" {
-		query += fmt.Sprintf(" AND status = $%d", argCount)
+		where += fmt.Sprintf(" AND status = $%d", argCount)
 		args = append(args, filter.Status)
 		argCount++
 	}
 
-	// Ordenar por data mais recente primeiro
-	query += " ORDER BY data_transacao DESC, id DESC"
+	return where, args, argCount
+}
+
+// colunasOrdenacao traduz os campos de ordenação aceitos em colunas; nada
+// fora desta lista chega ao ORDER BY
+var colunasOrdenacao = map[cambio.CampoOrdenacao]string{
+	cambio.OrdenarPorData:         "data_transacao",
+	cambio.OrdenarPorValorOrigem:  "valor_origem",
+	cambio.OrdenarPorValorDestino: "valor_destino",
+	cambio.OrdenarPorMoedaOrigem:  "moeda_origem",
+	cambio.OrdenarPorMoedaDestino: "moeda_destino",
+	cambio.OrdenarPorStatus:       "status",
+}
+
+// GetAll busca as transações com filtros opcionais, na ordem de
+// filter.Ordenacao. Com filter.Cursor, continua a partir dele por keyset:
+// a comparação com (coluna, id) usa o índice e não pula linhas quando
+// novas transações chegam entre as páginas.
+func (r *Repository) GetAll(ctx context.Context, filter cambio.TransactionFilter) ([]cambio.Transaction, error) {
+	where, args, argCount := filtrosTransacao(filter)
+
+	ordenacao := filter.Ordenacao
+	if filter.Cursor != nil {
+		ordenacao = filter.Cursor.Ordenacao()
+	}
+	if ordenacao.Campo == "" {
+		ordenacao = cambio.OrdenacaoPadrao()
+	}
+	coluna, existe := colunasOrdenacao[ordenacao.Campo]
+	if !existe {
+		return nil, fmt.Errorf("%w: campo %q", cambio.ErrOrdenacaoInvalida, ordenacao.Campo)
+	}
+
+	// Voltando a partir de um cursor, a leitura é feita na ordem inversa e
+	// revertida no fim
+	voltando := filter.Cursor != nil && filter.Cursor.Anterior
+	decrescente := ordenacao.Decrescente != voltando
+
+	if filter.Cursor != nil {
+		comparacao := ">"
+		if decrescente {
+			comparacao = "<"
+		}
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", coluna, comparacao, argCount, argCount+1)
+		args = append(args, filter.Cursor.Valor, filter.Cursor.ID)
+		argCount += 2
+	}
+
+	direcao := "ASC"
+	if decrescente {
+		direcao = "DESC"
+	}
+
+	query := `SELECT ` + colunasTransacao + ` FROM transacoes_cambio` + where
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", coluna, direcao, direcao)
 
 	// Adicionar limite e offset
 	if filter.Limit > 0 {
@@ -165,7 +248,7 @@ func (r *Repository) GetAll(filter cambio.TransactionFilter) ([]cambio.Transacti
 		args = append(args, filter.Offset)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -178,20 +261,7 @@ func (r *Repository) GetAll(filter cambio.TransactionFilter) ([]cambio.Transacti
 
 	for rows.Next() {
 		var t cambio.Transaction
-		err := rows.Scan(
-			&t.ID,
-			&t.UserID,
-			&t.DataTransacao,
-			&t.Tipo,
-			&t.MoedaOrigem,
-			&t.MoedaDestino,
-			&t.ValorOrigem,
-			&t.ValorDestino,
-			&t.TaxaCambio,
-			&t.Status,
-			&t.CreatedAt,
-			&t.UpdatedAt,
-		)
+		err := scanTransacao(rows, &t)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao escanear transação: %w", err)
 		}
@@ -202,11 +272,16 @@ func (r *Repository) GetAll(filter cambio.TransactionFilter) ([]cambio.Transacti
 		return nil, fmt.Errorf("erro ao iterar transações: %w", err)
 	}
 
+	if voltando {
+		slices.Reverse(transactions)
+	}
+
 	return transactions, nil
 }
 
-// Update atualiza uma transação existente
-func (r *Repository) Update(transaction *cambio.Transaction) error {
+// Update atualiza uma transação pendente. O status só muda por
+// AtualizarStatus, que respeita a máquina de estados.
+func (r *Repository) Update(ctx context.Context, transaction *cambio.Transaction) error {
 	query := `
 		UPDATE transacoes_cambio
 		SET data_transacao = $1,
@@ -216,122 +291,265 @@ func (r *Repository) Update(transaction *cambio.Transaction) error {
 		    valor_origem = $5,
 		    valor_destino = $6,
 		    taxa_cambio = $7,
-		    status = $8,
+		    taxa_media = $8,
+		    spread_percentual = $9,
+		    comissao = $10,
+		    categoria_iof = $11,
+		    aliquota_iof = $12,
+		    valor_iof = $13,
+		    fonte_taxa = $14,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $15 AND deleted_at IS NULL AND status = $16
+		RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return r.comAutoria(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			transaction.DataTransacao,
+			transaction.Tipo,
+			transaction.MoedaOrigem,
+			transaction.MoedaDestino,
+			transaction.ValorOrigem,
+			transaction.ValorDestino,
+			transaction.TaxaCambio,
+			transaction.TaxaMedia,
+			transaction.Spread,
+			transaction.Comissao,
+			transaction.CategoriaIOF,
+			transaction.AliquotaIOF,
+			transaction.ValorIOF,
+			transaction.FonteTaxa,
+			transaction.ID,
+			string(cambio.StatusPendente),
+		).Scan(&transaction.UpdatedAt)
+
+		if err == sql.ErrNoRows {
+			return motivoSemAlteracao(ctx, tx, transaction.ID, cambio.ErrTransacaoNaoEditavel)
+		}
+
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar transação: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// AtualizarStatus grava o novo status e as datas de transição, apenas se o
+// status gravado ainda for de. Assim duas mudanças simultâneas não passam
+// ambas pela máquina de estados.
+func (r *Repository) AtualizarStatus(ctx context.Context, transaction *cambio.Transaction, de cambio.StatusTransacao) error {
+	query := `
+		UPDATE transacoes_cambio
+		SET status = $1,
+		    confirmada_em = $2,
+		    cancelada_em = $3,
 		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $9
+		WHERE id = $4 AND status = $5 AND deleted_at IS NULL
 		RETURNING updated_at
 	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	err := r.db.QueryRowContext(
-		ctx,
-		query,
-		transaction.DataTransacao,
-		transaction.Tipo,
-		transaction.MoedaOrigem,
-		transaction.MoedaDestino,
-		transaction.ValorOrigem,
-		transaction.ValorDestino,
-		transaction.TaxaCambio,
-		transaction.Status,
-		transaction.ID,
-	).Scan(&transaction.UpdatedAt)
+	return r.comAutoria(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			transaction.Status,
+			transaction.ConfirmadaEm,
+			transaction.CanceladaEm,
+			transaction.ID,
+			string(de),
+		).Scan(&transaction.UpdatedAt)
+
+		if err == sql.ErrNoRows {
+			return motivoSemAlteracao(ctx, tx, transaction.ID, cambio.ErrTransicaoInvalida)
+		}
+
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar status da transação: %w", err)
+		}
+
+		return nil
+	})
+}
 
+// motivoSemAlteracao explica por que uma atualização condicionada ao status
+// não alterou nenhuma linha: a transação não existe ou o status mudou
+func motivoSemAlteracao(ctx context.Context, tx *sql.Tx, id int, erroStatus error) error {
+	var atual string
+	err := tx.QueryRowContext(ctx, `SELECT status FROM transacoes_cambio WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&atual)
 	if err == sql.ErrNoRows {
-		return fmt.Errorf("transação não encontrada")
+		return cambio.ErrTransacaoNaoEncontrada
 	}
-
 	if err != nil {
-		return fmt.Errorf("erro ao atualizar transação: %w", err)
+		return fmt.Errorf("erro ao verificar status da transação: %w", err)
 	}
-
-	return nil
+	return fmt.Errorf("%w: status atual é %s", erroStatus, atual)
 }
 
-// Delete remove uma transação do banco de dados
-func (r *Repository) Delete(id int) error {
-	query := `DELETE FROM transacoes_cambio WHERE id = $1`
+// Delete exclui logicamente uma transação: a linha continua no banco, com
+// deleted_at preenchido, para manter o histórico auditável
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	query := `
+		UPDATE transacoes_cambio
+		SET deleted_at = CURRENT_TIMESTAMP,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
+	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	return r.comAutoria(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, id)
+		if err != nil {
+			return fmt.Errorf("erro ao deletar transação: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("erro ao verificar linhas afetadas: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return cambio.ErrTransacaoNaoEncontrada
+		}
+
+		return nil
+	})
+}
+
+// comAutoria executa uma escrita numa transação do banco identificada com o
+// autor anexado ao contexto, que o trigger de auditoria grava junto com a
+// alteração. As configurações valem só até o fim da transação.
+func (r *Repository) comAutoria(ctx context.Context, escrita func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("erro ao deletar transação: %w", err)
+		return fmt.Errorf("erro ao iniciar transação no banco: %w", err)
+	}
+	defer tx.Rollback()
+
+	autoria := cambio.AutoriaDe(ctx)
+	userID := ""
+	if autoria.UserID > 0 {
+		userID = strconv.Itoa(autoria.UserID)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	_, err = tx.ExecContext(ctx,
+		`SELECT set_config('app.user_id', $1, true), set_config('app.request_id', $2, true)`,
+		userID, autoria.RequestID)
 	if err != nil {
-		return fmt.Errorf("erro ao verificar linhas afetadas: %w", err)
+		return fmt.Errorf("erro ao identificar autor da alteração: %w", err)
+	}
+
+	if err := escrita(tx); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("transação não encontrada")
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar alteração: %w", err)
 	}
 
 	return nil
 }
 
 // GetTotalCount retorna o total de transações que correspondem aos filtros
-func (r *Repository) GetTotalCount(filter cambio.TransactionFilter) (int, error) {
-	query := "SELECT COUNT(*) FROM transacoes_cambio WHERE 1=1"
+func (r *Repository) GetTotalCount(ctx context.Context, filter cambio.TransactionFilter) (int, error) {
+	where, args, _ := filtrosTransacao(filter)
+	query := "SELECT COUNT(*) FROM transacoes_cambio" + where
 
-	var args []interface{}
-	argCount := 1
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-	// Filtrar por usuário
-	if filter.UserID > 0 {
-		query += fmt.Sprintf(" AND user_id = $%d", argCount)
-		args = append(args, filter.UserID)
-		argCount++
+	var count int
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao contar transações: %w", err)
 	}
 
-	// Aplicar os mesmos filtros usados em GetAll
-	if filter.DataInicio != nil {
-		query += fmt.Sprintf(" AND data_transacao >= $%d", argCount)
-		args = append(args, *filter.DataInicio)
-		argCount++
-	}
+	return count, nil
+}
 
-	if filter.DataFim != nil {
-		query += fmt.Sprintf(" AND data_transacao <= $%d", argCount)
-		args = append(args, *filter.DataFim)
-		argCount++
-	}
+// GetResumo retorna a quantidade e o IOF total (em BRL) das transações filtradas
+func (r *Repository) GetResumo(ctx context.Context, filter cambio.TransactionFilter) (*cambio.ResumoTransacoes, error) {
+	where, args, _ := filtrosTransacao(filter)
+	query := "SELECT COUNT(*), COALESCE(SUM(valor_iof), 0) FROM transacoes_cambio" + where
 
-	if filter.Tipo != "" {
-		query += fmt.Sprintf(" AND tipo = $%d", argCount)
-		args = append(args, filter.Tipo)
-		argCount++
-	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-	if filter.MoedaOrigem != "" {
-		query += fmt.Sprintf(" AND moeda_origem = $%d", argCount)
-		args = append(args, filter.MoedaOrigem)
-		argCount++
+	var resumo cambio.ResumoTransacoes
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&resumo.Quantidade, &resumo.TotalIOF)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resumir transações: %w", err)
 	}
 
-	if filter.MoedaDestino != "" {
-		query += fmt.Sprintf(" AND moeda_destino = $%d", argCount)
-		args = append(args, filter.MoedaDestino)
-		argCount++
-	}
+	return &resumo, nil
+}
 
-	if filter.Status != "" {
-		query += fmt.Sprintf(" AND status = $%d", argCount)
-		args = append(args, filter.Status)
-	}
+// GetAuditoria retorna a trilha de auditoria da transação, em ordem de gravação
+func (r *Repository) GetAuditoria(ctx context.Context, transacaoID int) ([]cambio.RegistroAuditoria, error) {
+	query := `
+		SELECT id, transacao_id, operacao, user_id, request_id,
+		       campos_alterados, valores_anteriores, valores_novos, alterado_em
+		FROM transacoes_auditoria
+		WHERE transacao_id = $1
+		ORDER BY id
+	`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var count int
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	rows, err := r.db.QueryContext(ctx, query, transacaoID)
 	if err != nil {
-		return 0, fmt.Errorf("erro ao contar transações: %w", err)
+		return nil, fmt.Errorf("erro ao buscar auditoria da transação: %w", err)
+	}
+	defer rows.Close()
+
+	registros := []cambio.RegistroAuditoria{}
+
+	for rows.Next() {
+		var registro cambio.RegistroAuditoria
+		var userID sql.NullInt64
+		var requestID sql.NullString
+		var anteriores, novos []byte
+
+		err := rows.Scan(
+			&registro.ID,
+			&registro.TransacaoID,
+			&registro.Operacao,
+			&userID,
+			&requestID,
+			pq.Array(&registro.CamposAlterados),
+			&anteriores,
+			&novos,
+			&registro.AlteradoEm,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear registro de auditoria: %w", err)
+		}
+
+		if userID.Valid {
+			id := int(userID.Int64)
+			registro.UserID = &id
+		}
+		registro.RequestID = requestID.String
+		registro.ValoresAnteriores = anteriores
+		registro.ValoresNovos = novos
+
+		registros = append(registros, registro)
 	}
 
-	return count, nil
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao iterar auditoria da transação: %w", err)
+	}
+
+	return registros, nil
 }
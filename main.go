@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"golang-project/cambio"
+	"golang-project/config"
+	"golang-project/moeda"
 	"golang-project/server"
 	"os"
 	"time"
@@ -32,40 +35,54 @@ func main() {
 }
 
 func runCLIMode() {
-	servico := cambio.NewServicoTaxasSimples()
+	cfg := config.Carregar()
+	cliente, err := cambio.NewCambioClientConfigurado(cfg.Provedores, cfg.ArquivoTaxasFallback, cfg.OpcoesCliente()...)
+	if err != nil {
+		fmt.Printf("Configuração de provedores inválida, usando padrão: %v\n", err)
+		cliente = cambio.NewCambioClient()
+	}
+
+	servico := cambio.NewServicoTaxasCambioComCliente(cliente)
+	servico.UsarEstrategia(cambio.CargaAntecipada)
+	defer servico.Parar(context.Background())
 
 	fmt.Println("=== SISTEMA DE CÂMBIO SIMPLIFICADO ===")
 
-	err := servico.InicializarTaxas()
+	err = servico.Iniciar(context.Background())
 	if err != nil {
 		fmt.Printf("Erro ao carregar taxas: %v\n", err)
 		return
 	}
 
-	servico.ExibirTaxas()
+	taxas, err := servico.ObterTaxas(context.Background())
+	if err != nil {
+		fmt.Printf("Erro ao obter taxas: %v\n", err)
+		return
+	}
+	cambio.ExibirTaxas(taxas)
 
 	fmt.Println("\n=== EXEMPLOS DE CONVERSÃO ===")
 
 	conversoes := []struct {
-		valor   float64
+		valor   moeda.Decimal
 		origem  string
 		destino string
 	}{
-		{1000.0, "USD", "BRL"},
-		{500.0, "EUR", "BRL"},
-		{100.0, "BRL", "USD"},
-		{1000.0, "GBP", "JPY"},
+		{moeda.NewFromInt(1000), "USD", "BRL"},
+		{moeda.NewFromInt(500), "EUR", "BRL"},
+		{moeda.NewFromInt(100), "BRL", "USD"},
+		{moeda.NewFromInt(1000), "GBP", "JPY"},
 	}
 
 	for _, conv := range conversoes {
-		valorConvertido, err := servico.CalcularConversao(conv.valor, conv.origem, conv.destino)
+		conversao, err := servico.CalcularConversao(context.Background(), conv.valor, conv.origem, conv.destino)
 		if err != nil {
 			fmt.Printf("Erro na conversão %s->%s: %v\n", conv.origem, conv.destino, err)
 			continue
 		}
 
-		fmt.Printf("💱 %s %.2f = %s %.2f\n",
-			conv.origem, conv.valor,
-			conv.destino, valorConvertido)
+		fmt.Printf("💱 %s %s = %s %s (%s)\n",
+			conversao.MoedaOrigem, conversao.ValorOrigem,
+			conversao.MoedaDestino, conversao.ValorDestino, conversao.Fonte)
 	}
 }
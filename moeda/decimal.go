@@ -0,0 +1,399 @@
+package moeda
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ModoArredondamento define como descartar casas decimais excedentes
+type ModoArredondamento string
+
+const (
+	// ArredondamentoMeioParaCima arredonda 0,5 para longe do zero (2,345 -> 2,35)
+	ArredondamentoMeioParaCima ModoArredondamento = "half_up"
+	// ArredondamentoBancario arredonda 0,5 para o par mais próximo (2,345 -> 2,34)
+	ArredondamentoBancario ModoArredondamento = "half_even"
+	// ArredondamentoParaBaixo descarta as casas excedentes (trunca em direção ao zero)
+	ArredondamentoParaBaixo ModoArredondamento = "down"
+	// ArredondamentoParaCima arredonda qualquer fração para longe do zero
+	ArredondamentoParaCima ModoArredondamento = "up"
+)
+
+// Valido verifica se o modo é conhecido
+func (m ModoArredondamento) Valido() bool {
+	switch m {
+	case ArredondamentoMeioParaCima, ArredondamentoBancario, ArredondamentoParaBaixo, ArredondamentoParaCima:
+		return true
+	}
+	return false
+}
+
+// Decimal é um número decimal exato, representado por um coeficiente inteiro
+// e uma quantidade de casas decimais: valor = coef / 10^casas.
+// O valor zero de Decimal representa 0 e pode ser usado diretamente.
+type Decimal struct {
+	coef  *big.Int
+	casas int32
+}
+
+var (
+	bigZero = big.NewInt(0)
+	bigUm   = big.NewInt(1)
+	bigDez  = big.NewInt(10)
+)
+
+func potencia10(n int32) *big.Int {
+	return new(big.Int).Exp(bigDez, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) coeficiente() *big.Int {
+	if d.coef == nil {
+		return bigZero
+	}
+	return d.coef
+}
+
+// New cria um decimal a partir de um coeficiente e da quantidade de casas (New(12345, 2) = 123,45)
+func New(coef int64, casas int32) Decimal {
+	if casas < 0 {
+		return Decimal{coef: new(big.Int).Mul(big.NewInt(coef), potencia10(-casas))}
+	}
+	return Decimal{coef: big.NewInt(coef), casas: casas}
+}
+
+// NewFromInt cria um decimal inteiro
+func NewFromInt(n int64) Decimal {
+	return New(n, 0)
+}
+
+// MAXIMO_CASAS_DECIMAIS e MAXIMO_DIGITOS_INTEIROS limitam os números aceitos
+// por NewFromString. Valores vêm de requisições: sem limite, um expoente como
+// 1e1000000000 obrigaria a montar um inteiro gigantesco.
+const (
+	MAXIMO_CASAS_DECIMAIS   = 40
+	MAXIMO_DIGITOS_INTEIROS = 30
+)
+
+// NewFromString interpreta um número decimal como "123.45", "-0.5" ou "1e-3"
+func NewFromString(s string) (Decimal, error) {
+	original := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, fmt.Errorf("número decimal vazio")
+	}
+
+	var expoente int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("número decimal inválido: %q", original)
+		}
+		expoente = e
+		s = s[:i]
+	}
+
+	negativo := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negativo = s[0] == '-'
+		s = s[1:]
+	}
+
+	inteira, fracao, _ := strings.Cut(s, ".")
+	digitos := inteira + fracao
+	if digitos == "" || strings.Trim(digitos, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("número decimal inválido: %q", original)
+	}
+
+	// Conferir o tamanho do resultado antes de qualquer multiplicação
+	casas := int64(len(fracao)) - expoente
+	significativos := strings.TrimLeft(digitos, "0")
+	if significativos == "" {
+		return Decimal{casas: int32(min(max(casas, 0), MAXIMO_CASAS_DECIMAIS))}, nil
+	}
+	if excesso := casas - MAXIMO_CASAS_DECIMAIS; excesso > 0 {
+		// Zeros à direita da fração não mudam o valor e podem ser descartados
+		zeros := int64(len(digitos) - len(strings.TrimRight(digitos, "0")))
+		descartar := min(excesso, zeros)
+		digitos = digitos[:int64(len(digitos))-descartar]
+		significativos = significativos[:int64(len(significativos))-descartar]
+		casas -= descartar
+	}
+	if casas > MAXIMO_CASAS_DECIMAIS {
+		return Decimal{}, fmt.Errorf("número decimal com mais de %d casas decimais: %q", MAXIMO_CASAS_DECIMAIS, original)
+	}
+	if int64(len(significativos))-casas > MAXIMO_DIGITOS_INTEIROS {
+		return Decimal{}, fmt.Errorf("número decimal com mais de %d dígitos inteiros: %q", MAXIMO_DIGITOS_INTEIROS, original)
+	}
+
+	coef, _ := new(big.Int).SetString(digitos, 10)
+	if negativo {
+		coef.Neg(coef)
+	}
+
+	if casas < 0 {
+		coef.Mul(coef, potencia10(int32(-casas)))
+		casas = 0
+	}
+
+	return Decimal{coef: coef, casas: int32(casas)}, nil
+}
+
+// MustFromString é como NewFromString, mas entra em pânico se o texto for inválido.
+// Use apenas com constantes conhecidas.
+func MustFromString(s string) Decimal {
+	d, err := NewFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewFromFloat converte um float64 usando sua menor representação decimal
+// exata (5.42 vira 5,42 e não 5,4199999...). NaN e infinito viram zero.
+func NewFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}
+	}
+	d, _ := NewFromString(strconv.FormatFloat(f, 'f', -1, 64))
+	return d
+}
+
+// alinhar retorna os coeficientes de a e b na mesma escala
+func alinhar(a, b Decimal) (*big.Int, *big.Int, int32) {
+	ca, cb := a.coeficiente(), b.coeficiente()
+	switch {
+	case a.casas > b.casas:
+		return ca, new(big.Int).Mul(cb, potencia10(a.casas-b.casas)), a.casas
+	case b.casas > a.casas:
+		return new(big.Int).Mul(ca, potencia10(b.casas-a.casas)), cb, b.casas
+	default:
+		return ca, cb, a.casas
+	}
+}
+
+// Add retorna d + o
+func (d Decimal) Add(o Decimal) Decimal {
+	ca, cb, casas := alinhar(d, o)
+	return Decimal{coef: new(big.Int).Add(ca, cb), casas: casas}
+}
+
+// Sub retorna d - o
+func (d Decimal) Sub(o Decimal) Decimal {
+	ca, cb, casas := alinhar(d, o)
+	return Decimal{coef: new(big.Int).Sub(ca, cb), casas: casas}
+}
+
+// Mul retorna d × o, sem perda de precisão
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.coeficiente(), o.coeficiente()), casas: d.casas + o.casas}
+}
+
+// Neg retorna -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.coeficiente()), casas: d.casas}
+}
+
+// Div retorna d ÷ o com a quantidade de casas e o arredondamento informados.
+// Assim como a divisão inteira, entra em pânico se o divisor for zero.
+func (d Decimal) Div(o Decimal, casas int32, modo ModoArredondamento) Decimal {
+	if o.IsZero() {
+		panic("moeda: divisão decimal por zero")
+	}
+
+	// d/o = (cd / 10^sd) / (co / 10^so) = cd × 10^(so+casas) / (co × 10^sd) em 10^-casas
+	num := new(big.Int).Mul(d.coeficiente(), potencia10(o.casas+casas))
+	den := new(big.Int).Mul(o.coeficiente(), potencia10(d.casas))
+	return Decimal{coef: dividirArredondando(num, den, modo), casas: casas}
+}
+
+// Round arredonda para a quantidade de casas informada
+func (d Decimal) Round(casas int32, modo ModoArredondamento) Decimal {
+	if casas >= d.casas {
+		return Decimal{coef: new(big.Int).Mul(d.coeficiente(), potencia10(casas-d.casas)), casas: casas}
+	}
+	return Decimal{coef: dividirArredondando(d.coeficiente(), potencia10(d.casas-casas), modo), casas: casas}
+}
+
+// dividirArredondando calcula num/den arredondando o quociente conforme o modo
+func dividirArredondando(num, den *big.Int, modo ModoArredondamento) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// Sinal do resultado: para onde "longe do zero" aponta
+	passo := bigUm
+	if num.Sign()*den.Sign() < 0 {
+		passo = big.NewInt(-1)
+	}
+
+	// Compara o dobro do resto com o divisor para saber se passou da metade
+	dobroResto := new(big.Int).Abs(r)
+	dobroResto.Lsh(dobroResto, 1)
+	metade := dobroResto.Cmp(new(big.Int).Abs(den))
+
+	var afastar bool
+	switch modo {
+	case ArredondamentoParaBaixo:
+		afastar = false
+	case ArredondamentoParaCima:
+		afastar = true
+	case ArredondamentoBancario:
+		afastar = metade > 0 || (metade == 0 && q.Bit(0) == 1)
+	default:
+		afastar = metade >= 0
+	}
+
+	if afastar {
+		q.Add(q, passo)
+	}
+	return q
+}
+
+// Cmp compara d com o: -1 se d < o, 0 se iguais, 1 se d > o
+func (d Decimal) Cmp(o Decimal) int {
+	ca, cb, _ := alinhar(d, o)
+	return ca.Cmp(cb)
+}
+
+// Equal verifica igualdade numérica (1,50 == 1,5)
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+// Sign retorna -1, 0 ou 1 conforme o sinal de d
+func (d Decimal) Sign() int {
+	return d.coeficiente().Sign()
+}
+
+// IsZero verifica se d é zero
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// IsPositive verifica se d é maior que zero
+func (d Decimal) IsPositive() bool {
+	return d.Sign() > 0
+}
+
+// IsNegative verifica se d é menor que zero
+func (d Decimal) IsNegative() bool {
+	return d.Sign() < 0
+}
+
+// CasasDecimais retorna quantas casas decimais significativas d possui (1,50 tem 1)
+func (d Decimal) CasasDecimais() int32 {
+	coef := new(big.Int).Set(d.coeficiente())
+	casas := d.casas
+	resto := new(big.Int)
+	for casas > 0 && coef.Sign() != 0 {
+		q, r := new(big.Int).QuoRem(coef, bigDez, resto)
+		if r.Sign() != 0 {
+			break
+		}
+		coef = q
+		casas--
+	}
+	if coef.Sign() == 0 {
+		return 0
+	}
+	return casas
+}
+
+// Normalizar remove os zeros à direita das casas decimais sem alterar o valor
+// (1,50 vira 1,5 e 100,00 vira 100), para que valores iguais tenham a mesma representação
+func (d Decimal) Normalizar() Decimal {
+	return d.Round(d.CasasDecimais(), ArredondamentoMeioParaCima)
+}
+
+// Float64 converte para float64 (pode perder precisão; use apenas para exibição ou cálculos aproximados)
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String retorna a representação exata, com todas as casas da escala atual
+func (d Decimal) String() string {
+	coef := d.coeficiente()
+	digitos := new(big.Int).Abs(coef).String()
+
+	if d.casas > 0 {
+		if len(digitos) <= int(d.casas) {
+			digitos = strings.Repeat("0", int(d.casas)-len(digitos)+1) + digitos
+		}
+		ponto := len(digitos) - int(d.casas)
+		digitos = digitos[:ponto] + "." + digitos[ponto:]
+	}
+
+	if coef.Sign() < 0 {
+		return "-" + digitos
+	}
+	return digitos
+}
+
+// StringFixed formata com exatamente a quantidade de casas informada (arredondando meio para cima)
+func (d Decimal) StringFixed(casas int32) string {
+	return d.Round(casas, ArredondamentoMeioParaCima).String()
+}
+
+// MarshalJSON serializa como número JSON, sem aspas
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON aceita números JSON e números entre aspas
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*d = Decimal{}
+		return nil
+	}
+
+	valor, err := NewFromString(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = valor
+	return nil
+}
+
+// Scan implementa sql.Scanner para colunas DECIMAL/NUMERIC
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		valor, err := NewFromString(string(v))
+		if err != nil {
+			return err
+		}
+		*d = valor
+		return nil
+	case string:
+		valor, err := NewFromString(v)
+		if err != nil {
+			return err
+		}
+		*d = valor
+		return nil
+	case int64:
+		*d = NewFromInt(v)
+		return nil
+	case float64:
+		*d = NewFromFloat(v)
+		return nil
+	default:
+		return fmt.Errorf("não é possível converter %T em Decimal", src)
+	}
+}
+
+// Value implementa driver.Valuer, enviando o valor exato como texto
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
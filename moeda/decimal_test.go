@@ -0,0 +1,163 @@
+package moeda
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewFromString(t *testing.T) {
+	tests := []struct {
+		entrada  string
+		esperado string
+		erro     bool
+	}{
+		{"123.45", "123.45", false},
+		{"-0.5", "-0.5", false},
+		{"+7", "7", false},
+		{".25", "0.25", false},
+		{"1e3", "1000", false},
+		{"1.5e-3", "0.0015", false},
+		{"", "", true},
+		{"abc", "", true},
+		{"1.2.3", "", true},
+		{"1e1000000000", "", true},
+		{"1e-1000000000", "", true},
+		{"1" + strings.Repeat("0", 30), "", true},
+		{"0." + strings.Repeat("0", 40) + "1", "", true},
+		{"1" + strings.Repeat("0", 29), "1" + strings.Repeat("0", 29), false},
+		{"1.5" + strings.Repeat("0", 60), "1.5" + strings.Repeat("0", 39), false},
+		{"0e-1000000000", "0." + strings.Repeat("0", 40), false},
+	}
+
+	for _, test := range tests {
+		d, err := NewFromString(test.entrada)
+		if test.erro {
+			if err == nil {
+				t.Errorf("NewFromString(%q) deveria falhar", test.entrada)
+			}
+			continue
+		}
+		if err != nil || d.String() != test.esperado {
+			t.Errorf("NewFromString(%q) = %s, %v; esperado %s", test.entrada, d, err, test.esperado)
+		}
+	}
+}
+
+func TestNewFromFloatSemRuido(t *testing.T) {
+	if d := NewFromFloat(5.42); d.String() != "5.42" {
+		t.Errorf("NewFromFloat(5.42) = %s; esperado 5.42", d)
+	}
+}
+
+func TestOperacoesExatas(t *testing.T) {
+	a := MustFromString("0.1")
+	b := MustFromString("0.2")
+	if !a.Add(b).Equal(MustFromString("0.3")) {
+		t.Errorf("0.1 + 0.2 = %s; esperado 0.3", a.Add(b))
+	}
+	if r := MustFromString("1000").Mul(MustFromString("5.4321")); r.String() != "5432.1000" {
+		t.Errorf("1000 × 5.4321 = %s; esperado 5432.1000", r)
+	}
+	if r := MustFromString("10").Div(MustFromString("3"), 4, ArredondamentoMeioParaCima); r.String() != "3.3333" {
+		t.Errorf("10 ÷ 3 = %s; esperado 3.3333", r)
+	}
+	if r := MustFromString("2").Sub(MustFromString("2.50")); r.String() != "-0.50" {
+		t.Errorf("2 - 2.50 = %s; esperado -0.50", r)
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		valor    string
+		casas    int32
+		modo     ModoArredondamento
+		esperado string
+	}{
+		{"2.345", 2, ArredondamentoMeioParaCima, "2.35"},
+		{"2.345", 2, ArredondamentoBancario, "2.34"},
+		{"2.355", 2, ArredondamentoBancario, "2.36"},
+		{"2.3451", 2, ArredondamentoBancario, "2.35"},
+		{"-2.345", 2, ArredondamentoMeioParaCima, "-2.35"},
+		{"-2.345", 2, ArredondamentoBancario, "-2.34"},
+		{"2.349", 2, ArredondamentoParaBaixo, "2.34"},
+		{"2.341", 2, ArredondamentoParaCima, "2.35"},
+		{"149.5", 0, ArredondamentoMeioParaCima, "150"},
+		{"1.5", 2, ArredondamentoMeioParaCima, "1.50"},
+	}
+
+	for _, test := range tests {
+		r := MustFromString(test.valor).Round(test.casas, test.modo)
+		if r.String() != test.esperado {
+			t.Errorf("Round(%s, %d, %s) = %s; esperado %s", test.valor, test.casas, test.modo, r, test.esperado)
+		}
+	}
+}
+
+func TestArredondamentoPorMoeda(t *testing.T) {
+	r := RegistroPadrao()
+
+	if v := r.Arredondar(MustFromString("1234.5"), "JPY"); v.String() != "1235" {
+		t.Errorf("JPY arredondado = %s; esperado 1235", v)
+	}
+	if v := r.Arredondar(MustFromString("10.125"), "BRL"); v.String() != "10.12" {
+		t.Errorf("BRL (bancário) arredondado = %s; esperado 10.12", v)
+	}
+	if v := r.Arredondar(MustFromString("10.125"), "USD"); v.String() != "10.13" {
+		t.Errorf("USD (meio para cima) arredondado = %s; esperado 10.13", v)
+	}
+}
+
+func TestCasasDecimais(t *testing.T) {
+	tests := map[string]int32{"1.50": 1, "100": 0, "0.000": 0, "0.125": 3}
+	for valor, esperado := range tests {
+		if c := MustFromString(valor).CasasDecimais(); c != esperado {
+			t.Errorf("CasasDecimais(%s) = %d; esperado %d", valor, c, esperado)
+		}
+	}
+}
+
+func TestNormalizar(t *testing.T) {
+	tests := map[string]string{"1.50": "1.5", "100.00": "100", "100": "100", "0.000": "0", "-2.500": "-2.5", "0.125": "0.125"}
+	for valor, esperado := range tests {
+		if n := MustFromString(valor).Normalizar().String(); n != esperado {
+			t.Errorf("Normalizar(%s) = %s; esperado %s", valor, n, esperado)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		Valor Decimal `json:"valor"`
+		Texto Decimal `json:"texto"`
+	}
+	if err := json.Unmarshal([]byte(`{"valor": 100.25, "texto": "0.1"}`), &v); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if v.Valor.String() != "100.25" || v.Texto.String() != "0.1" {
+		t.Errorf("valores inesperados: %s, %s", v.Valor, v.Texto)
+	}
+
+	saida, _ := json.Marshal(v)
+	if string(saida) != `{"valor":100.25,"texto":0.1}` {
+		t.Errorf("JSON inesperado: %s", saida)
+	}
+
+	var zero Decimal
+	if saida, _ := json.Marshal(zero); string(saida) != "0" {
+		t.Errorf("zero deveria serializar como 0, obtido %s", saida)
+	}
+}
+
+func TestDecimalScan(t *testing.T) {
+	var d Decimal
+	if err := d.Scan([]byte("5432.10")); err != nil || d.String() != "5432.10" {
+		t.Errorf("Scan([]byte) = %s, %v", d, err)
+	}
+	if err := d.Scan(int64(3)); err != nil || d.String() != "3" {
+		t.Errorf("Scan(int64) = %s, %v", d, err)
+	}
+	if v, _ := d.Value(); v != "3" {
+		t.Errorf("Value() = %v; esperado \"3\"", v)
+	}
+}
@@ -0,0 +1,166 @@
+package moeda
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Moeda descreve uma moeda ISO 4217 suportada pelo sistema
+type Moeda struct {
+	Codigo        string `json:"codigo"`
+	Nome          string `json:"nome"`
+	CasasDecimais int    `json:"casas_decimais"`
+	Simbolo       string `json:"simbolo"`
+	Habilitada    bool   `json:"habilitada"`
+
+	// Arredondamento usado nos valores desta moeda (padrão: meio para cima)
+	Arredondamento ModoArredondamento `json:"arredondamento,omitempty"`
+}
+
+// Arredondar ajusta o valor às casas decimais e ao modo de arredondamento da moeda
+func (m Moeda) Arredondar(valor Decimal) Decimal {
+	modo := m.Arredondamento
+	if modo == "" {
+		modo = ArredondamentoMeioParaCima
+	}
+	return valor.Round(int32(m.CasasDecimais), modo)
+}
+
+var codigoISO = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Registro mantém o conjunto de moedas conhecidas, na ordem em que foram cadastradas
+type Registro struct {
+	mu     sync.RWMutex
+	moedas map[string]Moeda
+	ordem  []string
+}
+
+// NewRegistro cria um registro validando códigos e duplicidades
+func NewRegistro(moedas []Moeda) (*Registro, error) {
+	r := &Registro{moedas: make(map[string]Moeda, len(moedas))}
+
+	for _, m := range moedas {
+		m.Codigo = strings.ToUpper(strings.TrimSpace(m.Codigo))
+		if !codigoISO.MatchString(m.Codigo) {
+			return nil, fmt.Errorf("código de moeda inválido: %q", m.Codigo)
+		}
+		if _, existe := r.moedas[m.Codigo]; existe {
+			return nil, fmt.Errorf("moeda duplicada no registro: %s", m.Codigo)
+		}
+		if m.CasasDecimais < 0 {
+			return nil, fmt.Errorf("casas decimais inválidas para %s: %d", m.Codigo, m.CasasDecimais)
+		}
+		if m.Arredondamento != "" && !m.Arredondamento.Valido() {
+			return nil, fmt.Errorf("modo de arredondamento inválido para %s: %q", m.Codigo, m.Arredondamento)
+		}
+
+		r.moedas[m.Codigo] = m
+		r.ordem = append(r.ordem, m.Codigo)
+	}
+
+	return r, nil
+}
+
+// moedasPadrao são as moedas suportadas quando nada é configurado
+var moedasPadrao = []Moeda{
+	{Codigo: "USD", Nome: "Dólar americano", CasasDecimais: 2, Simbolo: "US$", Habilitada: true},
+	{Codigo: "EUR", Nome: "Euro", CasasDecimais: 2, Simbolo: "€", Habilitada: true},
+	{Codigo: "BRL", Nome: "Real brasileiro", CasasDecimais: 2, Simbolo: "R$", Habilitada: true, Arredondamento: ArredondamentoBancario},
+	{Codigo: "GBP", Nome: "Libra esterlina", CasasDecimais: 2, Simbolo: "£", Habilitada: true},
+	{Codigo: "JPY", Nome: "Iene japonês", CasasDecimais: 0, Simbolo: "¥", Habilitada: true},
+}
+
+// RegistroPadrao retorna um registro com as moedas padrão
+func RegistroPadrao() *Registro {
+	r, _ := NewRegistro(moedasPadrao)
+	return r
+}
+
+// CarregarArquivo lê o registro de um arquivo JSON com uma lista de moedas
+func CarregarArquivo(caminho string) (*Registro, error) {
+	data, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de moedas: %w", err)
+	}
+
+	var moedas []Moeda
+	if err := json.Unmarshal(data, &moedas); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar arquivo de moedas: %w", err)
+	}
+
+	return NewRegistro(moedas)
+}
+
+// Obter busca uma moeda pelo código, habilitada ou não
+func (r *Registro) Obter(codigo string) (Moeda, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, existe := r.moedas[strings.ToUpper(codigo)]
+	return m, existe
+}
+
+// Habilitada verifica se o código corresponde a uma moeda habilitada
+func (r *Registro) Habilitada(codigo string) bool {
+	m, existe := r.Obter(codigo)
+	return existe && m.Habilitada
+}
+
+// Habilitadas retorna as moedas habilitadas na ordem do registro
+func (r *Registro) Habilitadas() []Moeda {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var moedas []Moeda
+	for _, codigo := range r.ordem {
+		if m := r.moedas[codigo]; m.Habilitada {
+			moedas = append(moedas, m)
+		}
+	}
+	return moedas
+}
+
+// Arredondar ajusta o valor às regras da moeda informada. Moedas fora do
+// registro usam 2 casas decimais, arredondando meio para cima.
+func (r *Registro) Arredondar(valor Decimal, codigo string) Decimal {
+	m, existe := r.Obter(codigo)
+	if !existe {
+		m = Moeda{Codigo: codigo, CasasDecimais: 2}
+	}
+	return m.Arredondar(valor)
+}
+
+// Codigos retorna os códigos das moedas habilitadas
+func (r *Registro) Codigos() []string {
+	var codigos []string
+	for _, m := range r.Habilitadas() {
+		codigos = append(codigos, m.Codigo)
+	}
+	return codigos
+}
+
+// Lista retorna os códigos habilitados separados por vírgula, para mensagens
+func (r *Registro) Lista() string {
+	return strings.Join(r.Codigos(), ", ")
+}
+
+var padrao atomic.Pointer[Registro]
+
+func init() {
+	padrao.Store(RegistroPadrao())
+}
+
+// Padrao retorna o registro global usado por validações e buscas de taxas
+func Padrao() *Registro {
+	return padrao.Load()
+}
+
+// DefinirPadrao substitui o registro global (ex.: após carregar configuração)
+func DefinirPadrao(r *Registro) {
+	padrao.Store(r)
+}
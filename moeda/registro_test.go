@@ -0,0 +1,67 @@
+package moeda
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistroPadrao(t *testing.T) {
+	r := RegistroPadrao()
+
+	if !r.Habilitada("usd") {
+		t.Error("USD deveria estar habilitada (case insensitive)")
+	}
+	if r.Habilitada("XYZ") {
+		t.Error("XYZ não deveria estar habilitada")
+	}
+	if r.Lista() != "USD, EUR, BRL, GBP, JPY" {
+		t.Errorf("lista inesperada: %s", r.Lista())
+	}
+
+	jpy, _ := r.Obter("JPY")
+	if jpy.CasasDecimais != 0 {
+		t.Errorf("JPY deveria ter 0 casas decimais, obtido %d", jpy.CasasDecimais)
+	}
+}
+
+func TestNewRegistroValidacoes(t *testing.T) {
+	tests := []struct {
+		nome   string
+		moedas []Moeda
+	}{
+		{"código inválido", []Moeda{{Codigo: "US"}}},
+		{"duplicada", []Moeda{{Codigo: "USD"}, {Codigo: "usd"}}},
+		{"casas negativas", []Moeda{{Codigo: "USD", CasasDecimais: -1}}},
+	}
+
+	for _, test := range tests {
+		if _, err := NewRegistro(test.moedas); err == nil {
+			t.Errorf("%s: esperado erro", test.nome)
+		}
+	}
+}
+
+func TestCarregarArquivo(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), "moedas.json")
+	conteudo := `[
+		{"codigo": "BRL", "nome": "Real", "casas_decimais": 2, "simbolo": "R$", "habilitada": true},
+		{"codigo": "ARS", "nome": "Peso argentino", "casas_decimais": 2, "simbolo": "$", "habilitada": true},
+		{"codigo": "CHF", "nome": "Franco suíço", "casas_decimais": 2, "simbolo": "CHF", "habilitada": false}
+	]`
+	if err := os.WriteFile(caminho, []byte(conteudo), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := CarregarArquivo(caminho)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if !r.Habilitada("ARS") || r.Habilitada("CHF") {
+		t.Errorf("ARS deveria estar habilitada e CHF desabilitada: %v", r.Codigos())
+	}
+	if _, existe := r.Obter("CHF"); !existe {
+		t.Error("CHF deveria existir no registro mesmo desabilitada")
+	}
+}
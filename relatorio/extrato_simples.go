@@ -2,39 +2,56 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"golang-project/cambio"
+	"golang-project/config"
+	"golang-project/moeda"
 	"log"
 	"os"
-	"strconv"
 	"time"
 )
 
-var servicoCambio = cambio.NewServicoTaxasSimples()
-var taxasCambioFallback = map[string]map[string]float64{
-	"USD": {"BRL": 5.42, "EUR": 0.92, "GBP": 0.79, "JPY": 149.50},
-	"EUR": {"BRL": 5.89, "USD": 1.09, "GBP": 0.86, "JPY": 162.80},
-	"BRL": {"USD": 0.18, "EUR": 0.17, "GBP": 0.15, "JPY": 27.50},
-	"GBP": {"BRL": 6.85, "USD": 1.27, "EUR": 1.16, "JPY": 189.20},
-	"JPY": {"BRL": 0.036, "USD": 0.0067, "EUR": 0.0061, "GBP": 0.0053},
+var servicoCambio cambio.ServicoTaxas = novoServicoCambio()
+var tabelaIOF = cambio.TabelaIOFPadrao()
+
+// novoServicoCambio carrega as taxas uma única vez, na inicialização, e as
+// mantém em memória até serem recarregadas pelo menu. Os provedores e a
+// tabela de fallback (CAMBIO_TAXAS_FALLBACK), assim como a conexão com eles
+// (chave de API, URLs, proxy, timeout e resiliência), são os mesmos do servidor.
+func novoServicoCambio() *cambio.ServicoTaxasCambio {
+	cfg := config.Carregar()
+	cliente, err := cambio.NewCambioClientConfigurado(cfg.Provedores, cfg.ArquivoTaxasFallback, cfg.OpcoesCliente()...)
+	if err != nil {
+		fmt.Printf("⚠️ Configuração de provedores inválida, usando padrão: %v\n", err)
+		cliente = cambio.NewCambioClient()
+	}
+
+	servico := cambio.NewServicoTaxasCambioComCliente(cliente)
+	servico.UsarEstrategia(cambio.CargaAntecipada)
+	return servico
 }
 
 type Transacao struct {
-	ID           string  `json:"id"`
-	Data         string  `json:"data"`
-	Hora         string  `json:"hora"`
-	NomeCliente  string  `json:"nome_cliente"`
-	CpfCnpj      string  `json:"cpf_cnpj"`
-	MoedaOrigem  string  `json:"moeda_origem,omitempty"`
-	MoedaDestino string  `json:"moeda_destino,omitempty"`
-	ValorOrigem  float64 `json:"valor_origem"`
-	ValorDestino float64 `json:"valor_destino"`
-	Status       string  `json:"status"`
-	Comissao     float64 `json:"comissao"`
-	TipoOperacao string  `json:"tipo_operacao"`
-	Canal        string  `json:"canal"`
-	Observacoes  string  `json:"observacoes"`
+	ID           string        `json:"id"`
+	Data         string        `json:"data"`
+	Hora         string        `json:"hora"`
+	NomeCliente  string        `json:"nome_cliente"`
+	CpfCnpj      string        `json:"cpf_cnpj"`
+	MoedaOrigem  string        `json:"moeda_origem,omitempty"`
+	MoedaDestino string        `json:"moeda_destino,omitempty"`
+	ValorOrigem  moeda.Decimal `json:"valor_origem"`
+	ValorDestino moeda.Decimal `json:"valor_destino"`
+	Status       string        `json:"status"`
+	Comissao     moeda.Decimal `json:"comissao"`
+	CategoriaIOF string        `json:"categoria_iof,omitempty"`
+	AliquotaIOF  moeda.Decimal `json:"aliquota_iof"`
+	ValorIOF     moeda.Decimal `json:"valor_iof"`
+	FonteTaxa    string        `json:"fonte_taxa,omitempty"`
+	TipoOperacao string        `json:"tipo_operacao"`
+	Canal        string        `json:"canal"`
+	Observacoes  string        `json:"observacoes"`
 }
 
 type DadosCambio struct {
@@ -44,10 +61,10 @@ type DadosCambio struct {
 func main() {
 
 	fmt.Println("Inicializando sistema...")
-	err := servicoCambio.InicializarTaxas()
+	err := servicoCambio.Iniciar(context.Background())
 	if err != nil {
 		fmt.Printf("⚠️ Erro ao carregar taxas da API: %v\n", err)
-		fmt.Println("O sistema funcionará com taxas de fallback.")
+		fmt.Println("As taxas serão buscadas novamente na próxima conversão.")
 	}
 
 	for {
@@ -70,7 +87,7 @@ func main() {
 		case "3":
 			recarregarTaxasCambio()
 		case "4":
-			servicoCambio.ExibirTaxas()
+			exibirTaxas()
 		case "5":
 			fmt.Println("Saindo...")
 			return
@@ -96,8 +113,7 @@ func verTransacoes() {
 
 	total := 0
 	concluidas := 0
-	valorTotal := 0.0
-	comissaoTotal := 0.0
+	var valorTotal, comissaoTotal, iofTotal moeda.Decimal
 
 	for _, transacao := range dados.TransacoesCambio {
 		total++
@@ -106,53 +122,46 @@ func verTransacoes() {
 		fmt.Printf("Data: %s | Status: %s\n", transacao.Data, transacao.Status)
 
 		if transacao.MoedaOrigem != "" && transacao.MoedaDestino != "" {
-			fmt.Printf("Conversão: %s %.2f → %s %.2f | Comissão: R$ %.2f\n",
+			fmt.Printf("Conversão: %s %s → %s %s | Comissão: R$ %s\n",
 				transacao.MoedaOrigem, transacao.ValorOrigem,
 				transacao.MoedaDestino, transacao.ValorDestino, transacao.Comissao)
 		} else {
-			fmt.Printf("Valor: %.2f → %.2f | Comissão: R$ %.2f\n",
+			fmt.Printf("Valor: %s → %s | Comissão: R$ %s\n",
 				transacao.ValorOrigem, transacao.ValorDestino, transacao.Comissao)
 		}
+		if transacao.ValorIOF.IsPositive() {
+			fmt.Printf("IOF (%s, %s%%): R$ %s\n", transacao.CategoriaIOF, transacao.AliquotaIOF, transacao.ValorIOF)
+		}
 		fmt.Println("----------------------------------------")
 
 		if transacao.Status == "concluida" {
 			concluidas++
-			valorTotal += transacao.ValorDestino
-			comissaoTotal += transacao.Comissao
+			valorTotal = valorTotal.Add(transacao.ValorDestino)
+			comissaoTotal = comissaoTotal.Add(transacao.Comissao)
+			iofTotal = iofTotal.Add(transacao.ValorIOF)
 		}
 	}
 
 	fmt.Printf("\nRESUMO FINAL:\n")
 	fmt.Printf("Total de transações: %d\n", total)
 	fmt.Printf("Transações concluídas: %d\n", concluidas)
-	fmt.Printf("Valor total movimentado: R$ %.2f\n", valorTotal)
-	fmt.Printf("Total de comissões: R$ %.2f\n", comissaoTotal)
+	fmt.Printf("Valor total movimentado: R$ %s\n", valorTotal.StringFixed(2))
+	fmt.Printf("Total de comissões: R$ %s\n", comissaoTotal.StringFixed(2))
+	fmt.Printf("Total de IOF: R$ %s\n", iofTotal.StringFixed(2))
 }
 
-func calcularConversao(valorOrigem float64, moedaOrigem, moedaDestino string) float64 {
-	if moedaOrigem == moedaDestino {
-		return valorOrigem
+// calcularConversao converte pelo serviço de câmbio. Sem taxa para o par,
+// retorna erro: a transação não é registrada com um valor inventado.
+func calcularConversao(valorOrigem moeda.Decimal, moedaOrigem, moedaDestino string) (*cambio.Conversao, error) {
+	conversao, err := servicoCambio.CalcularConversao(context.Background(), valorOrigem, moedaOrigem, moedaDestino)
+	if err != nil {
+		return nil, err
 	}
 
-	if servicoCambio.EstaoCarregadas() {
-		valorConvertido, err := servicoCambio.CalcularConversao(valorOrigem, moedaOrigem, moedaDestino)
-		if err == nil {
-			fmt.Printf("Usando taxa carregada na inicialização\n")
-			return valorConvertido
-		}
-		fmt.Printf("Erro ao usar taxa carregada: %v\n", err)
+	if conversao.Fonte == cambio.FonteFallback {
+		fmt.Println("⚠️ Usando taxa da tabela de fallback")
 	}
-
-	fmt.Printf("Usando taxas de fallback\n")
-
-	if taxas, existe := taxasCambioFallback[moedaOrigem]; existe {
-		if taxa, existeTaxa := taxas[moedaDestino]; existeTaxa {
-			return valorOrigem * taxa
-		}
-	}
-
-	fmt.Printf("Taxa de câmbio não encontrada para %s -> %s. Usando valor 1:1\n", moedaOrigem, moedaDestino)
-	return valorOrigem
+	return conversao, nil
 }
 
 func inserirTransacao() {
@@ -168,32 +177,58 @@ func inserirTransacao() {
 	scanner.Scan()
 	cpfCnpj := scanner.Text()
 
-	fmt.Print("Moeda de origem (USD, EUR, BRL, etc.): ")
+	fmt.Printf("Moeda de origem (%s): ", moeda.Padrao().Lista())
 	scanner.Scan()
 	moedaOrigem := scanner.Text()
 
 	fmt.Print("Valor de origem: ")
 	scanner.Scan()
 	valorOrigemStr := scanner.Text()
-	valorOrigem, _ := strconv.ParseFloat(valorOrigemStr, 64)
+	valorOrigem, _ := moeda.NewFromString(valorOrigemStr)
 
-	fmt.Print("Moeda de destino (USD, EUR, BRL, etc.): ")
+	fmt.Printf("Moeda de destino (%s): ", moeda.Padrao().Lista())
 	scanner.Scan()
 	moedaDestino := scanner.Text()
 
-	valorDestino := calcularConversao(valorOrigem, moedaOrigem, moedaDestino)
-	fmt.Printf("Valor convertido: %s %.2f → %s %.2f\n",
-		moedaOrigem, valorOrigem, moedaDestino, valorDestino)
+	conversao, err := calcularConversao(valorOrigem, moedaOrigem, moedaDestino)
+	if err != nil {
+		fmt.Printf("Erro ao converter %s -> %s: %v\n", moedaOrigem, moedaDestino, err)
+		fmt.Println("Transação não registrada.")
+		return
+	}
+	valorDestino := conversao.ValorDestino
+	fmt.Printf("Valor convertido: %s %s → %s %s (taxa %s, fonte: %s)\n",
+		moedaOrigem, conversao.ValorOrigem, moedaDestino, valorDestino, conversao.Taxa, conversao.Fonte)
 
 	fmt.Print("Comissão: ")
 	scanner.Scan()
 	comissaoStr := scanner.Text()
-	comissao, _ := strconv.ParseFloat(comissaoStr, 64)
+	comissao, _ := moeda.NewFromString(comissaoStr)
 
 	fmt.Print("Tipo de operação (compra/venda): ")
 	scanner.Scan()
 	tipoOperacao := scanner.Text()
 
+	fmt.Print("Categoria de IOF (especie/cartao/remessa/investimento) [especie]: ")
+	scanner.Scan()
+	categoriaIOF := cambio.CategoriaIOF(scanner.Text())
+
+	agora := time.Now()
+	operacao := &cambio.Conversao{
+		ValorOrigem:  valorOrigem,
+		ValorDestino: valorDestino,
+		MoedaOrigem:  moedaOrigem,
+		MoedaDestino: moedaDestino,
+		Comissao:     comissao,
+	}
+	if err := tabelaIOF.Aplicar(operacao, categoriaIOF, agora); err != nil {
+		fmt.Printf("Erro ao calcular IOF: %v\n", err)
+		return
+	}
+	if operacao.ValorIOF.IsPositive() {
+		fmt.Printf("IOF (%s%%): R$ %s\n", operacao.AliquotaIOF, operacao.ValorIOF)
+	}
+
 	fmt.Print("Canal (presencial/internet_banking/mobile_app/telefone): ")
 	scanner.Scan()
 	canal := scanner.Text()
@@ -206,7 +241,6 @@ func inserirTransacao() {
 	scanner.Scan()
 	observacoes := scanner.Text()
 
-	agora := time.Now()
 	novaTransacao := Transacao{
 		ID:           fmt.Sprintf("TXN-%s-%03d", agora.Format("2006"), time.Now().Unix()%1000),
 		Data:         agora.Format("2006-01-02"),
@@ -219,6 +253,10 @@ func inserirTransacao() {
 		ValorDestino: valorDestino,
 		Status:       status,
 		Comissao:     comissao,
+		CategoriaIOF: operacao.CategoriaIOF,
+		AliquotaIOF:  operacao.AliquotaIOF,
+		ValorIOF:     operacao.ValorIOF,
+		FonteTaxa:    string(conversao.Fonte),
 		TipoOperacao: tipoOperacao,
 		Canal:        canal,
 		Observacoes:  observacoes,
@@ -256,13 +294,23 @@ func inserirTransacao() {
 func recarregarTaxasCambio() {
 	fmt.Println("\n=== RECARREGANDO TAXAS DE CÂMBIO ===")
 
-	err := servicoCambio.RecarregarTaxas()
+	resultado, err := servicoCambio.ForcarAtualizacao(context.Background())
 	if err != nil {
 		fmt.Printf("Erro ao recarregar taxas: %v\n", err)
-		fmt.Println("O sistema continuará usando as taxas de fallback.")
+		fmt.Println("O sistema continuará usando as taxas carregadas anteriormente.")
 		return
 	}
 
 	fmt.Println("Taxas recarregadas com sucesso!")
-	servicoCambio.ExibirTaxas()
+	cambio.ExibirTaxas(resultado)
+}
+
+// exibirTaxas mostra as taxas em memória, buscando-as se ainda não foram carregadas
+func exibirTaxas() {
+	resultado, err := servicoCambio.ObterTaxas(context.Background())
+	if err != nil {
+		fmt.Printf("❌ Taxas não carregadas: %v\n", err)
+		return
+	}
+	cambio.ExibirTaxas(resultado)
 }
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang-project/cambio"
+)
+
+// TEMPO_ENCERRAMENTO é quanto o servidor espera requisições e buscas de taxas
+// em andamento terminarem após receber SIGINT/SIGTERM
+const TEMPO_ENCERRAMENTO = 15 * time.Second
+
+// servirAteSinal atende em addr até receber SIGINT ou SIGTERM; então para de
+// aceitar conexões, aguarda as requisições em andamento e encerra a
+// atualização automática das taxas
+func servirAteSinal(addr string, handler http.Handler, servico cambio.ServicoTaxas) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: addr, Handler: handler}
+
+	erros := make(chan error, 1)
+	go func() {
+		erros <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-erros:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+		return
+	case <-ctx.Done():
+	}
+
+	log.Println("Encerrando servidor...")
+	ctxEncerramento, cancel := context.WithTimeout(context.Background(), TEMPO_ENCERRAMENTO)
+	defer cancel()
+
+	if err := srv.Shutdown(ctxEncerramento); err != nil {
+		log.Printf("Erro ao encerrar servidor HTTP: %v\n", err)
+	}
+	if err := servico.Parar(ctxEncerramento); err != nil {
+		log.Printf("Erro ao encerrar atualização de taxas: %v\n", err)
+	}
+	log.Println("✓ Servidor encerrado")
+}
@@ -1,37 +1,143 @@
 package server
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"golang-project/cambio"
+	"golang-project/config"
+	"golang-project/moeda"
 	"golang-project/utils"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 )
 
 type CambioServer struct {
-	servico         *cambio.ServicoTaxasCambio
+	servico         cambio.ServicoTaxas
 	transactionRepo cambio.TransactionRepository
+	historicoRepo   cambio.HistoricoRepository
+
+	// idempotencia guarda as respostas de POST /api/transacoes por Idempotency-Key
+	idempotencia         cambio.IdempotenciaRepository
+	retencaoIdempotencia time.Duration
 }
 
-func NewCambioServer() *CambioServer {
+// LIMITE_PADRAO_TRANSACOES e LIMITE_MAXIMO_TRANSACOES definem o tamanho das
+// páginas de GET /api/transacoes
+const (
+	LIMITE_PADRAO_TRANSACOES = 100
+	LIMITE_MAXIMO_TRANSACOES = 1000
+)
+
+// MAXIMO_PONTOS_HISTORICO limita a quantidade de intervalos de uma consulta ao histórico
+const MAXIMO_PONTOS_HISTORICO = 5000
+
+// NewCambioServer cria o servidor sobre o serviço de câmbio informado
+func NewCambioServer(servico cambio.ServicoTaxas) *CambioServer {
 	return &CambioServer{
-		servico: cambio.NewServicoTaxasCambio(),
+		servico:              servico,
+		idempotencia:         cambio.NewIdempotenciaEmMemoria(),
+		retencaoIdempotencia: cambio.RETENCAO_IDEMPOTENCIA_PADRAO,
+	}
+}
+
+// novoServicoTaxas monta o serviço de câmbio a partir da configuração:
+// provedores, triangulação, estratégia de carga, preços e IOF
+func novoServicoTaxas(cfg *config.Config) *cambio.ServicoTaxasCambio {
+	clienteCambio, err := cambio.NewCambioClientConfigurado(cfg.Provedores, cfg.ArquivoTaxasFallback, cfg.OpcoesCliente()...)
+	if err != nil {
+		log.Printf("Configuração de provedores inválida, usando padrão: %v\n", err)
+		clienteCambio = cambio.NewCambioClient()
+	}
+
+	if cfg.Pivo != "" {
+		clienteCambio.UsarTriangulacao(cfg.Pivo, cfg.ToleranciaConsistencia, cfg.BasesVerificacao...)
+	}
+
+	servico := cambio.NewServicoTaxasCambioComCliente(clienteCambio)
+	estrategia := cambio.EstrategiaCarga(cfg.Carga)
+	if err := estrategia.Valida(); err != nil {
+		log.Printf("%v; usando %s\n", err, cambio.CargaPeriodica)
+		estrategia = cambio.CargaPeriodica
+		cfg.Carga = string(estrategia)
+	}
+	servico.UsarEstrategia(estrategia)
+	servico.AnteciparAtualizacao(cfg.AntecedenciaAtualizacao)
+	servico.UsarCotacoes(cambio.NewCotacoesEmMemoria(), cfg.ValidadeCotacao)
+	servico.UsarValidador(cambio.NewValidadorTaxas(cfg.ToleranciaInversa, cfg.VariacaoMaxima))
+	servico.UsarAlertas(registrarAlerta)
+	if cfg.ArquivoPrecos != "" {
+		motor, err := cambio.CarregarMotorPrecificacao(cfg.ArquivoPrecos)
+		if err != nil {
+			log.Printf("Tabela de preços inválida, operando sem spread e comissão: %v\n", err)
+		} else {
+			servico.UsarPrecificacao(motor)
+		}
+	}
+	if cfg.ArquivoIOF != "" {
+		tabela, err := cambio.CarregarTabelaIOF(cfg.ArquivoIOF)
+		if err != nil {
+			log.Printf("Tabela de IOF inválida, usando alíquotas padrão: %v\n", err)
+		} else {
+			servico.UsarTabelaIOF(tabela)
+		}
+	}
+
+	return servico
+}
+
+// registrarAlerta leva ao log do servidor as buscas de taxas rejeitadas
+func registrarAlerta(alerta cambio.AlertaTaxas) {
+	mantidas := "nenhuma taxa anterior disponível"
+	if !alerta.MantidasDesde.IsZero() {
+		mantidas = "mantidas as taxas de " + alerta.MantidasDesde.Format(time.RFC3339)
+	}
+	log.Printf("ALERTA: busca de taxas rejeitada (%d anomalia(s); %s)\n", len(alerta.Anomalias), mantidas)
+	for _, a := range alerta.Anomalias {
+		log.Printf("ALERTA:   [%s] %s\n", a.Tipo, a.Mensagem)
 	}
 }
 
 type ConversaoRequest struct {
-	Valor        float64 `json:"valor"`
-	MoedaOrigem  string  `json:"moedaOrigem"`
-	MoedaDestino string  `json:"moedaDestino"`
+	Valor moeda.Decimal `json:"valor"`
+	// ValorDestino pede a conversão reversa: quanto é preciso na moeda de
+	// origem para receber este valor na moeda de destino
+	ValorDestino moeda.Decimal `json:"valorDestino"`
+	MoedaOrigem  string        `json:"moedaOrigem"`
+	MoedaDestino string        `json:"moedaDestino"`
+	// Tipo aplica o spread e a comissão da operação; vazio usa a taxa média
+	Tipo string `json:"tipo,omitempty"`
+}
+
+// reversa indica se a requisição informa o valor de destino
+func (r *ConversaoRequest) reversa() bool {
+	return !r.ValorDestino.IsZero()
 }
 
 // Validate valida os campos da requisição de conversão
 func (r *ConversaoRequest) Validate() error {
 	var errs utils.ValidationErrors
 
-	if !utils.IsPositive(r.Valor) {
+	switch {
+	case r.reversa() && !r.Valor.IsZero():
+		errs = append(errs, utils.ValidationError{
+			Field:   "valor",
+			Message: "informe valor ou valorDestino, não ambos",
+		})
+	case r.reversa() && !r.ValorDestino.IsPositive():
+		errs = append(errs, utils.ValidationError{
+			Field:   "valorDestino",
+			Message: "deve ser maior que zero",
+		})
+	case !r.reversa() && !r.Valor.IsPositive():
 		errs = append(errs, utils.ValidationError{
 			Field:   "valor",
 			Message: "deve ser maior que zero",
@@ -46,7 +152,7 @@ func (r *ConversaoRequest) Validate() error {
 	} else if !utils.IsValidCurrency(r.MoedaOrigem) {
 		errs = append(errs, utils.ValidationError{
 			Field:   "moedaOrigem",
-			Message: "moeda inválida (use: USD, EUR, BRL, GBP, JPY)",
+			Message: utils.InvalidCurrencyMessage(),
 		})
 	}
 
@@ -58,7 +164,14 @@ func (r *ConversaoRequest) Validate() error {
 	} else if !utils.IsValidCurrency(r.MoedaDestino) {
 		errs = append(errs, utils.ValidationError{
 			Field:   "moedaDestino",
-			Message: "moeda inválida (use: USD, EUR, BRL, GBP, JPY)",
+			Message: utils.InvalidCurrencyMessage(),
+		})
+	}
+
+	if r.Tipo != "" && r.Tipo != "Compra" && r.Tipo != "Venda" && r.Tipo != "Conversão" {
+		errs = append(errs, utils.ValidationError{
+			Field:   "tipo",
+			Message: "deve ser: Compra, Venda ou Conversão",
 		})
 	}
 
@@ -69,16 +182,166 @@ func (r *ConversaoRequest) Validate() error {
 }
 
 type ConversaoResponse struct {
-	ValorOriginal   float64 `json:"valorOriginal"`
-	ValorConvertido float64 `json:"valorConvertido"`
-	MoedaOrigem     string  `json:"moedaOrigem"`
-	MoedaDestino    string  `json:"moedaDestino"`
-	Taxa            float64 `json:"taxa"`
+	ValorOriginal   moeda.Decimal `json:"valorOriginal"`
+	ValorConvertido moeda.Decimal `json:"valorConvertido"`
+	MoedaOrigem     string        `json:"moedaOrigem"`
+	MoedaDestino    string        `json:"moedaDestino"`
+	Taxa            moeda.Decimal `json:"taxa"`
+	// TaxaEfetiva é valorConvertido / valorOriginal, com spread, comissão e arredondamentos
+	TaxaEfetiva moeda.Decimal `json:"taxaEfetiva"`
+	Tipo        string        `json:"tipo,omitempty"`
+	// Fonte indica se a taxa é ao_vivo, do cache ou da tabela de fallback
+	Fonte cambio.FonteTaxa `json:"fonte"`
+}
+
+func novaConversaoResponse(conversao *cambio.Conversao) ConversaoResponse {
+	return ConversaoResponse{
+		ValorOriginal:   conversao.ValorOrigem,
+		ValorConvertido: conversao.ValorDestino,
+		MoedaOrigem:     conversao.MoedaOrigem,
+		MoedaDestino:    conversao.MoedaDestino,
+		Taxa:            conversao.Taxa,
+		TaxaEfetiva:     conversao.TaxaEfetiva(),
+		Tipo:            conversao.Tipo,
+		Fonte:           conversao.Fonte,
+	}
+}
+
+// ConversaoLoteRequest aceita uma lista de conversões (itens) ou um valor
+// convertido para várias moedas (valor, moedaOrigem, moedasDestino)
+type ConversaoLoteRequest struct {
+	Itens []ConversaoRequest `json:"itens,omitempty"`
+
+	Valor         moeda.Decimal `json:"valor"`
+	MoedaOrigem   string        `json:"moedaOrigem,omitempty"`
+	MoedasDestino []string      `json:"moedasDestino,omitempty"`
+}
+
+// itensConversao expande a requisição em itens, na ordem em que a resposta
+// devolve os resultados
+func (r *ConversaoLoteRequest) itensConversao() []ConversaoRequest {
+	if len(r.Itens) > 0 {
+		return r.Itens
+	}
+	itens := make([]ConversaoRequest, len(r.MoedasDestino))
+	for i, destino := range r.MoedasDestino {
+		itens[i] = ConversaoRequest{Valor: r.Valor, MoedaOrigem: r.MoedaOrigem, MoedaDestino: destino}
+	}
+	return itens
+}
+
+// Validate valida a forma do lote e cada um dos itens
+func (r *ConversaoLoteRequest) Validate() error {
+	usaDestinos := len(r.MoedasDestino) > 0 || r.MoedaOrigem != ""
+	switch {
+	case len(r.Itens) > 0 && usaDestinos:
+		return utils.ValidationErrors{{Field: "itens", Message: "informe itens ou moedaOrigem e moedasDestino, não ambos"}}
+	case len(r.Itens) == 0 && len(r.MoedasDestino) == 0:
+		return utils.ValidationErrors{{Field: "itens", Message: "informe ao menos uma conversão"}}
+	}
+
+	itens := r.itensConversao()
+	if len(itens) > cambio.MAXIMO_ITENS_LOTE {
+		return utils.ValidationErrors{{
+			Field:   "itens",
+			Message: fmt.Sprintf("no máximo %d conversões por lote", cambio.MAXIMO_ITENS_LOTE),
+		}}
+	}
+
+	var errs utils.ValidationErrors
+	for i, item := range itens {
+		if item.reversa() || item.Tipo != "" {
+			errs = append(errs, utils.ValidationError{
+				Field:   fmt.Sprintf("itens[%d]", i),
+				Message: "o lote aceita apenas conversões pelo valor de origem, à taxa média",
+			})
+			continue
+		}
+		if err := item.Validate(); err != nil {
+			for _, e := range err.(utils.ValidationErrors) {
+				errs = append(errs, utils.ValidationError{
+					Field:   fmt.Sprintf("itens[%d].%s", i, e.Field),
+					Message: e.Message,
+				})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ItemLoteResponse é a conversão de um item ou, se ela falhou, apenas o erro
+type ItemLoteResponse struct {
+	*ConversaoResponse
+	Erro string `json:"erro,omitempty"`
+}
+
+// ConversaoLoteResponse traz as conversões na ordem pedida, todas calculadas
+// com as taxas buscadas em ObtidoEm
+type ConversaoLoteResponse struct {
+	Conversoes []ItemLoteResponse `json:"conversoes"`
+	ObtidoEm   time.Time          `json:"obtido_em"`
+	Obsoleto   bool               `json:"obsoleto"`
+}
+
+func novaConversaoLoteResponse(lote *cambio.LoteConversoes) ConversaoLoteResponse {
+	response := ConversaoLoteResponse{
+		Conversoes: make([]ItemLoteResponse, len(lote.Resultados)),
+		ObtidoEm:   lote.ObtidoEm,
+		Obsoleto:   lote.Obsoleto,
+	}
+	for i, r := range lote.Resultados {
+		if r.Erro != nil {
+			response.Conversoes[i].Erro = r.Erro.Error()
+			continue
+		}
+		conversao := novaConversaoResponse(r.Conversao)
+		response.Conversoes[i].ConversaoResponse = &conversao
+	}
+	return response
 }
 
 type TaxasResponse struct {
 	Taxas  map[string]map[string]float64 `json:"taxas"`
 	Status string                        `json:"status"`
+	Falhas map[string]string             `json:"falhas,omitempty"`
+
+	Divergencias []cambio.Divergencia `json:"divergencias,omitempty"`
+
+	// ObtidoEm e IdadeSegundos indicam quando as taxas foram buscadas;
+	// Obsoleto marca taxas vencidas servidas enquanto a atualização termina
+	ObtidoEm      time.Time `json:"obtido_em"`
+	IdadeSegundos int64     `json:"idade_segundos"`
+	Obsoleto      bool      `json:"obsoleto"`
+}
+
+// novaTaxasResponse monta a resposta de taxas, marcando o status como
+// "partial" quando alguma moeda base não pôde ser obtida
+func novaTaxasResponse(resultado *cambio.ResultadoTaxas, status string) TaxasResponse {
+	if !resultado.Completo() {
+		status = "partial"
+	}
+	return TaxasResponse{
+		Taxas:  resultado.Taxas,
+		Status: status,
+		Falhas: resultado.Falhas,
+
+		Divergencias: resultado.Divergencias,
+
+		ObtidoEm:      resultado.ObtidoEm,
+		IdadeSegundos: int64(resultado.Idade() / time.Second),
+		Obsoleto:      resultado.Obsoleto,
+	}
+}
+
+// HistoricoTransacaoResponse traz as alterações de uma transação, da mais
+// antiga para a mais recente
+type HistoricoTransacaoResponse struct {
+	TransacaoID int                        `json:"transacao_id"`
+	Alteracoes  []cambio.RegistroAuditoria `json:"alteracoes"`
 }
 
 type ErrorResponse struct {
@@ -100,8 +363,9 @@ func (s *CambioServer) respondError(w http.ResponseWriter, status int, message s
 // CORS middleware
 func (s *CambioServer) enableCORS(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Idempotency-Key")
+	w.Header().Set("Access-Control-Expose-Headers", "Idempotent-Replayed")
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
@@ -109,6 +373,47 @@ func (s *CambioServer) enableCORS(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GET /api/moedas - Listar moedas habilitadas
+func (s *CambioServer) GetMoedas(w http.ResponseWriter, r *http.Request) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"moedas": moeda.Padrao().Habilitadas(),
+	})
+}
+
+// HealthResponse informa se o serviço está no ar e o estado dos provedores.
+// Status é "degradado" quando algum provedor está com o circuito aberto.
+type HealthResponse struct {
+	Status     string                 `json:"status"`
+	Service    string                 `json:"service"`
+	Provedores []cambio.SaudeProvedor `json:"provedores,omitempty"`
+}
+
+// GET /api/health - Health check com o estado dos circuitos dos provedores
+func (s *CambioServer) GetHealth(w http.ResponseWriter, r *http.Request) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	response := HealthResponse{
+		Status:     "ok",
+		Service:    "cambio-api",
+		Provedores: s.servico.Saude(),
+	}
+	for _, p := range response.Provedores {
+		if p.Circuito != cambio.CircuitoFechado {
+			response.Status = "degradado"
+		}
+	}
+
+	s.respondJSON(w, http.StatusOK, response)
+}
+
 // GET /api/taxas - Obter todas as taxas de câmbio
 func (s *CambioServer) GetTaxas(w http.ResponseWriter, r *http.Request) {
 	s.enableCORS(w, r)
@@ -116,20 +421,115 @@ func (s *CambioServer) GetTaxas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	taxas, err := s.servico.ObterTaxasAtualizadas()
+	resultado, err := s.servico.ObterTaxas(r.Context())
 	if err != nil {
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	response := TaxasResponse{
-		Taxas:  taxas,
-		Status: "success",
-	}
+	response := novaTaxasResponse(resultado, "success")
 
 	s.respondJSON(w, http.StatusOK, response)
 }
 
+// parseDataConsulta aceita datas (2006-01-02) ou instantes RFC 3339. Com
+// fimDoDia, uma data simples é interpretada como o fim daquele dia.
+func parseDataConsulta(valor string, fimDoDia bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, valor); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", valor)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if fimDoDia {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// GET /api/taxas/historico?origem=USD&destino=BRL&de=&ate=&intervalo=1d - Série histórica OHLC
+func (s *CambioServer) GetHistoricoTaxas(w http.ResponseWriter, r *http.Request) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if s.historicoRepo == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Histórico de taxas não configurado")
+		return
+	}
+
+	query := r.URL.Query()
+	consulta := cambio.ConsultaHistorico{
+		Origem:    strings.ToUpper(query.Get("origem")),
+		Destino:   strings.ToUpper(query.Get("destino")),
+		Ate:       time.Now(),
+		Intervalo: 24 * time.Hour,
+	}
+
+	if consulta.Origem == "" || consulta.Destino == "" {
+		s.respondError(w, http.StatusBadRequest, "Parâmetros obrigatórios: origem, destino")
+		return
+	}
+	if !utils.IsValidCurrency(consulta.Origem) || !utils.IsValidCurrency(consulta.Destino) {
+		s.respondError(w, http.StatusBadRequest, utils.InvalidCurrencyMessage())
+		return
+	}
+
+	if ate := query.Get("ate"); ate != "" {
+		t, err := parseDataConsulta(ate, true)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, "Parâmetro ate deve ser uma data (2006-01-02) ou RFC 3339")
+			return
+		}
+		consulta.Ate = t
+	}
+
+	consulta.De = consulta.Ate.AddDate(0, 0, -30)
+	if de := query.Get("de"); de != "" {
+		t, err := parseDataConsulta(de, false)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, "Parâmetro de deve ser uma data (2006-01-02) ou RFC 3339")
+			return
+		}
+		consulta.De = t
+	}
+
+	if intervalo := query.Get("intervalo"); intervalo != "" {
+		d, err := cambio.ParseIntervalo(intervalo)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		consulta.Intervalo = d
+	}
+
+	if !consulta.De.Before(consulta.Ate) {
+		s.respondError(w, http.StatusBadRequest, "Parâmetro de deve ser anterior a ate")
+		return
+	}
+	if consulta.Ate.Sub(consulta.De)/consulta.Intervalo > MAXIMO_PONTOS_HISTORICO {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Período muito longo para o intervalo (máximo %d pontos)", MAXIMO_PONTOS_HISTORICO))
+		return
+	}
+
+	pontos, err := s.historicoRepo.Consultar(r.Context(), consulta)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"origem":    consulta.Origem,
+		"destino":   consulta.Destino,
+		"de":        consulta.De,
+		"ate":       consulta.Ate,
+		"intervalo": consulta.Intervalo.String(),
+		"pontos":    pontos,
+	})
+}
+
 // POST /api/converter - Converter valor entre moedas
 func (s *CambioServer) PostConverter(w http.ResponseWriter, r *http.Request) {
 	s.enableCORS(w, r)
@@ -154,30 +554,30 @@ func (s *CambioServer) PostConverter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	valorConvertido, err := s.servico.CalcularConversaoComAPI(req.Valor, req.MoedaOrigem, req.MoedaDestino)
+	conversao, err := s.converter(r.Context(), &req)
 	if err != nil {
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Calcular a taxa para resposta
-	taxa := valorConvertido / req.Valor
-	if req.Valor == 0 {
-		taxa = 0
-	}
+	s.respondJSON(w, http.StatusOK, novaConversaoResponse(conversao))
+}
 
-	response := ConversaoResponse{
-		ValorOriginal:   req.Valor,
-		ValorConvertido: valorConvertido,
-		MoedaOrigem:     req.MoedaOrigem,
-		MoedaDestino:    req.MoedaDestino,
-		Taxa:            taxa,
+// converter escolhe o cálculo pedido: reverso pelo valor de destino, com
+// spread e comissão do tipo ou à taxa média
+func (s *CambioServer) converter(ctx context.Context, req *ConversaoRequest) (*cambio.Conversao, error) {
+	switch {
+	case req.reversa():
+		return s.servico.CalcularConversaoReversa(ctx, req.ValorDestino, req.MoedaOrigem, req.MoedaDestino, req.Tipo)
+	case req.Tipo != "":
+		return s.servico.CalcularOperacao(ctx, req.Valor, req.MoedaOrigem, req.MoedaDestino, req.Tipo)
+	default:
+		return s.servico.CalcularConversao(ctx, req.Valor, req.MoedaOrigem, req.MoedaDestino)
 	}
-
-	s.respondJSON(w, http.StatusOK, response)
 }
 
-// GET /api/converter?valor=100&origem=USD&destino=BRL - Converter via query params
+// GET /api/converter?valor=100&origem=USD&destino=BRL - Converter via query params.
+// Com valorDestino no lugar de valor, calcula quanto é preciso na origem.
 func (s *CambioServer) GetConverter(w http.ResponseWriter, r *http.Request) {
 	s.enableCORS(w, r)
 	if r.Method == "OPTIONS" {
@@ -185,25 +585,34 @@ func (s *CambioServer) GetConverter(w http.ResponseWriter, r *http.Request) {
 	}
 
 	valorStr := r.URL.Query().Get("valor")
+	valorDestinoStr := r.URL.Query().Get("valorDestino")
 	origem := r.URL.Query().Get("origem")
 	destino := r.URL.Query().Get("destino")
 
-	if valorStr == "" || origem == "" || destino == "" {
-		s.respondError(w, http.StatusBadRequest, "Parâmetros obrigatórios: valor, origem, destino")
-		return
-	}
-
-	valor, err := strconv.ParseFloat(valorStr, 64)
-	if err != nil {
-		s.respondError(w, http.StatusBadRequest, "Valor deve ser um número válido")
+	if (valorStr == "" && valorDestinoStr == "") || origem == "" || destino == "" {
+		s.respondError(w, http.StatusBadRequest, "Parâmetros obrigatórios: valor (ou valorDestino), origem, destino")
 		return
 	}
 
 	// Validar usando a mesma lógica
 	req := ConversaoRequest{
-		Valor:        valor,
 		MoedaOrigem:  origem,
 		MoedaDestino: destino,
+		Tipo:         r.URL.Query().Get("tipo"),
+	}
+
+	var err error
+	if valorStr != "" {
+		if req.Valor, err = moeda.NewFromString(valorStr); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Valor deve ser um número válido")
+			return
+		}
+	}
+	if valorDestinoStr != "" {
+		if req.ValorDestino, err = moeda.NewFromString(valorDestinoStr); err != nil {
+			s.respondError(w, http.StatusBadRequest, "valorDestino deve ser um número válido")
+			return
+		}
 	}
 
 	if err := req.Validate(); err != nil {
@@ -211,27 +620,51 @@ func (s *CambioServer) GetConverter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	valorConvertido, err := s.servico.CalcularConversaoComAPI(valor, origem, destino)
+	conversao, err := s.converter(r.Context(), &req)
 	if err != nil {
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Calcular a taxa para resposta
-	taxa := valorConvertido / valor
-	if valor == 0 {
-		taxa = 0
+	s.respondJSON(w, http.StatusOK, novaConversaoResponse(conversao))
+}
+
+// POST /api/converter/lote - Converter vários valores com as mesmas taxas
+func (s *CambioServer) PostConverterLote(w http.ResponseWriter, r *http.Request) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
 	}
 
-	response := ConversaoResponse{
-		ValorOriginal:   valor,
-		ValorConvertido: valorConvertido,
-		MoedaOrigem:     origem,
-		MoedaDestino:    destino,
-		Taxa:            taxa,
+	var req ConversaoLoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "JSON inválido")
+		return
 	}
 
-	s.respondJSON(w, http.StatusOK, response)
+	if err := req.Validate(); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pedidos := req.itensConversao()
+	itens := make([]cambio.ItemConversao, len(pedidos))
+	for i, p := range pedidos {
+		itens[i] = cambio.ItemConversao{Valor: p.Valor, MoedaOrigem: p.MoedaOrigem, MoedaDestino: p.MoedaDestino}
+	}
+
+	lote, err := s.servico.ConverterLote(r.Context(), itens)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, novaConversaoLoteResponse(lote))
 }
 
 // POST /api/atualizar - Forçar atualização das taxas
@@ -246,16 +679,13 @@ func (s *CambioServer) PostAtualizar(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	taxas, err := s.servico.ForcarAtualizacao()
+	resultado, err := s.servico.ForcarAtualizacao(r.Context())
 	if err != nil {
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	response := TaxasResponse{
-		Taxas:  taxas,
-		Status: "updated",
-	}
+	response := novaTaxasResponse(resultado, "updated")
 
 	s.respondJSON(w, http.StatusOK, response)
 }
@@ -272,7 +702,7 @@ func (s *CambioServer) DeleteCache(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := s.servico.LimparCache()
+	err := s.servico.LimparCache(r.Context())
 	if err != nil {
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -326,12 +756,11 @@ func (s *CambioServer) GetTransacoes(w http.ResponseWriter, r *http.Request) {
 	filter.Status = r.URL.Query().Get("status")
 
 	// Parse limit e offset
+	filter.Limit = LIMITE_PADRAO_TRANSACOES
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			filter.Limit = limit
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			filter.Limit = min(limit, LIMITE_MAXIMO_TRANSACOES)
 		}
-	} else {
-		filter.Limit = 100 // Limite padrão
 	}
 
 	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
@@ -340,30 +769,130 @@ func (s *CambioServer) GetTransacoes(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Buscar transações
-	transactions, err := s.transactionRepo.GetAll(filter)
+	// Ordenação e cursor
+	ordenacao, err := cambio.ParseOrdenacao(r.URL.Query().Get("ordenar"), r.URL.Query().Get("direcao"))
 	if err != nil {
-		s.respondError(w, http.StatusInternalServerError, err.Error())
+		s.respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	filter.Ordenacao = ordenacao
+
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		cursor, err := cambio.DecodificarCursor(token)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
-	// Buscar total de registros
-	total, err := s.transactionRepo.GetTotalCount(filter)
+		// O cursor só vale na ordenação em que foi gerado; sem ordenar e
+		// direcao, a dele é mantida
+		pedida := r.URL.Query().Get("ordenar") != "" || r.URL.Query().Get("direcao") != ""
+		if pedida && cursor.Ordenacao() != ordenacao {
+			s.respondError(w, http.StatusBadRequest, cambio.ErrCursorInvalido.Error()+": gerado para outra ordenação")
+			return
+		}
+		if filter.Offset > 0 {
+			s.respondError(w, http.StatusBadRequest, "Use cursor ou offset, não ambos")
+			return
+		}
+
+		filter.Cursor = cursor
+		filter.Ordenacao = cursor.Ordenacao()
+	}
+
+	// Uma transação a mais indica se há outra página
+	leitura := filter
+	leitura.Limit++
+	transactions, err := s.transactionRepo.GetAll(r.Context(), leitura)
 	if err != nil {
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	pagina := cambio.MontarPagina(transactions, filter)
 
 	response := map[string]interface{}{
-		"transactions": transactions,
-		"total":        total,
+		"transactions": pagina.Transacoes,
 		"limit":        filter.Limit,
 		"offset":       filter.Offset,
+		"ordenar":      filter.Ordenacao.Campo,
+		"direcao":      filter.Ordenacao.Direcao(),
+	}
+	if pagina.ProximoCursor != "" {
+		response["next_cursor"] = pagina.ProximoCursor
+	}
+	if pagina.CursorAnterior != "" {
+		response["prev_cursor"] = pagina.CursorAnterior
+	}
+
+	// O total exige contar todas as transações filtradas; incluir_total=false
+	// dispensa a consulta nas páginas seguintes
+	if incluirTotal, err := strconv.ParseBool(r.URL.Query().Get("incluir_total")); err != nil || incluirTotal {
+		resumo, err := s.transactionRepo.GetResumo(r.Context(), filter)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		response["total"] = resumo.Quantidade
+		response["total_iof"] = resumo.TotalIOF
 	}
 
 	s.respondJSON(w, http.StatusOK, response)
 }
 
+// statusErroCotacao traduz os erros de execução de cotação em status HTTP
+func statusErroCotacao(err error) int {
+	switch {
+	case errors.Is(err, cambio.ErrCotacaoNaoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, cambio.ErrCotacaoExpirada):
+		return http.StatusGone
+	case errors.Is(err, cambio.ErrCotacaoDivergente):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// POST /api/cotacoes - Travar o preço de uma operação por alguns segundos
+func (s *CambioServer) PostCotacao(w http.ResponseWriter, r *http.Request) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Pegar user_id do contexto (middleware de autenticação)
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		s.respondError(w, http.StatusUnauthorized, "Usuário não autenticado")
+		return
+	}
+
+	var req cambio.CreateTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cotacao, err := s.servico.CriarCotacao(r.Context(), userID, req.ValorOrigem,
+		req.MoedaOrigem, req.MoedaDestino, req.Tipo, cambio.CategoriaIOF(req.CategoriaIOF))
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "Erro ao calcular cotação: "+err.Error())
+		return
+	}
+
+	s.respondJSON(w, http.StatusCreated, cotacao)
+}
+
 // POST /api/transacoes - Criar nova transação
 func (s *CambioServer) PostTransacao(w http.ResponseWriter, r *http.Request) {
 	s.enableCORS(w, r)
@@ -395,40 +924,111 @@ func (s *CambioServer) PostTransacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	chave := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if chave == "" {
+		s.criarTransacao(w, r, userID, &req)
+		return
+	}
+	s.criarTransacaoIdempotente(w, r, userID, chave, &req)
+}
+
+// criarTransacaoIdempotente cria a transação uma única vez por chave: as
+// repetições recebem a resposta guardada da primeira requisição
+func (s *CambioServer) criarTransacaoIdempotente(w http.ResponseWriter, r *http.Request, userID int, chave string, req *cambio.CreateTransactionRequest) {
+	if len(chave) > cambio.TAMANHO_MAXIMO_CHAVE_IDEMPOTENCIA {
+		s.respondError(w, http.StatusBadRequest,
+			fmt.Sprintf("Idempotency-Key deve ter no máximo %d caracteres", cambio.TAMANHO_MAXIMO_CHAVE_IDEMPOTENCIA))
+		return
+	}
+
+	// O hash é do pedido decodificado, indiferente a espaços, ordem dos campos
+	// e zeros à direita dos valores (100 e 100.00 são o mesmo pedido)
+	normalizado := *req
+	normalizado.ValorOrigem = req.ValorOrigem.Normalizar()
+	canonico, err := json.Marshal(&normalizado)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, "Erro ao processar requisição: "+err.Error())
+		return
+	}
+
+	agora := time.Now()
+	registro := &cambio.RegistroIdempotencia{
+		UserID:   userID,
+		Chave:    chave,
+		Hash:     cambio.HashRequisicao(canonico),
+		CriadoEm: agora,
+	}
+
+	existente, err := s.idempotencia.Reservar(r.Context(), registro, agora.Add(-s.retencaoIdempotencia))
+	if err != nil {
+		s.respondError(w, statusErroIdempotencia(err), err.Error())
+		return
+	}
+
+	if existente != nil {
+		if err := existente.Conferir(registro.Hash); err != nil {
+			s.respondError(w, statusErroIdempotencia(err), err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(existente.Status)
+		w.Write(existente.Corpo)
+		return
+	}
+
+	// Sem resposta guardada, a reserva é desfeita para permitir repetir o pedido
+	concluida := false
+	defer func() {
+		if !concluida {
+			if err := s.idempotencia.Liberar(context.WithoutCancel(r.Context()), registro); err != nil {
+				log.Printf("Erro ao liberar chave de idempotência: %v\n", err)
+			}
+		}
+	}()
+
+	gravador := &gravadorResposta{ResponseWriter: w, status: http.StatusOK}
+	s.criarTransacao(gravador, r, userID, req)
+
+	// Falhas do servidor não criaram a transação e podem ser repetidas
+	if gravador.status >= http.StatusInternalServerError {
+		return
+	}
+
+	// Mesmo sem conseguir guardar a resposta, a reserva é mantida até o fim da
+	// retenção: liberá-la permitiria duplicar a transação já criada
+	concluida = true
+	if err := s.idempotencia.Concluir(context.WithoutCancel(r.Context()), registro, gravador.status, gravador.corpo.Bytes()); err != nil {
+		log.Printf("Erro ao guardar resposta idempotente: %v\n", err)
+	}
+}
+
+// criarTransacao valida, calcula e grava a transação pedida
+func (s *CambioServer) criarTransacao(w http.ResponseWriter, r *http.Request, userID int, req *cambio.CreateTransactionRequest) {
 	// Validar requisição usando validação centralizada
 	if err := req.Validate(); err != nil {
 		s.respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Calcular o valor convertido usando o serviço de câmbio
-	valorDestino, err := s.servico.CalcularConversaoComAPI(req.ValorOrigem, req.MoedaOrigem, req.MoedaDestino)
+	agora := time.Now()
+	conversao, cotacao, err := s.calcularTransacao(r.Context(), req, userID, agora)
 	if err != nil {
-		s.respondError(w, http.StatusInternalServerError, "Erro ao calcular conversão: "+err.Error())
+		s.respondError(w, statusErroCotacao(err), err.Error())
 		return
 	}
 
-	taxa := valorDestino / req.ValorOrigem
-	if req.ValorOrigem == 0 {
-		taxa = 0
-	}
-
 	// Criar objeto de transação
 	transaction := &cambio.Transaction{
-		UserID:        userID, // Associar transação ao usuário logado
-		DataTransacao: time.Now(),
-		Tipo:          req.Tipo,
-		MoedaOrigem:   req.MoedaOrigem,
-		MoedaDestino:  req.MoedaDestino,
-		ValorOrigem:   req.ValorOrigem,
-		ValorDestino:  valorDestino,
-		TaxaCambio:    taxa,
-		Status:        "Concluído",
+		UserID: userID, // Associar transação ao usuário logado
+		Status: string(cambio.StatusPendente),
 	}
+	aplicarConversao(transaction, req, conversao, agora)
 
 	// Salvar no banco de dados
-	err = s.transactionRepo.Create(transaction)
+	err = s.transactionRepo.Create(contextoAuditado(r, userID), transaction)
 	if err != nil {
+		s.devolverCotacao(r, cotacao)
 		s.respondError(w, http.StatusInternalServerError, "Erro ao salvar transação: "+err.Error())
 		return
 	}
@@ -436,6 +1036,127 @@ func (s *CambioServer) PostTransacao(w http.ResponseWriter, r *http.Request) {
 	s.respondJSON(w, http.StatusCreated, transaction)
 }
 
+// gravadorResposta repassa a resposta ao cliente guardando status e corpo
+type gravadorResposta struct {
+	http.ResponseWriter
+	status int
+	corpo  bytes.Buffer
+}
+
+func (g *gravadorResposta) WriteHeader(status int) {
+	g.status = status
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gravadorResposta) Write(b []byte) (int, error) {
+	g.corpo.Write(b)
+	return g.ResponseWriter.Write(b)
+}
+
+// statusErroIdempotencia traduz os erros de idempotência em status HTTP
+func statusErroIdempotencia(err error) int {
+	switch {
+	case errors.Is(err, cambio.ErrIdempotenciaConflitante):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, cambio.ErrIdempotenciaEmAndamento):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// calcularTransacao precifica a operação pedida: ao preço da cotação
+// travada, se informada, ou às taxas atuais com spread, comissão e o IOF
+// vigente na data da operação
+func (s *CambioServer) calcularTransacao(ctx context.Context, req *cambio.CreateTransactionRequest, userID int, agora time.Time) (*cambio.Conversao, *cambio.CotacaoTravada, error) {
+	if req.CotacaoID != "" {
+		// Executar exatamente ao preço travado pela cotação
+		cotacao, err := s.servico.ExecutarCotacao(ctx, req.CotacaoID, userID,
+			req.ValorOrigem, req.MoedaOrigem, req.MoedaDestino, req.Tipo, cambio.CategoriaIOF(req.CategoriaIOF))
+		if err != nil {
+			return nil, nil, err
+		}
+		return cotacao.Conversao, cotacao, nil
+	}
+
+	conversao, err := s.servico.CalcularOperacao(ctx, req.ValorOrigem, req.MoedaOrigem, req.MoedaDestino, req.Tipo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Erro ao calcular conversão: %w", err)
+	}
+
+	if err := s.servico.AplicarIOF(conversao, cambio.CategoriaIOF(req.CategoriaIOF), agora); err != nil {
+		return nil, nil, fmt.Errorf("Erro ao calcular IOF: %w", err)
+	}
+
+	return conversao, nil, nil
+}
+
+// devolverCotacao faz a cotação consumida voltar a valer quando a operação
+// não foi gravada, para que ela possa ser repetida ao preço travado
+func (s *CambioServer) devolverCotacao(r *http.Request, cotacao *cambio.CotacaoTravada) {
+	if cotacao == nil {
+		return
+	}
+	if err := s.servico.DevolverCotacao(context.WithoutCancel(r.Context()), cotacao); err != nil {
+		log.Printf("Erro ao devolver cotação %s: %v\n", cotacao.ID, err)
+	}
+}
+
+// aplicarConversao copia para a transação a operação calculada
+func aplicarConversao(t *cambio.Transaction, req *cambio.CreateTransactionRequest, conversao *cambio.Conversao, agora time.Time) {
+	t.DataTransacao = agora
+	t.Tipo = req.Tipo
+	t.MoedaOrigem = req.MoedaOrigem
+	t.MoedaDestino = req.MoedaDestino
+	t.ValorOrigem = conversao.ValorOrigem
+	t.ValorDestino = conversao.ValorDestino
+	t.TaxaCambio = conversao.Taxa
+	t.TaxaMedia = conversao.TaxaMedia
+	t.Spread = conversao.Spread
+	t.Comissao = conversao.Comissao
+	t.CategoriaIOF = conversao.CategoriaIOF
+	t.AliquotaIOF = conversao.AliquotaIOF
+	t.ValorIOF = conversao.ValorIOF
+	t.FonteTaxa = string(conversao.Fonte)
+}
+
+// transacaoDoUsuario carrega a transação do caminho, desde que pertença ao
+// usuário logado. Em caso de erro, já respondeu a requisição.
+func (s *CambioServer) transacaoDoUsuario(w http.ResponseWriter, r *http.Request) (*cambio.Transaction, bool) {
+	// Se não houver repository configurado, retornar erro
+	if s.transactionRepo == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Serviço de transações não configurado")
+		return nil, false
+	}
+
+	// Pegar user_id do contexto (middleware de autenticação)
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		s.respondError(w, http.StatusUnauthorized, "Usuário não autenticado")
+		return nil, false
+	}
+
+	id, err := idTransacao(r)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "ID inválido")
+		return nil, false
+	}
+
+	transaction, err := s.transactionRepo.GetByID(r.Context(), id)
+	if err != nil {
+		s.respondError(w, statusErroTransicao(err), err.Error())
+		return nil, false
+	}
+
+	// Transações de outros usuários são tratadas como inexistentes
+	if transaction.UserID != userID {
+		s.respondError(w, http.StatusNotFound, cambio.ErrTransacaoNaoEncontrada.Error())
+		return nil, false
+	}
+
+	return transaction, true
+}
+
 // GET /api/transacoes/:id - Buscar transação por ID
 func (s *CambioServer) GetTransacaoByID(w http.ResponseWriter, r *http.Request) {
 	s.enableCORS(w, r)
@@ -443,23 +1164,200 @@ func (s *CambioServer) GetTransacaoByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Se não houver repository configurado, retornar erro
-	if s.transactionRepo == nil {
-		s.respondError(w, http.StatusServiceUnavailable, "Serviço de transações não configurado")
+	transaction, ok := s.transacaoDoUsuario(w, r)
+	if !ok {
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, transaction)
+}
+
+// PUT /api/transacoes/:id - Substituir a operação de uma transação pendente
+func (s *CambioServer) PutTransacao(w http.ResponseWriter, r *http.Request) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
 		return
 	}
 
-	// Extrair ID da URL (assumindo formato /api/transacoes/123)
-	idStr := r.URL.Path[len("/api/transacoes/"):]
-	id, err := strconv.Atoi(idStr)
+	transaction, ok := s.transacaoDoUsuario(w, r)
+	if !ok {
+		return
+	}
+
+	var req cambio.CreateTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	s.atualizarTransacao(w, r, transaction, &req)
+}
+
+// PATCH /api/transacoes/:id - Alterar campos de uma transação pendente
+func (s *CambioServer) PatchTransacao(w http.ResponseWriter, r *http.Request) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	transaction, ok := s.transacaoDoUsuario(w, r)
+	if !ok {
+		return
+	}
+
+	var alteracoes cambio.AtualizarTransacaoRequest
+	if err := json.NewDecoder(r.Body).Decode(&alteracoes); err != nil {
+		s.respondError(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	req := alteracoes.Aplicar(cambio.RequisicaoDaTransacao(transaction))
+	s.atualizarTransacao(w, r, transaction, &req)
+}
+
+// atualizarTransacao recalcula uma transação pendente com o pedido
+// informado e grava o resultado
+func (s *CambioServer) atualizarTransacao(w http.ResponseWriter, r *http.Request, transaction *cambio.Transaction, req *cambio.CreateTransactionRequest) {
+	if err := transaction.Editavel(); err != nil {
+		s.respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	agora := time.Now()
+	conversao, cotacao, err := s.calcularTransacao(r.Context(), req, transaction.UserID, agora)
 	if err != nil {
-		s.respondError(w, http.StatusBadRequest, "ID inválido")
+		s.respondError(w, statusErroCotacao(err), err.Error())
+		return
+	}
+	aplicarConversao(transaction, req, conversao, agora)
+
+	if err := s.transactionRepo.Update(contextoAuditado(r, transaction.UserID), transaction); err != nil {
+		s.devolverCotacao(r, cotacao)
+		s.respondError(w, statusErroTransicao(err), err.Error())
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, transaction)
+}
+
+// DELETE /api/transacoes/:id - Excluir transação (exclusão lógica)
+func (s *CambioServer) DeleteTransacao(w http.ResponseWriter, r *http.Request) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	transaction, ok := s.transacaoDoUsuario(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.transactionRepo.Delete(contextoAuditado(r, transaction.UserID), transaction.ID); err != nil {
+		s.respondError(w, statusErroTransicao(err), err.Error())
 		return
 	}
 
-	transaction, err := s.transactionRepo.GetByID(id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/transacoes/:id/historico - Trilha de auditoria da transação
+func (s *CambioServer) GetHistoricoTransacao(w http.ResponseWriter, r *http.Request) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	transaction, ok := s.transacaoDoUsuario(w, r)
+	if !ok {
+		return
+	}
+
+	alteracoes, err := s.transactionRepo.GetAuditoria(r.Context(), transaction.ID)
 	if err != nil {
-		s.respondError(w, http.StatusNotFound, err.Error())
+		s.respondError(w, http.StatusInternalServerError, "Erro ao buscar histórico: "+err.Error())
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, HistoricoTransacaoResponse{
+		TransacaoID: transaction.ID,
+		Alteracoes:  alteracoes,
+	})
+}
+
+// contextoAuditado anexa ao contexto da requisição o usuário e o ID da
+// requisição, que a trilha de auditoria grava junto com cada alteração
+func contextoAuditado(r *http.Request, userID int) context.Context {
+	requestID := chimiddleware.GetReqID(r.Context())
+	if requestID == "" {
+		// Fora do router chi, aproveita o ID enviado pelo cliente ou proxy
+		requestID = r.Header.Get(chimiddleware.RequestIDHeader)
+	}
+	return cambio.ComAutoria(r.Context(), cambio.Autoria{UserID: userID, RequestID: requestID})
+}
+
+// idTransacao extrai o ID de caminhos como /api/transacoes/123 e
+// /api/transacoes/123/confirmar
+func idTransacao(r *http.Request) (int, error) {
+	resto := strings.TrimPrefix(r.URL.Path, "/api/transacoes/")
+	idStr, _, _ := strings.Cut(resto, "/")
+	return strconv.Atoi(idStr)
+}
+
+// statusErroTransicao traduz os erros de mudança de status em status HTTP
+func statusErroTransicao(err error) int {
+	switch {
+	case errors.Is(err, cambio.ErrTransacaoNaoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, cambio.ErrTransicaoNaoPermitida):
+		return http.StatusForbidden
+	case errors.Is(err, cambio.ErrTransicaoInvalida), errors.Is(err, cambio.ErrTransacaoNaoEditavel):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// POST /api/transacoes/:id/confirmar - Confirmar transação pendente
+func (s *CambioServer) PostConfirmarTransacao(w http.ResponseWriter, r *http.Request) {
+	s.transicionarTransacao(w, r, cambio.StatusConcluido)
+}
+
+// POST /api/transacoes/:id/cancelar - Cancelar transação pendente
+func (s *CambioServer) PostCancelarTransacao(w http.ResponseWriter, r *http.Request) {
+	s.transicionarTransacao(w, r, cambio.StatusCancelado)
+}
+
+// transicionarTransacao leva uma transação do usuário logado ao novo status,
+// se a máquina de estados permitir ao cliente
+func (s *CambioServer) transicionarTransacao(w http.ResponseWriter, r *http.Request, para cambio.StatusTransacao) {
+	s.enableCORS(w, r)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	transaction, ok := s.transacaoDoUsuario(w, r)
+	if !ok {
+		return
+	}
+
+	de := cambio.StatusTransacao(transaction.Status)
+	if err := transaction.Transicionar(para, cambio.AtorCliente, time.Now()); err != nil {
+		s.respondError(w, statusErroTransicao(err), err.Error())
+		return
+	}
+
+	if err := s.transactionRepo.AtualizarStatus(contextoAuditado(r, transaction.UserID), transaction, de); err != nil {
+		s.respondError(w, statusErroTransicao(err), err.Error())
 		return
 	}
 
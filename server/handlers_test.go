@@ -0,0 +1,790 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-project/cambio"
+	"golang-project/moeda"
+)
+
+// servicoFalso responde com taxas fixas; métodos não sobrescritos entram em pânico
+type servicoFalso struct {
+	cambio.ServicoTaxas
+	resultado *cambio.ResultadoTaxas
+	err       error
+}
+
+func (f *servicoFalso) ObterTaxas(ctx context.Context) (*cambio.ResultadoTaxas, error) {
+	return f.resultado, f.err
+}
+
+func (f *servicoFalso) CalcularConversao(ctx context.Context, valor moeda.Decimal, moedaOrigem, moedaDestino string) (*cambio.Conversao, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return cambio.NewCambioClientComProvedor(nil).CalcularConversao(valor, moedaOrigem, moedaDestino, f.resultado.Taxas)
+}
+
+func (f *servicoFalso) ConverterLote(ctx context.Context, itens []cambio.ItemConversao) (*cambio.LoteConversoes, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	lote := &cambio.LoteConversoes{ObtidoEm: f.resultado.ObtidoEm, Obsoleto: f.resultado.Obsoleto}
+	for _, item := range itens {
+		conversao, err := f.CalcularConversao(ctx, item.Valor, item.MoedaOrigem, item.MoedaDestino)
+		lote.Resultados = append(lote.Resultados, cambio.ResultadoConversao{Conversao: conversao, Erro: err})
+	}
+	return lote, nil
+}
+
+// CalcularConversaoReversa devolve uma conversão fixa que registra o valor pedido
+func (f *servicoFalso) CalcularConversaoReversa(ctx context.Context, valorDestino moeda.Decimal, moedaOrigem, moedaDestino, tipo string) (*cambio.Conversao, error) {
+	return &cambio.Conversao{
+		ValorOrigem:  moeda.MustFromString("5400.00"),
+		ValorDestino: valorDestino,
+		MoedaOrigem:  moedaOrigem,
+		MoedaDestino: moedaDestino,
+		Taxa:         moeda.MustFromString("0.18518519"),
+		Tipo:         tipo,
+	}, nil
+}
+
+func (f *servicoFalso) CalcularOperacao(ctx context.Context, valor moeda.Decimal, moedaOrigem, moedaDestino, tipo string) (*cambio.Conversao, error) {
+	conversao, err := f.CalcularConversao(ctx, valor, moedaOrigem, moedaDestino)
+	if err != nil {
+		return nil, err
+	}
+	conversao.Tipo = tipo
+	return conversao, nil
+}
+
+func (f *servicoFalso) AplicarIOF(conversao *cambio.Conversao, categoria cambio.CategoriaIOF, data time.Time) error {
+	conversao.CategoriaIOF = string(categoria)
+	return nil
+}
+
+func novoServicoFalso() *servicoFalso {
+	resultado := cambio.NewResultadoTaxas()
+	resultado.Taxas["USD"] = map[string]float64{"BRL": 5.0}
+	resultado.ObtidoEm = time.Now().Add(-2 * time.Hour)
+	resultado.Obsoleto = true
+	return &servicoFalso{resultado: resultado}
+}
+
+func TestGetTaxasUsaOServico(t *testing.T) {
+	servidor := NewCambioServer(novoServicoFalso())
+
+	rec := httptest.NewRecorder()
+	servidor.GetTaxas(rec, httptest.NewRequest(http.MethodGet, "/api/taxas", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status esperado 200, obtido %d: %s", rec.Code, rec.Body)
+	}
+
+	var resposta TaxasResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resposta); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if resposta.Taxas["USD"]["BRL"] != 5.0 || !resposta.Obsoleto {
+		t.Errorf("esperadas as taxas obsoletas do serviço, obtido %+v", resposta)
+	}
+	if resposta.IdadeSegundos < 7000 {
+		t.Errorf("idade esperada de cerca de 2h, obtida %ds", resposta.IdadeSegundos)
+	}
+}
+
+func TestGetTaxasErroDoServico(t *testing.T) {
+	servidor := NewCambioServer(&servicoFalso{err: errors.New("API fora do ar")})
+
+	rec := httptest.NewRecorder()
+	servidor.GetTaxas(rec, httptest.NewRequest(http.MethodGet, "/api/taxas", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status esperado 500, obtido %d", rec.Code)
+	}
+}
+
+func TestPostConverter(t *testing.T) {
+	servidor := NewCambioServer(novoServicoFalso())
+
+	corpo := strings.NewReader(`{"valor": "100", "moedaOrigem": "USD", "moedaDestino": "BRL"}`)
+	rec := httptest.NewRecorder()
+	servidor.PostConverter(rec, httptest.NewRequest(http.MethodPost, "/api/converter", corpo))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status esperado 200, obtido %d: %s", rec.Code, rec.Body)
+	}
+
+	var resposta ConversaoResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resposta); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if resposta.ValorConvertido.String() != "500.00" {
+		t.Errorf("valor convertido esperado 500.00, obtido %s", resposta.ValorConvertido)
+	}
+}
+
+func TestPostConverterValidaRequisicao(t *testing.T) {
+	servidor := NewCambioServer(novoServicoFalso())
+
+	corpo := strings.NewReader(`{"valor": "-1", "moedaOrigem": "USD", "moedaDestino": "BRL"}`)
+	rec := httptest.NewRecorder()
+	servidor.PostConverter(rec, httptest.NewRequest(http.MethodPost, "/api/converter", corpo))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status esperado 400, obtido %d", rec.Code)
+	}
+}
+
+func TestConverterPeloValorDeDestino(t *testing.T) {
+	servidor := NewCambioServer(novoServicoFalso())
+
+	rec := httptest.NewRecorder()
+	url := "/api/converter?valorDestino=1000&origem=BRL&destino=USD"
+	servidor.GetConverter(rec, httptest.NewRequest(http.MethodGet, url, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status esperado 200, obtido %d: %s", rec.Code, rec.Body)
+	}
+
+	var resposta ConversaoResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resposta); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if resposta.ValorOriginal.String() != "5400.00" || resposta.ValorConvertido.String() != "1000" {
+		t.Errorf("esperados os dois valores da conversão reversa, obtido %+v", resposta)
+	}
+	if resposta.TaxaEfetiva.String() != "0.18518519" {
+		t.Errorf("taxa efetiva esperada 0.18518519, obtida %s", resposta.TaxaEfetiva)
+	}
+}
+
+func TestConverterRejeitaValorEValorDestino(t *testing.T) {
+	servidor := NewCambioServer(novoServicoFalso())
+
+	corpo := strings.NewReader(`{"valor": "10", "valorDestino": "1000", "moedaOrigem": "BRL", "moedaDestino": "USD"}`)
+	rec := httptest.NewRecorder()
+	servidor.PostConverter(rec, httptest.NewRequest(http.MethodPost, "/api/converter", corpo))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status esperado 400, obtido %d", rec.Code)
+	}
+}
+
+func TestPostConverterLoteUmValorParaVariasMoedas(t *testing.T) {
+	servico := novoServicoFalso()
+	servico.resultado.Taxas["USD"]["EUR"] = 0.9
+	servidor := NewCambioServer(servico)
+
+	corpo := strings.NewReader(`{"valor": "100", "moedaOrigem": "USD", "moedasDestino": ["BRL", "EUR", "GBP"]}`)
+	rec := httptest.NewRecorder()
+	servidor.PostConverterLote(rec, httptest.NewRequest(http.MethodPost, "/api/converter/lote", corpo))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status esperado 200, obtido %d: %s", rec.Code, rec.Body)
+	}
+
+	var resposta ConversaoLoteResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resposta); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if len(resposta.Conversoes) != 3 || !resposta.ObtidoEm.Equal(servico.resultado.ObtidoEm) || !resposta.Obsoleto {
+		t.Fatalf("esperadas 3 conversões com o instante das taxas, obtido %+v", resposta)
+	}
+	if c := resposta.Conversoes[0]; c.ConversaoResponse == nil || c.ValorConvertido.String() != "500.00" {
+		t.Errorf("USD->BRL: esperado 500.00, obtido %+v", c)
+	}
+	if c := resposta.Conversoes[1]; c.ConversaoResponse == nil || c.MoedaDestino != "EUR" {
+		t.Errorf("USD->EUR: esperada conversão na ordem pedida, obtido %+v", c)
+	}
+	if c := resposta.Conversoes[2]; c.Erro == "" || c.ConversaoResponse != nil {
+		t.Errorf("USD->GBP: esperado erro apenas neste item, obtido %+v", c)
+	}
+}
+
+func TestPostConverterLoteValidaItens(t *testing.T) {
+	servidor := NewCambioServer(novoServicoFalso())
+
+	casos := map[string]string{
+		"vazio":         `{}`,
+		"formas mistas": `{"itens": [{"valor": "1", "moedaOrigem": "USD", "moedaDestino": "BRL"}], "moedaOrigem": "USD", "moedasDestino": ["EUR"]}`,
+		"item inválido": `{"itens": [{"valor": "1", "moedaOrigem": "USD", "moedaDestino": "BRL"}, {"valor": "0", "moedaOrigem": "USD", "moedaDestino": "BRL"}]}`,
+	}
+	for nome, corpo := range casos {
+		rec := httptest.NewRecorder()
+		servidor.PostConverterLote(rec, httptest.NewRequest(http.MethodPost, "/api/converter/lote", strings.NewReader(corpo)))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status esperado 400, obtido %d", nome, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	corpo := `{"itens": [{"valor": "1", "moedaOrigem": "USD", "moedaDestino": "BRL"}, {"valor": "0", "moedaOrigem": "USD", "moedaDestino": "BRL"}]}`
+	servidor.PostConverterLote(rec, httptest.NewRequest(http.MethodPost, "/api/converter/lote", strings.NewReader(corpo)))
+	if !strings.Contains(rec.Body.String(), "itens[1].valor") {
+		t.Errorf("erro deveria apontar o item inválido, obtido %s", rec.Body)
+	}
+}
+
+// servicoComSaude acrescenta o estado dos provedores ao servicoFalso
+type servicoComSaude struct {
+	*servicoFalso
+	saude []cambio.SaudeProvedor
+}
+
+func (f *servicoComSaude) Saude() []cambio.SaudeProvedor {
+	return f.saude
+}
+
+func TestGetHealthIndicaCircuitoAberto(t *testing.T) {
+	servico := &servicoComSaude{
+		servicoFalso: novoServicoFalso(),
+		saude: []cambio.SaudeProvedor{
+			{Nome: "fxratesapi", Circuito: cambio.CircuitoAberto, FalhasSeguidas: 5},
+			{Nome: "ecb", Circuito: cambio.CircuitoFechado},
+		},
+	}
+	servidor := NewCambioServer(servico)
+
+	rec := httptest.NewRecorder()
+	servidor.GetHealth(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var resposta HealthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resposta); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if resposta.Status != "degradado" || len(resposta.Provedores) != 2 {
+		t.Errorf("esperado status degradado com 2 provedores, obtido %+v", resposta)
+	}
+}
+
+// transacoesFalsas guarda transações em memória; métodos não sobrescritos entram em pânico
+type transacoesFalsas struct {
+	cambio.TransactionRepository
+	transacoes map[int]*cambio.Transaction
+	auditoria  []cambio.RegistroAuditoria
+	criadas    int
+	resumos    int
+	// errGravar simula uma falha do banco em Create e Update
+	errGravar error
+}
+
+// GetAll lista por ID decrescente, continuando após o cursor
+func (f *transacoesFalsas) GetAll(ctx context.Context, filter cambio.TransactionFilter) ([]cambio.Transaction, error) {
+	var transacoes []cambio.Transaction
+	for id := 1000; id > 0 && len(transacoes) < filter.Limit; id-- {
+		if t, existe := f.transacoes[id]; existe && (filter.Cursor == nil || id < filter.Cursor.ID) {
+			transacoes = append(transacoes, *t)
+		}
+	}
+	return transacoes, nil
+}
+
+func (f *transacoesFalsas) GetResumo(ctx context.Context, filter cambio.TransactionFilter) (*cambio.ResumoTransacoes, error) {
+	f.resumos++
+	return &cambio.ResumoTransacoes{Quantidade: len(f.transacoes)}, nil
+}
+
+// auditar imita o trigger de auditoria, registrando o autor do contexto
+func (f *transacoesFalsas) auditar(ctx context.Context, id int, operacao cambio.OperacaoAuditoria) {
+	autoria := cambio.AutoriaDe(ctx)
+	f.auditoria = append(f.auditoria, cambio.RegistroAuditoria{
+		ID:          int64(len(f.auditoria) + 1),
+		TransacaoID: id,
+		Operacao:    operacao,
+		UserID:      &autoria.UserID,
+		RequestID:   autoria.RequestID,
+	})
+}
+
+func (f *transacoesFalsas) GetAuditoria(ctx context.Context, transacaoID int) ([]cambio.RegistroAuditoria, error) {
+	var registros []cambio.RegistroAuditoria
+	for _, registro := range f.auditoria {
+		if registro.TransacaoID == transacaoID {
+			registros = append(registros, registro)
+		}
+	}
+	return registros, nil
+}
+
+func (f *transacoesFalsas) Create(ctx context.Context, t *cambio.Transaction) error {
+	if f.errGravar != nil {
+		return f.errGravar
+	}
+	f.criadas++
+	t.ID = 100 + f.criadas
+	copia := *t
+	f.transacoes[t.ID] = &copia
+	return nil
+}
+
+func (f *transacoesFalsas) GetByID(ctx context.Context, id int) (*cambio.Transaction, error) {
+	t, existe := f.transacoes[id]
+	if !existe {
+		return nil, cambio.ErrTransacaoNaoEncontrada
+	}
+	copia := *t
+	return &copia, nil
+}
+
+func (f *transacoesFalsas) AtualizarStatus(ctx context.Context, t *cambio.Transaction, de cambio.StatusTransacao) error {
+	gravada, existe := f.transacoes[t.ID]
+	if !existe {
+		return cambio.ErrTransacaoNaoEncontrada
+	}
+	if gravada.Status != string(de) {
+		return cambio.ErrTransicaoInvalida
+	}
+	copia := *t
+	f.transacoes[t.ID] = &copia
+	f.auditar(ctx, t.ID, cambio.OperacaoAlteracao)
+	return nil
+}
+
+func (f *transacoesFalsas) Update(ctx context.Context, t *cambio.Transaction) error {
+	if f.errGravar != nil {
+		return f.errGravar
+	}
+	gravada, existe := f.transacoes[t.ID]
+	if !existe {
+		return cambio.ErrTransacaoNaoEncontrada
+	}
+	if err := gravada.Editavel(); err != nil {
+		return err
+	}
+	copia := *t
+	f.transacoes[t.ID] = &copia
+	f.auditar(ctx, t.ID, cambio.OperacaoAlteracao)
+	return nil
+}
+
+func (f *transacoesFalsas) Delete(ctx context.Context, id int) error {
+	if _, existe := f.transacoes[id]; !existe {
+		return cambio.ErrTransacaoNaoEncontrada
+	}
+	delete(f.transacoes, id)
+	return nil
+}
+
+func novoServidorComTransacoes(transacoes ...*cambio.Transaction) (*CambioServer, *transacoesFalsas) {
+	repo := &transacoesFalsas{transacoes: make(map[int]*cambio.Transaction)}
+	for _, t := range transacoes {
+		repo.transacoes[t.ID] = t
+	}
+	servidor := NewCambioServer(novoServicoFalso())
+	servidor.transactionRepo = repo
+	return servidor, repo
+}
+
+// requisicaoAutenticada simula o AuthMiddleware, que guarda o usuário no contexto
+func requisicaoAutenticada(metodo, url string, userID int) *http.Request {
+	return requisicaoAutenticadaCom(metodo, url, "", userID)
+}
+
+func requisicaoAutenticadaCom(metodo, url, corpo string, userID int) *http.Request {
+	req := httptest.NewRequest(metodo, url, strings.NewReader(corpo))
+	return req.WithContext(context.WithValue(req.Context(), "user_id", userID))
+}
+
+func TestConfirmarTransacaoPendente(t *testing.T) {
+	servidor, repo := novoServidorComTransacoes(&cambio.Transaction{ID: 7, UserID: 1, Status: string(cambio.StatusPendente)})
+
+	rec := httptest.NewRecorder()
+	servidor.PostConfirmarTransacao(rec, requisicaoAutenticada(http.MethodPost, "/api/transacoes/7/confirmar", 1))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status esperado 200, obtido %d: %s", rec.Code, rec.Body)
+	}
+	if gravada := repo.transacoes[7]; gravada.Status != string(cambio.StatusConcluido) || gravada.ConfirmadaEm == nil {
+		t.Errorf("transação deveria ser gravada como concluída, obtido %+v", gravada)
+	}
+
+	// Uma transação concluída não volta a ser confirmada nem pode ser cancelada pelo cliente
+	rec = httptest.NewRecorder()
+	servidor.PostConfirmarTransacao(rec, requisicaoAutenticada(http.MethodPost, "/api/transacoes/7/confirmar", 1))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("confirmar de novo: status esperado 409, obtido %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	servidor.PostCancelarTransacao(rec, requisicaoAutenticada(http.MethodPost, "/api/transacoes/7/cancelar", 1))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("cancelar concluída: status esperado 403, obtido %d", rec.Code)
+	}
+}
+
+func TestCancelarTransacaoDeOutroUsuario(t *testing.T) {
+	servidor, repo := novoServidorComTransacoes(&cambio.Transaction{ID: 7, UserID: 1, Status: string(cambio.StatusPendente)})
+
+	rec := httptest.NewRecorder()
+	servidor.PostCancelarTransacao(rec, requisicaoAutenticada(http.MethodPost, "/api/transacoes/7/cancelar", 2))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status esperado 404, obtido %d", rec.Code)
+	}
+	if repo.transacoes[7].Status != string(cambio.StatusPendente) {
+		t.Error("transação de outro usuário não deveria mudar")
+	}
+}
+
+func TestRotearTransacaoSemChi(t *testing.T) {
+	casos := []struct {
+		metodo, caminho string
+		status          int
+	}{
+		{http.MethodGet, "/api/transacoes/7", http.StatusOK},
+		{http.MethodPost, "/api/transacoes/7", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/transacoes/7/historico", http.StatusOK},
+		{http.MethodPost, "/api/transacoes/7/historico", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/transacoes/7/confirmar", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/api/transacoes/7/cancelar", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/transacoes/x/7/confirmar", http.StatusNotFound},
+		{http.MethodPost, "/api/transacoes/7/confirmar/", http.StatusNotFound},
+		{http.MethodGet, "/api/transacoes/7/", http.StatusNotFound},
+		{http.MethodGet, "/api/transacoes/7/outra", http.StatusNotFound},
+		{http.MethodGet, "/api/transacoes/", http.StatusNotFound},
+		{http.MethodOptions, "/api/transacoes/7/confirmar", http.StatusOK},
+	}
+
+	for _, c := range casos {
+		servidor, repo := novoServidorComTransacoes(transacaoPendente())
+		rec := httptest.NewRecorder()
+		servidor.rotearTransacao(rec, requisicaoAutenticada(c.metodo, c.caminho, 1))
+
+		if rec.Code != c.status {
+			t.Errorf("%s %s: status esperado %d, obtido %d", c.metodo, c.caminho, c.status, rec.Code)
+		}
+		if repo.transacoes[7].Status != string(cambio.StatusPendente) {
+			t.Errorf("%s %s: a transação não deveria mudar de status", c.metodo, c.caminho)
+		}
+	}
+
+	// A rota exata ainda confirma
+	servidor, repo := novoServidorComTransacoes(transacaoPendente())
+	rec := httptest.NewRecorder()
+	servidor.rotearTransacao(rec, requisicaoAutenticada(http.MethodPost, "/api/transacoes/7/confirmar", 1))
+	if rec.Code != http.StatusOK || repo.transacoes[7].Status != string(cambio.StatusConcluido) {
+		t.Errorf("POST confirmar: esperado 200 e transação concluída, obtido %d (%s)", rec.Code, repo.transacoes[7].Status)
+	}
+}
+
+func transacaoPendente() *cambio.Transaction {
+	return &cambio.Transaction{
+		ID:           7,
+		UserID:       1,
+		Tipo:         "Compra",
+		MoedaOrigem:  "USD",
+		MoedaDestino: "BRL",
+		ValorOrigem:  moeda.NewFromInt(10),
+		ValorDestino: moeda.NewFromInt(50),
+		Status:       string(cambio.StatusPendente),
+	}
+}
+
+func TestGetTransacaoDeOutroUsuario(t *testing.T) {
+	servidor, _ := novoServidorComTransacoes(transacaoPendente())
+
+	rec := httptest.NewRecorder()
+	servidor.GetTransacaoByID(rec, requisicaoAutenticada(http.MethodGet, "/api/transacoes/7", 2))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("outro usuário: status esperado 404, obtido %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	servidor.GetTransacaoByID(rec, requisicaoAutenticada(http.MethodGet, "/api/transacoes/7", 1))
+	if rec.Code != http.StatusOK {
+		t.Errorf("dono: status esperado 200, obtido %d", rec.Code)
+	}
+}
+
+func TestPatchTransacaoRecalculaPendente(t *testing.T) {
+	servidor, repo := novoServidorComTransacoes(transacaoPendente())
+
+	rec := httptest.NewRecorder()
+	req := requisicaoAutenticadaCom(http.MethodPatch, "/api/transacoes/7", `{"valor_origem": "20"}`, 1)
+	servidor.PatchTransacao(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status esperado 200, obtido %d: %s", rec.Code, rec.Body)
+	}
+	gravada := repo.transacoes[7]
+	if gravada.ValorOrigem.String() != "20.00" || gravada.ValorDestino.String() != "100.00" || gravada.Tipo != "Compra" {
+		t.Errorf("esperada a transação recalculada mantendo os demais campos, obtido %+v", gravada)
+	}
+}
+
+func TestPutTransacaoConcluidaRejeitada(t *testing.T) {
+	concluida := transacaoPendente()
+	concluida.Status = string(cambio.StatusConcluido)
+	servidor, repo := novoServidorComTransacoes(concluida)
+
+	rec := httptest.NewRecorder()
+	corpo := `{"tipo": "Venda", "moeda_origem": "USD", "moeda_destino": "BRL", "valor_origem": "20"}`
+	servidor.PutTransacao(rec, requisicaoAutenticadaCom(http.MethodPut, "/api/transacoes/7", corpo, 1))
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status esperado 409, obtido %d", rec.Code)
+	}
+	if repo.transacoes[7].Tipo != "Compra" {
+		t.Error("transação concluída não deveria ser alterada")
+	}
+}
+
+func TestDeleteTransacao(t *testing.T) {
+	servidor, repo := novoServidorComTransacoes(transacaoPendente())
+
+	rec := httptest.NewRecorder()
+	servidor.DeleteTransacao(rec, requisicaoAutenticada(http.MethodDelete, "/api/transacoes/7", 2))
+	if rec.Code != http.StatusNotFound || len(repo.transacoes) != 1 {
+		t.Fatalf("outro usuário não deveria excluir: status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	servidor.DeleteTransacao(rec, requisicaoAutenticada(http.MethodDelete, "/api/transacoes/7", 1))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status esperado 204, obtido %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	servidor.GetTransacaoByID(rec, requisicaoAutenticada(http.MethodGet, "/api/transacoes/7", 1))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("transação excluída não deveria ser encontrada, status %d", rec.Code)
+	}
+}
+
+func TestHistoricoTransacaoRegistraAutorERequisicao(t *testing.T) {
+	servidor, _ := novoServidorComTransacoes(transacaoPendente())
+
+	req := requisicaoAutenticada(http.MethodPost, "/api/transacoes/7/confirmar", 1)
+	req.Header.Set("X-Request-Id", "req-123")
+	servidor.PostConfirmarTransacao(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	servidor.GetHistoricoTransacao(rec, requisicaoAutenticada(http.MethodGet, "/api/transacoes/7/historico", 1))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status esperado 200, obtido %d: %s", rec.Code, rec.Body)
+	}
+
+	var resposta HistoricoTransacaoResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resposta); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if resposta.TransacaoID != 7 || len(resposta.Alteracoes) != 1 {
+		t.Fatalf("esperada 1 alteração da transação 7, obtido %+v", resposta)
+	}
+	alteracao := resposta.Alteracoes[0]
+	if alteracao.UserID == nil || *alteracao.UserID != 1 || alteracao.RequestID != "req-123" {
+		t.Errorf("esperado autor 1 na requisição req-123, obtido %+v", alteracao)
+	}
+
+	rec = httptest.NewRecorder()
+	servidor.GetHistoricoTransacao(rec, requisicaoAutenticada(http.MethodGet, "/api/transacoes/7/historico", 2))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("outro usuário: status esperado 404, obtido %d", rec.Code)
+	}
+}
+
+// servicoComCotacoes executa e devolve cotações guardadas em memória
+type servicoComCotacoes struct {
+	*servicoFalso
+	cotacoes *cambio.CotacoesEmMemoria
+}
+
+func (f *servicoComCotacoes) ExecutarCotacao(ctx context.Context, id string, userID int, valor moeda.Decimal, moedaOrigem, moedaDestino, tipo string, categoria cambio.CategoriaIOF) (*cambio.CotacaoTravada, error) {
+	return f.cotacoes.Consumir(ctx, id, userID, func(c *cambio.CotacaoTravada) error {
+		return c.Confere(tipo, moedaOrigem, moedaDestino, valor, categoria)
+	})
+}
+
+func (f *servicoComCotacoes) DevolverCotacao(ctx context.Context, cotacao *cambio.CotacaoTravada) error {
+	return f.cotacoes.Salvar(ctx, cotacao)
+}
+
+func TestCotacaoVoltaAValerSeATransacaoNaoForGravada(t *testing.T) {
+	servico := &servicoComCotacoes{servicoFalso: novoServicoFalso(), cotacoes: cambio.NewCotacoesEmMemoria()}
+	servico.cotacoes.Salvar(context.Background(), &cambio.CotacaoTravada{
+		ID: "c1",
+		Conversao: &cambio.Conversao{
+			ValorOrigem:  moeda.MustFromString("20"),
+			ValorDestino: moeda.MustFromString("100"),
+			MoedaOrigem:  "USD",
+			MoedaDestino: "BRL",
+			Taxa:         moeda.MustFromString("5"),
+			Tipo:         "Compra",
+			CategoriaIOF: string(cambio.IOFEspecie),
+		},
+		UserID:   1,
+		CriadaEm: time.Now(),
+		ExpiraEm: time.Now().Add(time.Minute),
+	})
+
+	repo := &transacoesFalsas{transacoes: make(map[int]*cambio.Transaction), errGravar: errors.New("conexão perdida")}
+	servidor := NewCambioServer(servico)
+	servidor.transactionRepo = repo
+	corpo := `{"tipo": "Compra", "moeda_origem": "USD", "moeda_destino": "BRL", "valor_origem": "20", "cotacao_id": "c1"}`
+
+	rec := httptest.NewRecorder()
+	servidor.PostTransacao(rec, requisicaoAutenticadaCom(http.MethodPost, "/api/transacoes", corpo, 1))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status esperado 500, obtido %d: %s", rec.Code, rec.Body)
+	}
+
+	// Com o banco de volta, a mesma cotação é executada ao preço travado
+	repo.errGravar = nil
+	rec = httptest.NewRecorder()
+	servidor.PostTransacao(rec, requisicaoAutenticadaCom(http.MethodPost, "/api/transacoes", corpo, 1))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status esperado 201, obtido %d: %s", rec.Code, rec.Body)
+	}
+	if repo.criadas != 1 || repo.transacoes[101].ValorDestino.String() != "100" {
+		t.Errorf("transação deveria ser criada ao preço cotado, obtido %+v", repo.transacoes[101])
+	}
+}
+
+func postTransacaoIdempotente(servidor *CambioServer, chave, corpo string) *httptest.ResponseRecorder {
+	req := requisicaoAutenticadaCom(http.MethodPost, "/api/transacoes", corpo, 1)
+	req.Header.Set("Idempotency-Key", chave)
+	rec := httptest.NewRecorder()
+	servidor.PostTransacao(rec, req)
+	return rec
+}
+
+func TestPostTransacaoIdempotente(t *testing.T) {
+	servidor, repo := novoServidorComTransacoes()
+	corpo := `{"tipo": "Compra", "moeda_origem": "USD", "moeda_destino": "BRL", "valor_origem": "20"}`
+
+	primeira := postTransacaoIdempotente(servidor, "chave-1", corpo)
+	if primeira.Code != http.StatusCreated {
+		t.Fatalf("status esperado 201, obtido %d: %s", primeira.Code, primeira.Body)
+	}
+
+	// Mesmo pedido com espaçamento diferente: repete a resposta sem criar outra transação
+	repetida := postTransacaoIdempotente(servidor, "chave-1", strings.ReplaceAll(corpo, " ", ""))
+	if repetida.Code != http.StatusCreated || repetida.Body.String() != primeira.Body.String() {
+		t.Errorf("esperada a mesma resposta, obtido %d: %s", repetida.Code, repetida.Body)
+	}
+	if repetida.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("resposta repetida deveria ser sinalizada em Idempotent-Replayed")
+	}
+	// O mesmo valor com outra escala (20.00) também é o mesmo pedido
+	escala := postTransacaoIdempotente(servidor, "chave-1", strings.Replace(corpo, `"20"`, `20.00`, 1))
+	if escala.Code != http.StatusCreated || escala.Body.String() != primeira.Body.String() {
+		t.Errorf("valor com zeros à direita deveria repetir a resposta, obtido %d: %s", escala.Code, escala.Body)
+	}
+	if repo.criadas != 1 {
+		t.Errorf("esperada 1 transação criada, obtido %d", repo.criadas)
+	}
+
+	conflitante := postTransacaoIdempotente(servidor, "chave-1", strings.Replace(corpo, `"20"`, `"30"`, 1))
+	if conflitante.Code != http.StatusUnprocessableEntity {
+		t.Errorf("outro corpo com a mesma chave: status esperado 422, obtido %d", conflitante.Code)
+	}
+
+	if outra := postTransacaoIdempotente(servidor, "chave-2", corpo); outra.Code != http.StatusCreated || repo.criadas != 2 {
+		t.Errorf("outra chave deveria criar nova transação, status %d, criadas %d", outra.Code, repo.criadas)
+	}
+}
+
+func TestPostTransacaoIdempotenteEmAndamento(t *testing.T) {
+	servidor, repo := novoServidorComTransacoes()
+	corpo := `{"tipo": "Compra", "moeda_origem": "USD", "moeda_destino": "BRL", "valor_origem": "20"}`
+
+	// Simula a primeira requisição ainda sendo processada
+	var req cambio.CreateTransactionRequest
+	json.Unmarshal([]byte(corpo), &req)
+	canonico, _ := json.Marshal(req)
+	servidor.idempotencia.Reservar(context.Background(), &cambio.RegistroIdempotencia{
+		UserID: 1, Chave: "chave-1", Hash: cambio.HashRequisicao(canonico), CriadoEm: time.Now(),
+	}, time.Now().Add(-time.Hour))
+
+	rec := postTransacaoIdempotente(servidor, "chave-1", corpo)
+	if rec.Code != http.StatusConflict || repo.criadas != 0 {
+		t.Errorf("status esperado 409 sem criar transação, obtido %d (criadas %d)", rec.Code, repo.criadas)
+	}
+}
+
+func TestPostTransacaoIdempotenteRetomaReservaAbandonada(t *testing.T) {
+	servidor, repo := novoServidorComTransacoes()
+	corpo := `{"tipo": "Compra", "moeda_origem": "USD", "moeda_destino": "BRL", "valor_origem": "20"}`
+
+	// Simula uma requisição que morreu sem concluir nem liberar a chave
+	var req cambio.CreateTransactionRequest
+	json.Unmarshal([]byte(corpo), &req)
+	canonico, _ := json.Marshal(req)
+	servidor.idempotencia.Reservar(context.Background(), &cambio.RegistroIdempotencia{
+		UserID: 1, Chave: "chave-1", Hash: cambio.HashRequisicao(canonico),
+		CriadoEm: time.Now().Add(-2 * cambio.PRAZO_RESERVA_IDEMPOTENCIA),
+	}, time.Now().Add(-time.Hour))
+
+	rec := postTransacaoIdempotente(servidor, "chave-1", corpo)
+	if rec.Code != http.StatusCreated || repo.criadas != 1 {
+		t.Fatalf("status esperado 201 criando a transação, obtido %d (criadas %d)", rec.Code, repo.criadas)
+	}
+	if repetida := postTransacaoIdempotente(servidor, "chave-1", corpo); repetida.Header().Get("Idempotent-Replayed") != "true" || repo.criadas != 1 {
+		t.Errorf("repetição deveria receber a resposta guardada, obtido %d (criadas %d)", repetida.Code, repo.criadas)
+	}
+}
+
+func getTransacoes(servidor *CambioServer, consulta string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	rec := httptest.NewRecorder()
+	servidor.GetTransacoes(rec, requisicaoAutenticada(http.MethodGet, "/api/transacoes?"+consulta, 1))
+	var resposta map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&resposta)
+	return rec, resposta
+}
+
+func TestGetTransacoesPaginaPorCursor(t *testing.T) {
+	var transacoes []*cambio.Transaction
+	for id := 1; id <= 3; id++ {
+		transacoes = append(transacoes, &cambio.Transaction{ID: id, UserID: 1})
+	}
+	servidor, repo := novoServidorComTransacoes(transacoes...)
+
+	rec, resposta := getTransacoes(servidor, "limit=2")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status esperado 200, obtido %d: %s", rec.Code, rec.Body)
+	}
+	if len(resposta["transactions"].([]interface{})) != 2 || resposta["total"] != float64(3) {
+		t.Fatalf("esperadas 2 de 3 transações, obtido %+v", resposta)
+	}
+	proximo, ok := resposta["next_cursor"].(string)
+	if !ok || resposta["prev_cursor"] != nil {
+		t.Fatalf("primeira página deveria ter só next_cursor, obtido %+v", resposta)
+	}
+
+	// Páginas seguintes podem dispensar a contagem
+	_, resposta = getTransacoes(servidor, "limit=2&incluir_total=false&cursor="+proximo)
+	lidas := resposta["transactions"].([]interface{})
+	if len(lidas) != 1 || lidas[0].(map[string]interface{})["id"] != float64(1) {
+		t.Errorf("esperada só a transação 1, obtido %+v", lidas)
+	}
+	if resposta["next_cursor"] != nil || resposta["prev_cursor"] == nil || resposta["total"] != nil {
+		t.Errorf("última página deveria ter só prev_cursor e nenhum total, obtido %+v", resposta)
+	}
+	if repo.resumos != 1 {
+		t.Errorf("esperada 1 contagem, obtido %d", repo.resumos)
+	}
+}
+
+func TestGetTransacoesOrdenacaoInvalida(t *testing.T) {
+	servidor, _ := novoServidorComTransacoes()
+
+	for _, consulta := range []string{
+		"ordenar=user_id",
+		"direcao=lado",
+		"cursor=invalido",
+		"ordenar=status&cursor=" + (&cambio.Cursor{Campo: cambio.OrdenarPorData, Decrescente: true, ID: 1}).Codificar(),
+	} {
+		if rec, _ := getTransacoes(servidor, consulta); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status esperado 400, obtido %d", consulta, rec.Code)
+		}
+	}
+}
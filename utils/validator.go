@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"golang-project/moeda"
 )
 
 // Validator interface para tipos que podem ser validados
@@ -47,16 +49,14 @@ func IsValidEmail(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
-// IsValidCurrency valida se é um código de moeda válido
+// IsValidCurrency valida se é um código de moeda habilitado no registro de moedas
 func IsValidCurrency(currency string) bool {
-	validCurrencies := map[string]bool{
-		"USD": true,
-		"EUR": true,
-		"BRL": true,
-		"GBP": true,
-		"JPY": true,
-	}
-	return validCurrencies[strings.ToUpper(currency)]
+	return moeda.Padrao().Habilitada(currency)
+}
+
+// InvalidCurrencyMessage retorna a mensagem de moeda inválida com as moedas aceitas
+func InvalidCurrencyMessage() string {
+	return fmt.Sprintf("moeda inválida (use: %s)", moeda.Padrao().Lista())
 }
 
 // MinLength valida comprimento mínimo de string